@@ -15,6 +15,8 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"gorm.io/gorm/schema"
 
@@ -22,21 +24,30 @@ import (
 
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/mysql"
 	"github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/gorilla/mux"
+	"github.com/islax/microapp/audit"
+	"github.com/islax/microapp/cache"
 	"github.com/islax/microapp/config"
 	microappCtx "github.com/islax/microapp/context"
+	"github.com/islax/microapp/dialect"
 	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/event/handler"
+	"github.com/islax/microapp/event/idempotency"
+	"github.com/islax/microapp/event/monitor"
+	"github.com/islax/microapp/event/outbox"
+	"github.com/islax/microapp/event/transport"
+	"github.com/islax/microapp/health"
+	"github.com/islax/microapp/lifecycle"
 	"github.com/islax/microapp/log"
 	"github.com/islax/microapp/metrics"
 	"github.com/islax/microapp/repository"
+	"github.com/islax/microapp/repository/retention"
 	"github.com/islax/microapp/retry"
 	"github.com/islax/microapp/security"
-	gormmysqldriver "gorm.io/driver/mysql"
+	"github.com/islax/microapp/tracing"
 	"gorm.io/gorm"
 
-	gomysqldriver "github.com/go-sql-driver/mysql"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	uuid "github.com/satori/go.uuid"
@@ -60,10 +71,43 @@ type App struct {
 	server          *http.Server
 	log             zerolog.Logger
 	eventDispatcher event.Dispatcher
+	eventTransport  transport.Transport
+	eventOutbox     *outbox.Relay
+
+	idempotencyStore  idempotency.Store
+	idempotencyPurger *idempotency.Purger
+
+	lifecycle        *lifecycle.Manager
+	monitorSequence  int32
+	draining         int32
+	shutdownOnce     sync.Once
+	shutdownComplete chan struct{}
+
+	healthChecks *health.Checks
+
+	tracingShutdown func(ctx context.Context) error
+
+	auditRecorder *audit.Recorder
+
+	replicaRouter   *repository.ReplicaRouter
+	retentionWorker *retention.Worker
+	dialect         dialect.Dialect
+	cache           *cache.Client
 }
 
 // NewWithEnvValues creates a new application with environment variable values for initializing database, event dispatcher and logger.
+// It exits the application if any of the components fails to start.
 func NewWithEnvValues(appName string, appConfigDefaults map[string]interface{}) *App {
+	app, err := TryNewWithEnvValues(appName, appConfigDefaults)
+	if err != nil {
+		log.New(appName, "error", os.Stdout).Fatal().Err(err).Msg("Failed to start, exiting the application!!")
+	}
+	return app
+}
+
+// TryNewWithEnvValues creates a new application with environment variable values for initializing database, event dispatcher and logger.
+// Components are started in the order of their dependencies within ISLA_STARTUP_TIMEOUT, if any of them fails the components already started are stopped and the error is returned.
+func TryNewWithEnvValues(appName string, appConfigDefaults map[string]interface{}) (*App, error) {
 	appConfig := config.NewConfig(appConfigDefaults)
 	printMicroAppVersion(appConfig)
 	log.InitializeGlobalSettings()
@@ -80,9 +124,13 @@ func NewWithEnvValues(appName string, appConfigDefaults map[string]interface{})
 
 	var err error
 	var appEventDispatcher event.Dispatcher
+	var appEventTransport transport.Transport
 	if appConfig.GetStringWithDefault("ENABLE_EVENT_DISPATCHER", "0") == "1" || appConfig.GetStringWithDefault("LOG_TO_EVENTQ", "0") == "1" {
-		if appEventDispatcher, err = event.NewRabbitMQEventDispatcher(consoleOnlyLogger); err != nil {
-			consoleOnlyLogger.Fatal().Err(err).Msg("Failed to initialize event dispatcher to queue, exiting the application!")
+		if appEventTransport, err = transport.New(appName, appConfig); err != nil {
+			return nil, fmt.Errorf("failed to initialize event transport: %v", err)
+		}
+		if appEventDispatcher, err = appEventTransport.NewDispatcher(consoleOnlyLogger); err != nil {
+			return nil, fmt.Errorf("failed to initialize event dispatcher to queue: %v", err)
 		}
 		if appConfig.GetStringWithDefault("LOG_TO_EVENTQ", "0") == "1" {
 			multiWriters = io.MultiWriter(os.Stdout, event.NewEventQWriter(appEventDispatcher))
@@ -95,90 +143,420 @@ func NewWithEnvValues(appName string, appConfigDefaults map[string]interface{})
 	}
 	//TODO: default module to system
 	appLogger := log.New(appName, appConfig.GetString("LOG_LEVEL"), multiWriters)
-	//TODO: Need to wait till eventDispatcher is ready
-	time.Sleep(5 * time.Second)
 
-	app := App{Name: appName, Config: appConfig, log: *appLogger, eventDispatcher: appEventDispatcher}
-	err = app.initializeDB()
-	if err != nil {
-		consoleOnlyLogger.Fatal().Err(err).Msg("Failed to initialize database, exiting the application!!")
+	app := App{Name: appName, Config: appConfig, log: *appLogger, eventDispatcher: appEventDispatcher, eventTransport: appEventTransport, lifecycle: lifecycle.NewManager(*appLogger), shutdownComplete: make(chan struct{})}
+	if app.tracingShutdown, err = tracing.Init(tracing.ConfigFromEnv(appName, appConfig)); err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %v", err)
 	}
+	app.registerComponents()
 
-	err = app.initializeMemcache()
-	if err != nil {
-		consoleOnlyLogger.Fatal().Err(err).Msg("Failed to initialize memcached, exiting the application!!")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(appConfig.GetInt(config.EvSuffixForStartupTimeout))*time.Second)
+	defer cancel()
+	if err := app.lifecycle.Start(ctx); err != nil {
+		app.tracingShutdown(context.Background())
+		return nil, err
 	}
+	app.registerHealthChecks()
 
 	tlsConfig, err := app.setTLSClientConfig()
 	if err != nil {
-		consoleOnlyLogger.Fatal().Err(err).Msg("Failed to set TLS Client Config, exiting the application!!")
+		app.Stop()
+		return nil, fmt.Errorf("failed to set TLS Client Config: %v", err)
 	}
 	http.DefaultTransport.(*http.Transport).TLSClientConfig = tlsConfig
 
-	return &app
+	return &app, nil
 }
 
 // New creates a new microApp
 func New(appName string, appConfigDefaults map[string]interface{}, appLog zerolog.Logger, appDB *gorm.DB, appMemcache *memcache.Client, appEventDispatcher event.Dispatcher) *App {
 	appConfig := config.NewConfig(appConfigDefaults)
-	return &App{Name: appName, Config: appConfig, log: appLog, DB: appDB, MemcachedClient: appMemcache, eventDispatcher: appEventDispatcher}
+	app := &App{Name: appName, Config: appConfig, log: appLog, DB: appDB, MemcachedClient: appMemcache, eventDispatcher: appEventDispatcher, lifecycle: lifecycle.NewManager(appLog), shutdownComplete: make(chan struct{})}
+	if err := app.useRowVersion(appDB); err != nil {
+		appLog.Error().Err(err).Msg("Failed to enable row versioning.")
+	}
+	if err := app.useTenantIsolation(appDB); err != nil {
+		appLog.Error().Err(err).Msg("Failed to enable tenant isolation.")
+	}
+	app.registerProvidedComponents()
+	if err := app.lifecycle.Start(context.Background()); err != nil {
+		appLog.Error().Err(err).Msg("Failed to start the components.")
+	}
+	app.registerHealthChecks()
+	return app
 }
 
 func (app *App) initializeDB() error {
 	if app.Config.GetBool(config.EvSuffixForDBRequired) {
-		var db *gorm.DB
-		err := retry.Do(3, time.Second*15, func() error {
-			//gorm custom logger
-			dbLogger := log.NewGormLogger(app.log, log.Config{SlowThreshold: time.Duration(app.Config.GetInt(config.EvSuffixForGormSlowThreshold)) * time.Millisecond})
-			var err error
-			dbconf := &gorm.Config{PrepareStmt: true, Logger: dbLogger}
-
-			if app.Config.GetBool("DB_NAMING_STRATEGY_IS_SINGULAR") {
-				dbconf.NamingStrategy = schema.NamingStrategy{SingularTable: true}
-			}
+		dbDialect, err := app.getDialect()
+		if err != nil {
+			return err
+		}
+		if err = dbDialect.ConfigureTLS(dialect.TLSConfig{CAPath: app.Config.GetString("DB_SSL_CA_PATH"), CertPath: app.Config.GetString("DB_SSL_CERT_PATH"), KeyPath: app.Config.GetString("DB_SSL_KEY_PATH")}); err != nil {
+			app.log.Warn().Err(err).Msgf("TLS config error [%v]. Connecting without certificates", err)
+		}
+		db, err := app.openDB(app.GetConnectionString())
+		if err != nil {
+			return err
+		}
+		app.DB = db
+		app.log.Info().Str("dialect", dbDialect.Name()).Msg("Database connected!")
+	}
+	return nil
+}
 
-			if err = registerTLSConfig(app.Config.GetString("DB_SSL_CA_PATH"), app.Config.GetString("DB_SSL_CERT_PATH"), app.Config.GetString("DB_SSL_KEY_PATH")); err != nil {
-				app.log.Warn().Err(err).Msgf("TLS config error [%v]. Connecting without certificates", err)
-			}
+// getDialect returns the database dialect of ISLA_DB_DIALECT
+func (app *App) getDialect() (dialect.Dialect, error) {
+	if app.dialect == nil {
+		dbDialect, err := dialect.New(app.Config.GetString(config.EvSuffixForDBDialect))
+		if err != nil {
+			return nil, err
+		}
+		app.dialect = dbDialect
+	}
+	return app.dialect, nil
+}
 
-			sqlDB, err := sql.Open("mysql", app.GetConnectionString())
-			if err != nil {
-				app.log.Error().Err(err).Msgf("Error creating connection pool [%v]. Trying again...", err)
-			}
-			sqlDB.SetConnMaxLifetime(time.Duration(app.Config.GetInt(config.EvSuffixForDBConnectionLifetime)) * time.Minute)
-			sqlDB.SetMaxIdleConns(app.Config.GetInt(config.EvSuffixForDBMaxIdleConnections))
-			sqlDB.SetMaxOpenConns(app.Config.GetInt(config.EvSuffixForDBMaxOpenConnections))
-			db, err = gorm.Open(gormmysqldriver.New(gormmysqldriver.Config{
-				Conn: sqlDB,
-			}), dbconf)
-			if err != nil && strings.Contains(err.Error(), "connection refused") {
-				app.log.Warn().Msgf("Error connecting to Database [%v]. Trying again...", err)
-				return err
-			}
+// openDB connects to the database of the connection string, retrying if the connection is refused
+func (app *App) openDB(connectionString string) (*gorm.DB, error) {
+	dbDialect, err := app.getDialect()
+	if err != nil {
+		return nil, err
+	}
+	var db *gorm.DB
+	err = retry.Do(3, time.Second*15, func() error {
+		//gorm custom logger
+		dbLogger := log.NewGormLogger(app.log, log.Config{SlowThreshold: time.Duration(app.Config.GetInt(config.EvSuffixForGormSlowThreshold)) * time.Millisecond})
+		var err error
+		dbconf := &gorm.Config{PrepareStmt: true, Logger: dbLogger}
+
+		if app.Config.GetBool("DB_NAMING_STRATEGY_IS_SINGULAR") {
+			dbconf.NamingStrategy = schema.NamingStrategy{SingularTable: true}
+		}
 
-			return retry.Stop{OriginalError: err}
+		db, err = dialect.Open(dbDialect, connectionString, dbconf, dialect.PoolConfig{
+			MaxOpenConnections: app.Config.GetInt(config.EvSuffixForDBMaxOpenConnections),
+			MaxIdleConnections: app.Config.GetInt(config.EvSuffixForDBMaxIdleConnections),
+			ConnectionLifetime: time.Duration(app.Config.GetInt(config.EvSuffixForDBConnectionLifetime)) * time.Minute,
 		})
-		app.DB = db
-		app.log.Info().Msg("Database connected!")
+		if err != nil && strings.Contains(err.Error(), "connection refused") {
+			app.log.Warn().Msgf("Error connecting to Database [%v]. Trying again...", err)
+			return err
+		}
+
+		return retry.Stop{OriginalError: err}
+	})
+	if err == nil {
+		err = db.Use(tracing.NewGormPlugin())
+	}
+	if err == nil {
+		err = app.useRowVersion(db)
+	}
+	if err == nil {
+		err = app.useTenantIsolation(db)
+	}
+	return db, err
+}
+
+// initializeDBReplicas connects to the replicas of ISLA_DB_REPLICA_HOSTS and routes the read-only units of work to them.
+// Replicas which fail to connect are skipped, reads fall back to the primary if none is available.
+func (app *App) initializeDBReplicas() error {
+	hosts := app.Config.GetString(config.EvSuffixForDBReplicaHosts)
+	if app.DB == nil || strings.TrimSpace(hosts) == "" {
+		return nil
+	}
+
+	replicas := make([]*repository.Replica, 0)
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		port := app.Config.GetString(config.EvSuffixForDBPort)
+		if replicaHost, replicaPort, err := net.SplitHostPort(host); err == nil {
+			host, port = replicaHost, replicaPort
+		}
+		db, err := app.openDB(app.connectionString(host, port))
+		if err != nil {
+			app.log.Warn().Err(err).Str("replica", host).Msg("Error connecting to database replica, skipping it.")
+			continue
+		}
+		replicas = append(replicas, repository.NewReplica(net.JoinHostPort(host, port), db))
+	}
+	if len(replicas) == 0 {
+		app.log.Warn().Msg("No database replica is available, reads are routed to the primary.")
+		return nil
+	}
+
+	router, err := repository.NewReplicaRouter(app.DB, replicas, repository.ReplicaRouterConfig{
+		Selection:           app.Config.GetString(config.EvSuffixForDBReplicaSelection),
+		Stickiness:          time.Duration(app.Config.GetInt(config.EvSuffixForDBReplicaStickiness)) * time.Second,
+		HealthCheckInterval: time.Duration(app.Config.GetInt(config.EvSuffixForDBReplicaHealthCheckInterval)) * time.Second,
+	}, app.log)
+	if err != nil {
 		return err
 	}
+	router.Start()
+	app.replicaRouter = router
+	app.log.Info().Int("replicas", len(replicas)).Msg("Database replicas connected!")
 	return nil
 }
 
+// closeDBReplicas stops routing to the replicas and closes their connection pools
+func (app *App) closeDBReplicas() error {
+	if app.replicaRouter == nil {
+		return nil
+	}
+	app.replicaRouter.Stop()
+	var closeErr error
+	for _, replica := range app.replicaRouter.Replicas() {
+		if sqlDB, err := replica.DB.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				closeErr = err
+			}
+		}
+	}
+	app.replicaRouter = nil
+	return closeErr
+}
+
+// initializeEventOutbox prepares the outbox table and starts relaying the staged events to the event dispatcher
+func (app *App) initializeEventOutbox() error {
+	if !app.Config.GetBool(config.EvSuffixForEnableEventOutbox) {
+		return nil
+	}
+	if app.DB == nil || app.eventDispatcher == nil {
+		app.log.Warn().Msg("Event outbox requires database and event dispatcher. Please set ISLA_DB_REQUIRED and ISLA_ENABLE_EVENT_DISPATCHER to enable it.")
+		return nil
+	}
+
+	publisher, ok := app.eventDispatcher.(event.Publisher)
+	if !ok {
+		return errors.New("event outbox requires an event dispatcher which reports publish failures")
+	}
+
+	if err := outbox.Migrate(app.DB); err != nil {
+		return err
+	}
+
+	app.eventOutbox = outbox.NewRelay(app.DB, publisher, app.log, outbox.RelayConfig{
+		PollInterval: time.Duration(app.Config.GetInt(config.EvSuffixForEventOutboxPollInterval)) * time.Second,
+		BatchSize:    app.Config.GetInt(config.EvSuffixForEventOutboxBatchSize),
+		MaxAttempts:  app.Config.GetInt(config.EvSuffixForEventOutboxMaxAttempts),
+		Retention:    time.Duration(app.Config.GetInt(config.EvSuffixForEventOutboxRetention)) * time.Hour,
+	})
+	app.eventOutbox.Start()
+	app.log.Info().Msg("Event outbox relay started!")
+	return nil
+}
+
+// initializeAuditTrail prepares the audit trail table and the recorder used by the audited repositories
+func (app *App) initializeAuditTrail() error {
+	if !app.Config.GetBool(config.EvSuffixForAuditTrail) {
+		return nil
+	}
+	if app.DB == nil {
+		app.log.Warn().Msg("Audit trail requires database. Please set ISLA_DB_REQUIRED to enable it.")
+		return nil
+	}
+
+	if err := audit.Migrate(app.DB); err != nil {
+		return err
+	}
+	app.auditRecorder = audit.NewRecorder(audit.Config{
+		EventTopic:    app.Config.GetString(config.EvSuffixForAuditEventTopic),
+		DispatchEvent: app.DispatchEventWithUOW,
+	})
+	app.log.Info().Msg("Audit trail initialized!")
+	return nil
+}
+
+// initializeSoftDeleteRetention starts purging the soft deleted records of the tables in ISLA_SOFT_DELETE_RETENTION
+func (app *App) initializeSoftDeleteRetention() error {
+	policiesConfig := strings.TrimSpace(app.Config.GetString(config.EvSuffixForSoftDeleteRetention))
+	if policiesConfig == "" {
+		return nil
+	}
+	if app.DB == nil {
+		app.log.Warn().Msg("Soft delete retention requires database. Please set ISLA_DB_REQUIRED to enable it.")
+		return nil
+	}
+
+	policies := make([]retention.Policy, 0)
+	for _, policyConfig := range strings.Split(policiesConfig, ",") {
+		tableAndHours := strings.Split(strings.TrimSpace(policyConfig), ":")
+		if len(tableAndHours) != 2 {
+			return fmt.Errorf("invalid soft delete retention [%v], expected table:hours", policyConfig)
+		}
+		hours, err := strconv.Atoi(strings.TrimSpace(tableAndHours[1]))
+		if err != nil || hours < 0 {
+			return fmt.Errorf("invalid soft delete retention hours [%v] of table %v", tableAndHours[1], tableAndHours[0])
+		}
+		policies = append(policies, retention.Policy{Table: strings.TrimSpace(tableAndHours[0]), Age: time.Duration(hours) * time.Hour})
+	}
+
+	app.retentionWorker = retention.NewWorker(app.DB, policies, retention.Config{
+		Interval:  time.Duration(app.Config.GetInt(config.EvSuffixForSoftDeleteRetentionInterval)) * time.Minute,
+		BatchSize: app.Config.GetInt(config.EvSuffixForSoftDeleteRetentionBatchSize),
+		DryRun:    app.Config.GetBool(config.EvSuffixForSoftDeleteRetentionDryRun),
+	}, app.log)
+	app.retentionWorker.Start()
+	app.log.Info().Int("policies", len(policies)).Msg("Soft delete retention started!")
+	return nil
+}
+
+// AuditRecorder returns the recorder of the audit trail which can be used to query it, nil if ISLA_AUDIT_TRAIL is not set
+func (app *App) AuditRecorder() *audit.Recorder {
+	return app.auditRecorder
+}
+
+// NewAuditedRepository returns a repository which records the changes made by Update, Upsert, UpdateBatch, UpsertBatch, CheckVersionAndUpdate and Delete in the audit trail.
+// Changes are not recorded if ISLA_AUDIT_TRAIL is not set.
+func (app *App) NewAuditedRepository() *repository.GormRepository {
+	if app.auditRecorder == nil {
+		return &repository.GormRepository{}
+	}
+	return repository.NewAuditedRepository(app.auditRecorder)
+}
+
+// initializeCache creates the cache used by the cached repositories, memcached if it is required else in-memory LRU.
+// The invalidation of the in-memory cache is not seen by the other instances of the app, so its records live for at most ISLA_CACHE_LRU_TTL (5 seconds by default).
+func (app *App) initializeCache() error {
+	ttl := time.Duration(app.Config.GetInt(config.EvSuffixForCacheTTL)) * time.Second
+	if app.MemcachedClient != nil {
+		app.cache = cache.NewClient(cache.NewMemcached(app.MemcachedClient), app.Name, ttl)
+		app.log.Info().Msg("Cache initialized with memcached!")
+		return nil
+	}
+	if lruTTL := time.Duration(app.Config.GetInt(config.EvSuffixForCacheLRUTTL)) * time.Second; lruTTL < ttl {
+		ttl = lruTTL
+	}
+	app.cache = cache.NewClient(cache.NewLRU(app.Config.GetInt(config.EvSuffixForCacheLRUSize)), app.Name, ttl)
+	app.log.Info().Msg("Cache initialized in memory!")
+	return nil
+}
+
+// Cache returns the cache-aside client for caching the reads, it caches in memcached if ISLA_MEMCACHED_REQUIRED is set else in memory
+func (app *App) Cache() *cache.Client {
+	return app.cache
+}
+
+// NewCachedRepository returns a repository whose reads by id in the read-only units of work are cached for ISLA_CACHE_TTL,
+// the records are invalidated once the units of work updating or deleting them are committed.
+// Without memcached the invalidation is local to the instance, the other instances may read the stale records for up to ISLA_CACHE_LRU_TTL.
+// Changes are recorded in the audit trail if ISLA_AUDIT_TRAIL is set.
+func (app *App) NewCachedRepository() *repository.GormRepository {
+	cachedRepository := &repository.GormRepository{}
+	cachedRepository.SetCache(app.cache)
+	if app.auditRecorder != nil {
+		cachedRepository.SetChangeRecorder(app.auditRecorder)
+	}
+	return cachedRepository
+}
+
 // GetConnectionString gets database connection string
 func (app *App) GetConnectionString() string {
-	dbHost := app.Config.GetString("DB_HOST")
-	dbName := app.Config.GetString("DB_NAME")
-	dbPort := app.Config.GetString("DB_PORT")
-	dbUser := app.Config.GetString("DB_USER")
-	dbPassword := app.Config.GetString("DB_PWD")
+	return app.connectionString(app.Config.GetString("DB_HOST"), app.Config.GetString("DB_PORT"))
+}
 
-	return fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?multiStatements=true&charset=utf8&parseTime=True&loc=Local&tls=preferred", dbUser, dbPassword, dbHost, dbPort, dbName)
+// connectionString gets database connection string of the host in ISLA_DB_DIALECT, used for the primary and the replicas
+func (app *App) connectionString(dbHost string, dbPort string) string {
+	dbDialect, err := app.getDialect()
+	if err != nil {
+		app.log.Error().Err(err).Msg("Unable to build database connection string.")
+		return ""
+	}
+	return dbDialect.DSN(dialect.Connection{
+		Host:     dbHost,
+		Port:     dbPort,
+		User:     app.Config.GetString("DB_USER"),
+		Password: app.Config.GetString("DB_PWD"),
+		Name:     app.Config.GetString("DB_NAME"),
+	})
+}
+
+// useRowVersion increments the version of the versioned entities on every update and upsert
+func (app *App) useRowVersion(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	if err := db.Use(repository.NewRowVersionPlugin()); err != nil && err != gorm.ErrRegistered {
+		return err
+	}
+	return nil
+}
+
+// useTenantIsolation restricts the statements on tenant scoped entities to the tenant of the unit of work if ISLA_TENANT_ISOLATION is set
+func (app *App) useTenantIsolation(db *gorm.DB) error {
+	if db == nil || !app.Config.GetBool(config.EvSuffixForTenantIsolation) {
+		return nil
+	}
+	if err := db.Use(repository.NewTenantIsolationPlugin()); err != nil && err != gorm.ErrRegistered {
+		return err
+	}
+	return nil
 }
 
 // NewUnitOfWork creates new UnitOfWork
 func (app *App) NewUnitOfWork(readOnly bool, logger zerolog.Logger) *repository.UnitOfWork {
-	return repository.NewUnitOfWork(app.DB, readOnly, logger, log.Config{SlowThreshold: time.Duration(app.Config.GetInt(config.EvSuffixForGormSlowThreshold)) * time.Millisecond})
+	return app.NewUnitOfWorkWithContext(context.Background(), readOnly, logger)
+}
+
+// NewUnitOfWorkWithContext creates new UnitOfWork whose statements are part of the trace of the context
+// Read-only units of work are routed to the replicas if ISLA_DB_REPLICA_HOSTS is set, a read failing on a replica with connection error is run again on the primary.
+func (app *App) NewUnitOfWorkWithContext(ctx context.Context, readOnly bool, logger zerolog.Logger) *repository.UnitOfWork {
+	logConfig := log.Config{SlowThreshold: time.Duration(app.Config.GetInt(config.EvSuffixForGormSlowThreshold)) * time.Millisecond}
+	router := app.replicaRouter
+	if router == nil {
+		return repository.NewUnitOfWorkWithContext(ctx, app.DB, readOnly, logger, logConfig)
+	}
+	if readOnly {
+		return repository.NewUnitOfWorkWithContext(ctx, router.ReadDB(ctx), readOnly, logger, logConfig)
+	}
+	uow := repository.NewUnitOfWorkWithContext(ctx, app.DB, readOnly, logger, logConfig)
+	uow.AfterCommit(func() { router.RecordWrite(ctx) })
+	return uow
+}
+
+// RunInTransaction runs fn in a new UnitOfWork which is committed if fn succeeds.
+// The transaction is retried with jittered backoff up to ISLA_TRANSACTION_RETRY_ATTEMPTS times if fn or commit fails with deadlock, lock wait timeout or serialization failure,
+// fn should therefore be safe to run again. Other errors are returned as is, the wait before retry ends with the error of ctx once ctx is done.
+// The unit of work is neither restricted to a tenant nor has an actor unless ctx carries them (repository.WithTenant, repository.WithActor),
+// use RunInTransactionWithToken for the tenant and user of a token.
+func (app *App) RunInTransaction(ctx context.Context, fn func(uow *repository.UnitOfWork) error) error {
+	logger := app.log.With().Str("correlationId", repository.CorrelationIDFromContext(ctx)).Logger()
+	attempt := 0
+	err := retry.DoWithJitterContext(ctx, app.Config.GetInt(config.EvSuffixForTransactionRetryAttempts), time.Duration(app.Config.GetInt(config.EvSuffixForTransactionRetryDelay))*time.Millisecond, func() error {
+		attempt++
+		if err := ctx.Err(); err != nil {
+			return retry.Stop{OriginalError: err}
+		}
+
+		uow := app.NewUnitOfWorkWithContext(ctx, false, logger)
+		defer uow.Complete()
+		err := fn(uow)
+		if err == nil {
+			err = uow.TryCommit()
+		}
+		if err != nil && !dialect.IsRetryable(err) {
+			return retry.Stop{OriginalError: err}
+		}
+		if err != nil {
+			logger.Warn().Err(err).Int("attempt", attempt).Msg("Transaction failed with retryable error.")
+		}
+		return err
+	})
+	if err == nil && attempt > 1 {
+		logger.Info().Int("retries", attempt-1).Msg("Transaction succeeded after retries.")
+	} else if err != nil && attempt > 1 {
+		logger.Error().Err(err).Int("retries", attempt-1).Msg("Transaction failed after retries.")
+	}
+	return err
+}
+
+// RunInTransactionWithToken runs fn like RunInTransaction in the unit of work restricted to the tenant of the token and recording the changes on behalf of its user
+func (app *App) RunInTransactionWithToken(ctx context.Context, token *security.JwtToken, fn func(uow *repository.UnitOfWork) error) error {
+	return app.RunInTransaction(withToken(ctx, token), fn)
 }
 
 //Initialize initializes properties of the app
@@ -189,13 +567,35 @@ func (app *App) Initialize(routeSpecifiers []RouteSpecifier) {
 	app.Router.Use(mux.CORSMethodMiddleware(app.Router))
 
 	for _, routeSpecifier := range routeSpecifiers {
+		// Health controller created without checks serves the checks of the app
+		if healthController, ok := routeSpecifier.(interface{ SetDefaultChecks(*health.Checks) }); ok {
+			healthController.SetDefaultChecks(app.healthChecks)
+		}
 		routeSpecifier.RegisterRoutes(app.Router)
 	}
 
 	//prometheus
 	if app.Config.GetBool(config.EvSuffixForEnableMetrics) {
 		if app.Config.GetBool(config.EvSuffixForDBRequired) {
-			metrics.RegisterGormMetrics(app.DB, app.Config)
+			metricsCtx, stopMetrics := context.WithCancel(context.Background())
+			app.RegisterComponent(lifecycle.Component{
+				Name: "gormMetrics",
+				Start: func(ctx context.Context) error {
+					if app.replicaRouter == nil {
+						return metrics.RegisterGormMetricsWithContext(metricsCtx, app.DB, app.Config)
+					}
+					if err := metrics.RegisterGormMetricsWithLabels(metricsCtx, app.DB, app.Config, map[string]string{"db": "primary"}); err != nil {
+						return err
+					}
+					for _, replica := range app.replicaRouter.Replicas() {
+						if err := metrics.RegisterGormMetricsWithLabels(metricsCtx, replica.DB, app.Config, map[string]string{"db": replica.Name}); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+				Stop: func(ctx context.Context) error { stopMetrics(); return nil },
+			})
 		}
 		// Create our middleware.
 		mdlw := middleware.New(middleware.Config{
@@ -206,6 +606,7 @@ func (app *App) Initialize(routeSpecifiers []RouteSpecifier) {
 		app.Router.Path("/metrics").Handler(promhttp.Handler())
 	}
 
+	app.Router.Use(tracing.Middleware(app.Name))
 	app.Router.Use(app.loggingMiddleware)
 
 	//TODO: Revisit this logic
@@ -234,10 +635,12 @@ func (app *App) Start() {
 	if app.Config.GetBool(config.EvSuffixForEnableTLS) {
 		app.StartSecure(app.Config.GetString(config.EvSuffixForTLSCert), app.Config.GetString(config.EvSuffixForTLSKey))
 	} else {
+		app.registerServer()
 		if err := app.server.ListenAndServe(); err != nil {
 			if err != http.ErrServerClosed {
 				app.log.Fatal().Err(err).Msg("Unable to start server, exiting the application!")
 			}
+			<-app.shutdownComplete
 		}
 	}
 }
@@ -268,8 +671,12 @@ func (app *App) StartSecure(tlsCert string, tlsKey string) {
 		app.log.Fatal().Msg("TLS_KEY is not defined or empty, exiting the application!")
 	}
 
+	app.registerServer()
 	if err := app.server.ListenAndServeTLS(tlsCert, tlsKey); err != nil {
-		app.log.Fatal().Err(err).Msg("Unable to start server or server stopped, exiting the application!")
+		if err != http.ErrServerClosed {
+			app.log.Fatal().Err(err).Msg("Unable to start server or server stopped, exiting the application!")
+		}
+		<-app.shutdownComplete
 	}
 }
 
@@ -290,15 +697,19 @@ func (app *App) MigrateDB() {
 		logger.Info().Msg("DB Migration End!")
 		return
 	}
-	migrateDB, err := sql.Open("mysql", app.GetConnectionString())
+	dbDialect, err := app.getDialect()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Unable to determine DB dialect for migration, exiting the application!")
+	}
+	migrateDB, err := sql.Open(dbDialect.DriverName(), app.GetConnectionString())
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Unable to open DB connection for migration, exiting the application!")
 	}
-	migrateDBDriver, err := mysql.WithInstance(migrateDB, &mysql.Config{})
+	migrateDBDriver, err := dbDialect.MigrationDriver(migrateDB)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Unable to prepare DB instance for migration, exiting the application!")
 	}
-	m, err := migrate.NewWithInstance("file", fsrc, "mysql", migrateDBDriver)
+	m, err := migrate.NewWithInstance("file", fsrc, dbDialect.Name(), migrateDBDriver)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Unable to initialize DB instance for migration, exiting the application!")
 	}
@@ -315,19 +726,13 @@ func (app *App) MigrateDB() {
 	logger.Info().Msg("DB Migration End!")
 }
 
-// Stop http server
+// Stop stops the http server and all the components of the app within ISLA_SHUTDOWN_TIMEOUT
 func (app *App) Stop() {
-	wait, _ := time.ParseDuration("2m")
-	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(app.Config.GetInt(config.EvSuffixForShutdownTimeout))*time.Second)
 	defer cancel()
 
-	app.server.Shutdown(ctx)
-
-	if app.Config.GetBool("DB_REQUIRED") {
-		sqlDB, err := app.DB.DB()
-		if err != nil {
-			sqlDB.Close()
-		}
+	if err := app.Shutdown(ctx); err != nil {
+		app.log.Error().Err(err).Msg("Failed to stop the application gracefully.")
 	}
 }
 
@@ -345,6 +750,10 @@ func (rec *httpStatusRecorder) WriteHeader(code int) {
 	rec.ResponseWriter.WriteHeader(code)
 }
 
+func isHealthRequest(requestURI string) bool {
+	return strings.HasSuffix(requestURI, "/health") || strings.HasSuffix(requestURI, "/health/live") || strings.HasSuffix(requestURI, "/health/ready")
+}
+
 func (app *App) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
@@ -353,13 +762,16 @@ func (app *App) loggingMiddleware(next http.Handler) http.Handler {
 			r.Header.Set("X-Correlation-ID", uuid.NewV4().String())
 		}
 		logger := app.Logger("Ingress").With().Timestamp().Str("caller", r.Header.Get("X-Client")).Str("correlationId", r.Header.Get("X-Correlation-ID")).Str("method", r.Method).Str("requestURI", r.RequestURI).Logger()
+		if traceID := tracing.TraceID(r.Context()); traceID != "" {
+			logger = logger.With().Str("traceId", traceID).Logger()
+		}
 
 		rec := &httpStatusRecorder{ResponseWriter: w}
-		if (!strings.HasSuffix(r.RequestURI, "/health") || app.Config.GetBool(config.EvSuffixForEnableHealthLog)) && !strings.HasSuffix(r.RequestURI, "/metrics") {
+		if (!isHealthRequest(r.RequestURI) || app.Config.GetBool(config.EvSuffixForEnableHealthLog)) && !strings.HasSuffix(r.RequestURI, "/metrics") {
 			logger.Info().Msg("Begin")
 		}
 		next.ServeHTTP(rec, r)
-		if (!strings.HasSuffix(r.RequestURI, "/health") || app.Config.GetBool(config.EvSuffixForEnableHealthLog)) && !strings.HasSuffix(r.RequestURI, "/metrics") {
+		if (!isHealthRequest(r.RequestURI) || app.Config.GetBool(config.EvSuffixForEnableHealthLog)) && !strings.HasSuffix(r.RequestURI, "/metrics") {
 			if rec.status >= http.StatusInternalServerError {
 				logger.Error().Int("status", rec.status).Dur("responseTime", time.Now().Sub(startTime)).Msg("End.")
 			} else {
@@ -376,34 +788,146 @@ func (app *App) DispatchEvent(token string, corelationID string, topic string, p
 	}
 }
 
+// DispatchEventWithUOW dispatches the event only after the given unit of work is committed.
+// If event outbox is enabled, the event is staged in the outbox as part of the unit of work and relayed in background.
+func (app *App) DispatchEventWithUOW(uow *repository.UnitOfWork, token string, correlationID string, topic string, payload interface{}) error {
+	if app.eventOutbox != nil {
+		if _, err := outbox.Add(uow, token, correlationID, topic, payload); err != nil {
+			return err
+		}
+		uow.AfterCommit(app.eventOutbox.Notify)
+		return nil
+	}
+
+	ctx := uow.DB.Statement.Context
+	uow.AfterCommit(func() {
+		app.DispatchEventWithContext(ctx, token, correlationID, topic, payload)
+	})
+	return nil
+}
+
+// DispatchEventWithContext delegates to eventDispatcher, trace of the context is propagated to the consumers of the event if the dispatcher supports it.
+func (app *App) DispatchEventWithContext(ctx context.Context, token string, corelationID string, topic string, payload interface{}) {
+	if app.eventDispatcher == nil {
+		return
+	}
+	if contextDispatcher, ok := app.eventDispatcher.(event.ContextDispatcher); ok && ctx != nil {
+		contextDispatcher.DispatchEventWithContext(ctx, token, corelationID, topic, payload)
+		return
+	}
+	app.eventDispatcher.DispatchEvent(token, corelationID, topic, payload)
+}
+
+// TryDispatchEvent dispatches the event without blocking the caller, returns error if the event dispatcher can not accept the event
+func (app *App) TryDispatchEvent(token string, corelationID string, topic string, payload interface{}) error {
+	if app.eventDispatcher == nil {
+		return nil
+	}
+	if nonBlockingDispatcher, ok := app.eventDispatcher.(event.NonBlockingDispatcher); ok {
+		return nonBlockingDispatcher.TryDispatchEvent(token, corelationID, topic, payload)
+	}
+	app.eventDispatcher.DispatchEvent(token, corelationID, topic, payload)
+	return nil
+}
+
+// UseEventTransport makes the app publish events through the given transport
+func (app *App) UseEventTransport(eventTransport transport.Transport) error {
+	eventDispatcher, err := eventTransport.NewDispatcher(&app.log)
+	if err != nil {
+		return err
+	}
+	app.eventTransport = eventTransport
+	app.eventDispatcher = eventDispatcher
+	return nil
+}
+
+// NewEventMonitor creates a new event monitor on the configured event transport, that publishes received events from a named queue to the specified channel
+func (app *App) NewEventMonitor(queueName string, eventsToMonitor []string, eventSignal chan *monitor.EventInfo) (monitor.EventMonitor, error) {
+	if app.eventTransport == nil {
+		eventTransport, err := transport.New(app.Name, app.Config)
+		if err != nil {
+			return nil, err
+		}
+		app.eventTransport = eventTransport
+	}
+	eventMonitor, err := app.eventTransport.NewMonitor(&app.log, queueName, eventsToMonitor, eventSignal)
+	if err != nil {
+		return nil, err
+	}
+	app.RegisterComponent(lifecycle.Component{
+		Name: fmt.Sprintf("eventMonitor:%v#%v", queueName, atomic.AddInt32(&app.monitorSequence, 1)),
+		Stop: func(ctx context.Context) error { eventMonitor.Stop(); return nil },
+	})
+	if connection, ok := eventMonitor.(event.ConnectionStater); ok {
+		app.healthChecks.AddReadinessCheck(health.RabbitMQChecker("eventMonitor:"+queueName, connection))
+	}
+	return eventMonitor, nil
+}
+
+// EventOutbox returns the event outbox relay, nil if event outbox is not enabled
+func (app *App) EventOutbox() *outbox.Relay {
+	return app.eventOutbox
+}
+
 // NewExecutionContext creates new exectuion context
 func (app *App) NewExecutionContext(token *security.JwtToken, correlationID string, action string, isUOWReqd, isUOWReadonly bool) microappCtx.ExecutionContext {
-	executionContext := microappCtx.NewExecutionContext(token, correlationID, action, app.log)
+	return app.NewExecutionContextWithContext(context.Background(), token, correlationID, action, isUOWReqd, isUOWReadonly)
+}
+
+// NewExecutionContextWithContext creates new exectuion context carrying the given context, statements of its unit of work are part of the trace of the context
+func (app *App) NewExecutionContextWithContext(ctx context.Context, token *security.JwtToken, correlationID string, action string, isUOWReqd, isUOWReadonly bool) microappCtx.ExecutionContext {
+	executionContext := microappCtx.NewExecutionContextWithContext(ctx, token, correlationID, action, app.log)
 	if isUOWReqd {
-		uow := app.NewUnitOfWork(isUOWReadonly, *executionContext.GetDefaultLogger())
+		uowCtx := withToken(repository.WithCorrelationID(ctx, executionContext.GetCorrelationID()), token)
+		uow := app.NewUnitOfWorkWithContext(uowCtx, isUOWReadonly, *executionContext.GetDefaultLogger())
 		executionContext.SetUOW(uow)
 	}
 	return executionContext
 }
 
+// withToken returns the context whose units of work record the changes on behalf of the user of the token,
+// and are restricted to the tenant of the token when tenant isolation is enabled
+func withToken(ctx context.Context, token *security.JwtToken) context.Context {
+	if token == nil {
+		return ctx
+	}
+	ctx = repository.WithActor(ctx, repository.Actor{UserID: token.UserID, UserName: token.UserName, TenantID: token.TenantID, Token: token.Raw})
+	if token.TenantID != uuid.Nil {
+		ctx = repository.WithTenant(ctx, token.TenantID)
+	}
+	return ctx
+}
+
+// NewExecutionContextFromRequest creates new exectuion context for the request, it is part of the trace of the request
+func (app *App) NewExecutionContextFromRequest(r *http.Request, token *security.JwtToken, action string, isUOWReqd, isUOWReadonly bool) microappCtx.ExecutionContext {
+	return app.NewExecutionContextWithContext(r.Context(), token, GetCorrelationIDFromRequest(r), action, isUOWReqd, isUOWReadonly)
+}
+
 // NewExecutionContextWithCustomToken creates new exectuion context with custom made token
 func (app *App) NewExecutionContextWithCustomToken(tenantID uuid.UUID, userID uuid.UUID, username string, correlationID string, action string, admin, isUOWReqd, isUOWReadonly bool) microappCtx.ExecutionContext {
-	executionContext := microappCtx.NewExecutionContext(&security.JwtToken{Admin: admin, TenantID: tenantID, UserID: userID, UserName: username}, correlationID, action, app.log)
-	if isUOWReqd {
-		uow := app.NewUnitOfWork(isUOWReadonly, *executionContext.GetDefaultLogger())
-		executionContext.SetUOW(uow)
-	}
-	return executionContext
+	return app.NewExecutionContextWithContext(context.Background(), &security.JwtToken{Admin: admin, TenantID: tenantID, UserID: userID, UserName: username}, correlationID, action, isUOWReqd, isUOWReadonly)
 }
 
 // NewExecutionContextWithSystemToken creates new exectuion context with sys default token
 func (app *App) NewExecutionContextWithSystemToken(correlationID string, action string, admin, isUOWReqd, isUOWReadonly bool) microappCtx.ExecutionContext {
-	executionContext := microappCtx.NewExecutionContext(&security.JwtToken{Admin: admin, TenantID: uuid.Nil, UserID: uuid.Nil, TenantName: "None", UserName: "System", DisplayName: "System"}, correlationID, action, app.log)
-	if isUOWReqd {
-		uow := app.NewUnitOfWork(isUOWReadonly, *executionContext.GetDefaultLogger())
-		executionContext.SetUOW(uow)
+	return app.NewExecutionContextWithContext(context.Background(), &security.JwtToken{Admin: admin, TenantID: uuid.Nil, UserID: uuid.Nil, TenantName: "None", UserName: "System", DisplayName: "System"}, correlationID, action, isUOWReqd, isUOWReadonly)
+}
+
+// NewEventRouter creates a router which decodes the received events and invokes their handlers in an execution context with read-write unit of work.
+// Redelivered events are skipped if the event idempotency store is configured.
+func (app *App) NewEventRouter() *handler.Router {
+	router := handler.NewRouter(app.Config, app.log, func(ctx context.Context, token *security.JwtToken, correlationID string, action string) microappCtx.ExecutionContext {
+		return app.NewExecutionContextWithContext(ctx, token, correlationID, action, true, false)
+	})
+	if app.idempotencyStore != nil {
+		router.UseIdempotencyStore(app.idempotencyStore)
 	}
-	return executionContext
+	return router
+}
+
+// UseIdempotencyStore makes the event routers created afterwards skip the events already recorded processed in the given store
+func (app *App) UseIdempotencyStore(store idempotency.Store) {
+	app.idempotencyStore = store
 }
 
 // GetCorrelationIDFromRequest returns correlationId from request header
@@ -411,28 +935,6 @@ func GetCorrelationIDFromRequest(r *http.Request) string {
 	return r.Header.Get("X-Correlation-ID")
 }
 
-func registerTLSConfig(sslCA, sslCert, sslKey string) error {
-	rootCertPool := x509.NewCertPool()
-	pem, err := ioutil.ReadFile(sslCA)
-	if err != nil {
-		return err
-	}
-	if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
-		return err
-	}
-	clientCert := make([]tls.Certificate, 0, 1)
-	certs, err := tls.LoadX509KeyPair(sslCert, sslKey)
-	if err != nil {
-		return err
-	}
-	clientCert = append(clientCert, certs)
-	gomysqldriver.RegisterTLSConfig("custom", &tls.Config{
-		RootCAs:      rootCertPool,
-		Certificates: clientCert,
-	})
-	return nil
-}
-
 // initializeMemcache initializes the memcached client
 func (app *App) initializeMemcache() error {
 	if !app.Config.GetBool(config.EvSuffixForMemCachedRequired) {
@@ -468,6 +970,36 @@ func (app *App) initializeMemcache() error {
 	return nil
 }
 
+// initializeIdempotencyStore initializes the store used by event routers to skip the redelivered events
+func (app *App) initializeIdempotencyStore() error {
+	retention := time.Duration(app.Config.GetInt(config.EvSuffixForEventIdempotencyRetention)) * time.Hour
+
+	switch strings.ToLower(app.Config.GetString(config.EvSuffixForEventIdempotencyStore)) {
+	case "":
+		return nil
+	case "db":
+		if app.DB == nil {
+			return errors.New("event idempotency store db requires database, please set ISLA_DB_REQUIRED")
+		}
+		store := idempotency.NewDBStore(app.DB)
+		if err := store.Migrate(); err != nil {
+			return err
+		}
+		app.idempotencyPurger = idempotency.NewPurger(store, retention, time.Hour, app.log)
+		app.idempotencyPurger.Start()
+		app.idempotencyStore = store
+	case "memcached":
+		if app.MemcachedClient == nil {
+			return errors.New("event idempotency store memcached requires memcached, please set ISLA_MEMCACHED_REQUIRED")
+		}
+		app.idempotencyStore = idempotency.NewMemcachedStore(app.MemcachedClient, retention, 0)
+	default:
+		return fmt.Errorf("unsupported event idempotency store: %v", app.Config.GetString(config.EvSuffixForEventIdempotencyStore))
+	}
+	app.log.Info().Msg("Event idempotency store initialized!")
+	return nil
+}
+
 func (app *App) setTLSClientConfig() (*tls.Config, error) {
 	tlsConfig := &tls.Config{}
 
@@ -0,0 +1,54 @@
+package microapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/config"
+	"github.com/islax/microapp/repository"
+	"github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRunInTransaction(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%v?mode=memory&cache=shared", uuid.NewV4())), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := New("test", map[string]interface{}{config.EvSuffixForTransactionRetryAttempts: 3, config.EvSuffixForTransactionRetryDelay: 1}, zerolog.New(ioutil.Discard), db, nil, nil)
+
+	attempts := 0
+	err = app.RunInTransaction(context.Background(), func(uow *repository.UnitOfWork) error {
+		attempts++
+		if attempts < 3 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected transaction to succeed on attempt 3, got %v, %v", attempts, err)
+	}
+
+	attempts = 0
+	failure := errors.New("not retryable")
+	if err := app.RunInTransaction(context.Background(), func(uow *repository.UnitOfWork) error { attempts++; return failure }); err != failure || attempts != 1 {
+		t.Errorf("Expected non-retryable error on attempt 1, got %v, %v", attempts, err)
+	}
+
+	app = New("test", map[string]interface{}{config.EvSuffixForTransactionRetryAttempts: 3, config.EvSuffixForTransactionRetryDelay: 60000}, zerolog.New(ioutil.Discard), db, nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	attempts = 0
+	start := time.Now()
+	err = app.RunInTransaction(ctx, func(uow *repository.UnitOfWork) error { attempts++; return sqlite3.Error{Code: sqlite3.ErrBusy} })
+	if err != context.DeadlineExceeded || attempts != 1 || time.Since(start) > 10*time.Second {
+		t.Errorf("Expected backoff to end with the context, got %v after %v attempts in %v", err, attempts, time.Since(start))
+	}
+}
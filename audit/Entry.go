@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/islax/microapp/repository"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Entry represents a change made to an entity, Changes holds the old and new values of the changed columns as JSON
+type Entry struct {
+	ID            uuid.UUID `gorm:"type:varchar(36);primary_key;" json:"id"`
+	TenantID      uuid.UUID `gorm:"column:tenantId;type:varchar(36);index:audit_tenant" json:"tenantId"`
+	Entity        string    `gorm:"column:entity;type:varchar(128);index:audit_entity" json:"entity"`
+	EntityID      string    `gorm:"column:entityId;type:varchar(64);index:audit_entity" json:"entityId"`
+	Action        string    `gorm:"column:action;type:varchar(16)" json:"action"`
+	Changes       string    `gorm:"column:changes;type:text" json:"-"`
+	UserID        uuid.UUID `gorm:"column:userId;type:varchar(36)" json:"userId"`
+	UserName      string    `gorm:"column:userName;type:varchar(255)" json:"userName"`
+	CorrelationID string    `gorm:"column:correlationId;type:varchar(64)" json:"correlationId"`
+	CreatedAt     time.Time `gorm:"column:createdOn;index:audit_tenant" json:"createdOn"`
+}
+
+// TableName returns the name of audit trail table
+func (Entry) TableName() string {
+	return "auditTrail"
+}
+
+// GetChanges returns the old and new values of the changed columns
+func (entry Entry) GetChanges() (map[string]repository.FieldChange, error) {
+	changes := make(map[string]repository.FieldChange)
+	if entry.Changes == "" {
+		return changes, nil
+	}
+	err := json.Unmarshal([]byte(entry.Changes), &changes)
+	return changes, err
+}
+
+// MarshalJSON includes the changes as JSON object
+func (entry Entry) MarshalJSON() ([]byte, error) {
+	type entryJSON Entry
+	return json.Marshal(struct {
+		entryJSON
+		Changes json.RawMessage `json:"changes,omitempty"`
+	}{entryJSON(entry), json.RawMessage(entry.Changes)})
+}
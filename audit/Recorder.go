@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"encoding/json"
+
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/repository"
+	"github.com/islax/microapp/repository/generic"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Config configures the events emitted by Recorder, no event is emitted if EventTopic or DispatchEvent is not set
+type Config struct {
+	// EventTopic is the topic of the event emitted per change, the payload is the audit entry
+	EventTopic string
+	// DispatchEvent dispatches the event once the unit of work of the change is committed, e.g. App.DispatchEventWithUOW
+	DispatchEvent func(uow *repository.UnitOfWork, token string, correlationID string, topic string, payload interface{}) error
+}
+
+// Recorder records the changes made through the audited repositories in the audit trail table, implements repository.ChangeRecorder
+type Recorder struct {
+	config  Config
+	entries *generic.Repository[Entry]
+}
+
+// Migrate creates / updates the audit trail table
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Entry{})
+}
+
+// NewRecorder returns a new audit trail recorder
+func NewRecorder(config Config) *Recorder {
+	return &Recorder{config: config, entries: generic.NewRepository[Entry]()}
+}
+
+// RecordChange adds the change to the audit trail in the unit of work of the change and emits the change event if configured
+func (recorder *Recorder) RecordChange(uow *repository.UnitOfWork, change repository.Change) error {
+	changes, err := json.Marshal(change.Fields)
+	if err != nil {
+		return microappError.NewUnexpectedError(microappError.ErrorCodeJSONMarshalFailure, err)
+	}
+	entry := &Entry{
+		ID:            uuid.NewV4(),
+		TenantID:      change.TenantID,
+		Entity:        change.Entity,
+		EntityID:      change.EntityID,
+		Action:        change.Action,
+		Changes:       string(changes),
+		UserID:        change.Actor.UserID,
+		UserName:      change.Actor.UserName,
+		CorrelationID: change.CorrelationID,
+	}
+	if err := recorder.entries.Add(uow, entry); err != nil {
+		return err
+	}
+
+	if recorder.config.EventTopic != "" && recorder.config.DispatchEvent != nil {
+		return recorder.config.DispatchEvent(uow, change.Actor.Token, change.CorrelationID, recorder.config.EventTopic, entry)
+	}
+	return nil
+}
+
+// Query returns the audit entries matching the query, latest first
+func (recorder *Recorder) Query(uow *repository.UnitOfWork, queryProcessors ...repository.QueryProcessor) ([]Entry, error) {
+	queryProcessors = append([]repository.QueryProcessor{repository.Order(clause.OrderByColumn{Column: clause.Column{Name: "createdOn"}, Desc: true}, false)}, queryProcessors...)
+	return recorder.entries.List(uow, queryProcessors...)
+}
+
+// QueryForTenant returns the audit entries of the tenant matching the query, latest first
+func (recorder *Recorder) QueryForTenant(uow *repository.UnitOfWork, tenantID uuid.UUID, queryProcessors ...repository.QueryProcessor) ([]Entry, error) {
+	queryProcessors = append([]repository.QueryProcessor{repository.FilterColumn("tenantId", tenantID)}, queryProcessors...)
+	return recorder.Query(uow, queryProcessors...)
+}
+
+// EntityTrail returns the changes of the entity, latest first. entity is the table of the entity.
+func (recorder *Recorder) EntityTrail(uow *repository.UnitOfWork, entity string, entityID string, queryProcessors ...repository.QueryProcessor) ([]Entry, error) {
+	queryProcessors = append([]repository.QueryProcessor{repository.FilterColumn("entity", entity), repository.FilterColumn("entityId", entityID)}, queryProcessors...)
+	return recorder.Query(uow, queryProcessors...)
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/model"
+	"github.com/islax/microapp/repository"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	model.Base
+	Name string
+}
+
+func TestRecorder(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	dispatched := 0
+	recorder := NewRecorder(Config{EventTopic: "audit", DispatchEvent: func(uow *repository.UnitOfWork, token string, correlationID string, topic string, payload interface{}) error {
+		dispatched++
+		return nil
+	}})
+	repo := repository.NewAuditedRepository(recorder)
+	actor := repository.Actor{UserID: uuid.NewV4(), UserName: "jdoe", TenantID: uuid.NewV4()}
+	ctx := repository.WithCorrelationID(repository.WithActor(context.Background(), actor), "correlation-1")
+	newUOW := func() *repository.UnitOfWork {
+		return repository.NewUnitOfWorkWithContext(ctx, db, false, zerolog.New(os.Stdout), log.Config{})
+	}
+
+	id := uuid.NewV4()
+	uow := newUOW()
+	if err := repo.Add(uow, &widget{Base: model.Base{ID: id}, Name: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(uow, &widget{Base: model.Base{ID: id}, Name: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(uow, &widget{Base: model.Base{ID: id}}); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+
+	entries, err := recorder.EntityTrail(newUOW(), "widgets", id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || dispatched != 2 {
+		t.Fatalf("Expected 2 entries and events, got %v entries and %v events", len(entries), dispatched)
+	}
+	actions := map[string]Entry{}
+	for _, entry := range entries {
+		actions[entry.Action] = entry
+		if entry.UserID != actor.UserID || entry.UserName != "jdoe" || entry.TenantID != actor.TenantID || entry.CorrelationID != "correlation-1" {
+			t.Errorf("Expected actor and correlation id on entry, got %+v", entry)
+		}
+	}
+	changes, err := actions[repository.ChangeActionUpdate].GetChanges()
+	if err != nil || len(changes) != 1 || changes["name"].Old != "first" || changes["name"].New != "second" {
+		t.Errorf("Expected name changed from first to second, got %v, %v", changes, err)
+	}
+	if changes, _ := actions[repository.ChangeActionDelete].GetChanges(); changes["name"].Old != "second" {
+		t.Errorf("Expected deleted name second, got %v", changes)
+	}
+}
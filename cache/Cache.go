@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ErrCacheMiss is returned by the backends when the key is not cached or has expired
+var ErrCacheMiss = errors.New("cache: miss")
+
+// Backend stores the cached values
+type Backend interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+var (
+	requestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "microapp_cache_requests_total",
+		Help: "The total number of cache lookups by result, hit or miss.",
+	}, []string{"result"})
+	failuresCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "microapp_cache_failures_total",
+		Help: "The total number of failed cache backend operations.",
+	}, []string{"operation"})
+	registerMetricsOnce sync.Once
+)
+
+// call is a load in progress, the concurrent lookups of its key wait for it
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// Client is a cache-aside over the backend. Concurrent misses of a key in the process are loaded once (stampede protection),
+// failures of the backend are treated as misses so that the reads fall back to the source.
+type Client struct {
+	backend   Backend
+	namespace string
+	ttl       time.Duration
+
+	mutex sync.Mutex
+	calls map[string]*call
+}
+
+// NewClient returns a cache-aside client, the keys are prefixed with the namespace and the values expire after the ttl
+func NewClient(backend Backend, namespace string, ttl time.Duration) *Client {
+	registerMetricsOnce.Do(func() {
+		_ = prometheus.Register(requestsCounter)
+		_ = prometheus.Register(failuresCounter)
+	})
+	return &Client{backend: backend, namespace: strings.ReplaceAll(namespace, " ", "_"), ttl: ttl, calls: make(map[string]*call)}
+}
+
+// Key returns the key of the parts in the namespace of the tenant, uuid.Nil for the values shared across the tenants
+func (client *Client) Key(tenantID uuid.UUID, parts ...string) string {
+	tenant := "global"
+	if tenantID != uuid.Nil {
+		tenant = tenantID.String()
+	}
+	return fmt.Sprintf("%v:%v:%v", client.namespace, tenant, strings.Join(parts, ":"))
+}
+
+// GetOrLoad returns the cached value of the key, on miss the value is loaded and cached.
+// The value is not cached if load fails or returns nil, the error of load is returned to all the lookups waiting for it.
+func (client *Client) GetOrLoad(key string, load func() ([]byte, error)) ([]byte, error) {
+	if value, err := client.backend.Get(key); err == nil {
+		requestsCounter.WithLabelValues("hit").Inc()
+		return value, nil
+	} else if err != ErrCacheMiss {
+		failuresCounter.WithLabelValues("get").Inc()
+	}
+	requestsCounter.WithLabelValues("miss").Inc()
+
+	client.mutex.Lock()
+	if inProgress, ok := client.calls[key]; ok {
+		client.mutex.Unlock()
+		<-inProgress.done
+		return inProgress.value, inProgress.err
+	}
+	loading := &call{done: make(chan struct{})}
+	client.calls[key] = loading
+	client.mutex.Unlock()
+
+	defer func() {
+		client.mutex.Lock()
+		delete(client.calls, key)
+		client.mutex.Unlock()
+		close(loading.done)
+	}()
+	loading.value, loading.err = load()
+	if loading.err == nil && loading.value != nil {
+		if err := client.backend.Set(key, loading.value, client.ttl); err != nil {
+			failuresCounter.WithLabelValues("set").Inc()
+		}
+	}
+	return loading.value, loading.err
+}
+
+// Invalidate removes the keys from the cache
+func (client *Client) Invalidate(keys ...string) {
+	for _, key := range keys {
+		if err := client.backend.Delete(key); err != nil && err != ErrCacheMiss {
+			failuresCounter.WithLabelValues("delete").Inc()
+		}
+	}
+}
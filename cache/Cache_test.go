@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestLRU(t *testing.T) {
+	lru := NewLRU(2)
+	lru.Set("a", []byte("1"), time.Minute)
+	lru.Set("b", []byte("2"), time.Minute)
+	lru.Get("a")
+	lru.Set("c", []byte("3"), time.Minute)
+	if _, err := lru.Get("b"); err != ErrCacheMiss {
+		t.Errorf("Expected least recently used entry evicted, got %v", err)
+	}
+	if value, err := lru.Get("a"); err != nil || string(value) != "1" {
+		t.Errorf("Expected recently used entry kept, got %s, %v", value, err)
+	}
+	lru.Set("d", []byte("4"), -time.Second)
+	if _, err := lru.Get("d"); err != ErrCacheMiss {
+		t.Errorf("Expected expired entry missed, got %v", err)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	client := NewClient(NewLRU(10), "test service", time.Minute)
+	key := client.Key(uuid.Nil, "widgets", "1")
+	if key != "test_service:global:widgets:1" {
+		t.Errorf("Unexpected key %v", key)
+	}
+
+	var loads int32
+	release := make(chan struct{})
+	var wait sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			value, err := client.GetOrLoad(key, func() ([]byte, error) {
+				atomic.AddInt32(&loads, 1)
+				<-release
+				return []byte("widget"), nil
+			})
+			if err != nil || string(value) != "widget" {
+				t.Errorf("Expected loaded value, got %s, %v", value, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wait.Wait()
+	if loads != 1 {
+		t.Errorf("Expected concurrent misses loaded once, got %v loads", loads)
+	}
+
+	client.Invalidate(key)
+	if _, err := client.GetOrLoad(key, func() ([]byte, error) { return nil, errors.New("failed") }); err == nil {
+		t.Error("Expected load error after invalidation")
+	}
+}
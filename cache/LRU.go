@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultLRUCapacity is the number of entries of the LRU backend if not configured
+const DefaultLRUCapacity = 10000
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// LRU is an in-memory backend which evicts the least recently used entry once the capacity is reached,
+// used when memcached is not configured. The entries are not shared across the instances of the service.
+type LRU struct {
+	capacity int
+	mutex    sync.Mutex
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used at front
+}
+
+// NewLRU returns an in-memory backend of the capacity
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = DefaultLRUCapacity
+	}
+	return &LRU{capacity: capacity, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// Get implements Backend
+func (lru *LRU) Get(key string) ([]byte, error) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	element, ok := lru.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		lru.remove(element)
+		return nil, ErrCacheMiss
+	}
+	lru.order.MoveToFront(element)
+	return entry.value, nil
+}
+
+// Set implements Backend
+func (lru *LRU) Set(key string, value []byte, ttl time.Duration) error {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	expires := time.Now().Add(ttl)
+	if element, ok := lru.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		lru.order.MoveToFront(element)
+		return nil
+	}
+	lru.entries[key] = lru.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	if lru.order.Len() > lru.capacity {
+		lru.remove(lru.order.Back())
+	}
+	return nil
+}
+
+// Delete implements Backend
+func (lru *LRU) Delete(key string) error {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	if element, ok := lru.entries[key]; ok {
+		lru.remove(element)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including the expired ones not evicted yet
+func (lru *LRU) Len() int {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	return lru.order.Len()
+}
+
+func (lru *LRU) remove(element *list.Element) {
+	lru.order.Remove(element)
+	delete(lru.entries, element.Value.(*lruEntry).key)
+}
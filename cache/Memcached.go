@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// Memcached is a backend over memcached, the entries are shared across the instances of the service
+type Memcached struct {
+	client *memcache.Client
+}
+
+// NewMemcached returns a backend over the memcached client
+func NewMemcached(client *memcache.Client) *Memcached {
+	return &Memcached{client: client}
+}
+
+// Get implements Backend
+func (backend *Memcached) Get(key string) ([]byte, error) {
+	item, err := backend.client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.Value, nil
+}
+
+// Set implements Backend
+func (backend *Memcached) Set(key string, value []byte, ttl time.Duration) error {
+	return backend.client.Set(&memcache.Item{Key: key, Value: value, Expiration: expiration(ttl)})
+}
+
+// Delete implements Backend
+func (backend *Memcached) Delete(key string) error {
+	if err := backend.client.Delete(key); err != nil && err != memcache.ErrCacheMiss {
+		return err
+	}
+	return nil
+}
+
+// expiration converts the ttl to memcached expiration, ttls over 30 days are converted to unix time as required by memcached
+func expiration(ttl time.Duration) int32 {
+	if ttl > 30*24*time.Hour {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	if ttl > 0 && ttl < time.Second {
+		return 1
+	}
+	return int32(ttl / time.Second)
+}
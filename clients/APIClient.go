@@ -12,6 +12,7 @@ import (
 
 	microappCtx "github.com/islax/microapp/context"
 	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/tracing"
 )
 
 // APIClient represents the actual client calling microservice
@@ -59,7 +60,9 @@ func (apiClient *APIClient) DoRequestBasic(context microappCtx.ExecutionContext,
 	request.Header.Set("X-Correlation-ID", context.GetCorrelationID())
 	request.Header.Set("Content-Type", "application/json")
 
+	request, span := tracing.StartClientSpan(context.GetContext(), request)
 	response, err := apiClient.HTTPClient.Do(request)
+	tracing.EndClientSpan(span, response, err)
 	if err != nil {
 		return nil, microappError.NewAPIClientError(apiURL, nil, nil, fmt.Errorf("unable to invoke API: %w", err))
 	}
@@ -95,7 +98,9 @@ func (apiClient *APIClient) DoRequestProxy(context microappCtx.ExecutionContext,
 		request.Header.Set("Authorization", r.Header.Get("Authorization"))
 	}
 
+	request, span := tracing.StartClientSpan(context.GetContext(), request)
 	response, err := apiClient.HTTPClient.Do(request)
+	tracing.EndClientSpan(span, response, err)
 	if err != nil {
 		return nil, microappError.NewAPIClientError(apiURL, nil, nil, fmt.Errorf("unable to invoke API: %w", err))
 	}
@@ -0,0 +1,33 @@
+// Command eventredrive moves the events from the dead-letter queue of a named queue back to the queue.
+// RabbitMQ connection settings are read from ISLA_QUEUE_* environment variables.
+//
+//	eventredrive -queue settings_tenant_events -limit 100
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/event/monitor"
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	queueName := flag.String("queue", "", "Name of the queue whose dead-letter queue is redriven")
+	limit := flag.Int("limit", 0, "Maximum number of events to redrive, 0 redrives all")
+	flag.Parse()
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	if *queueName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	redriven, err := monitor.RedriveDeadLetters(&logger, event.AMQPConnectionConfigFromEnv(), *queueName, *limit)
+	if err != nil {
+		logger.Error().Err(err).Int("redriven", redriven).Msg("Unable to redrive dead-letter queue.")
+		os.Exit(1)
+	}
+	logger.Info().Int("redriven", redriven).Msgf("Redriven dead-letter queue of %v", *queueName)
+}
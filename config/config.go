@@ -16,12 +16,23 @@ func NewConfig(defaults map[string]interface{}) *Config {
 	config.viper.SetDefault(EvSuffixForJwtSecret, "Secret key for test")
 
 	config.viper.SetDefault(EvSuffixForDBRequired, true)
+	config.viper.SetDefault(EvSuffixForDBDialect, "mysql")
 	config.viper.SetDefault(EvSuffixForDBHost, "localhost")
 	config.viper.SetDefault(EvSuffixForDBPort, "3306")
 	config.viper.SetDefault(EvSuffixForDBUser, "root")
 	config.viper.SetDefault(EvSuffixForDBPassword, "Cyber!nc#")
 	config.viper.SetDefault(EvSuffixForDBConnectionLifetime, 60)
 	config.viper.SetDefault(EvSuffixForDBMaxIdleConnections, 30)
+	config.viper.SetDefault(EvSuffixForDBReplicaSelection, "round-robin")
+	config.viper.SetDefault(EvSuffixForDBReplicaStickiness, 0)
+	config.viper.SetDefault(EvSuffixForDBReplicaHealthCheckInterval, 10)
+
+	config.viper.SetDefault(EvSuffixForTransactionRetryAttempts, 3)
+	config.viper.SetDefault(EvSuffixForTransactionRetryDelay, 50)
+
+	config.viper.SetDefault(EvSuffixForSoftDeleteRetentionBatchSize, 500)
+	config.viper.SetDefault(EvSuffixForSoftDeleteRetentionDryRun, false)
+	config.viper.SetDefault(EvSuffixForSoftDeleteRetentionInterval, 60)
 
 	config.viper.SetDefault(EvSuffixForLogLevel, "error")
 
@@ -34,6 +45,45 @@ func NewConfig(defaults map[string]interface{}) *Config {
 	config.viper.SetDefault("TLS_CRT", "/opt/isla/tls.crt")
 	config.viper.SetDefault("TLS_KEY", "/opt/isla/tls.key")
 	config.viper.SetDefault(EvSuffixForGormMetricsRefresh, 30)
+
+	config.viper.SetDefault(EvSuffixForCacheTTL, 300)
+	config.viper.SetDefault(EvSuffixForCacheLRUSize, 10000)
+	config.viper.SetDefault(EvSuffixForCacheLRUTTL, 5)
+
+	config.viper.SetDefault(EvSuffixForEventOutboxBatchSize, 100)
+	config.viper.SetDefault(EvSuffixForEventOutboxMaxAttempts, 10)
+	config.viper.SetDefault(EvSuffixForEventOutboxPollInterval, 5)
+	config.viper.SetDefault(EvSuffixForEventOutboxRetention, 24)
+
+	config.viper.SetDefault(EvSuffixForEventTransport, "rabbitmq")
+	config.viper.SetDefault(EvSuffixForQueueHost, "localhost")
+	config.viper.SetDefault(EvSuffixForQueuePort, "5672")
+	config.viper.SetDefault(EvSuffixForQueueUser, "guest")
+	config.viper.SetDefault(EvSuffixForQueuePassword, "guest")
+	config.viper.SetDefault(EvSuffixForEventSpoolMaxMessages, 10000)
+	config.viper.SetDefault(EvSuffixForEventPublishConfirmTimeout, 30)
+	config.viper.SetDefault(EvSuffixForEventSchemaVersion, "1.0")
+	config.viper.SetDefault(EvSuffixForEventIdempotencyRetention, 72)
+	config.viper.SetDefault(EvSuffixForEventMaxRedeliveries, 5)
+	config.viper.SetDefault(EvSuffixForEventRetryDelay, 5)
+	config.viper.SetDefault(EvSuffixForEventRetryMaxDelay, 300)
+	config.viper.SetDefault(EvSuffixForEventPrefetchCount, 50)
+
+	config.viper.SetDefault(EvSuffixForStartupTimeout, 120)
+	config.viper.SetDefault(EvSuffixForShutdownTimeout, 120)
+	config.viper.SetDefault(EvSuffixForShutdownDrainDelay, 0)
+
+	config.viper.SetDefault(EvSuffixForHealthCheckTimeout, 5)
+	config.viper.SetDefault(EvSuffixForHealthCheckCacheTTL, 5)
+	config.viper.SetDefault(EvSuffixForHealthDiskPath, "/")
+	config.viper.SetDefault(EvSuffixForHealthDiskMinFreeMB, 0)
+
+	config.viper.SetDefault(EvSuffixForTracingExporter, "none")
+	config.viper.SetDefault(EvSuffixForTracingFilePath, "traces.json")
+	config.viper.SetDefault(EvSuffixForTracingSampleRatio, 1.0)
+
+	config.viper.SetDefault(EvSuffixForTenantIsolation, false)
+	config.viper.SetDefault(EvSuffixForAuditTrail, false)
 	for key, value := range defaults {
 		config.viper.SetDefault(key, value)
 	}
@@ -88,6 +138,11 @@ func (config *Config) GetInt(key string) int {
 	return config.viper.GetInt(key)
 }
 
+// GetFloat64 return float64 value set for the given key
+func (config *Config) GetFloat64(key string) float64 {
+	return config.viper.GetFloat64(key)
+}
+
 // GetMapString returns the value associated with the given key as a map of strings
 func (config *Config) GetMapString(key string) map[string]string {
 	return config.viper.GetStringMapString(key)
@@ -10,6 +10,8 @@ const (
 	EvSuffixForDBHost = "DB_HOST"
 	// EvSuffixForDBConnectionLifetime environment variable name for connection lifetime in database connection pool
 	EvSuffixForDBConnectionLifetime = "DB_CONNECTION_MAX_LIFETIME"
+	// EvSuffixForDBDialect environment variable name for database dialect, valid values are mysql, postgres and sqlite
+	EvSuffixForDBDialect = "DB_DIALECT"
 	// EvSuffixForDBLogLevel environment variable name for database log level
 	EvSuffixForDBLogLevel = "DB_LOG_LEVEL"
 	// EvSuffixForDBMaxIdleConnections environment variable name for max idle connections in database connection pool
@@ -20,10 +22,30 @@ const (
 	EvSuffixForDBPassword = "DB_PWD"
 	// EvSuffixForDBPort environment variable name for database port
 	EvSuffixForDBPort = "DB_PORT"
+	// EvSuffixForDBReplicaHosts environment variable name for comma separated host[:port] of the read replicas
+	EvSuffixForDBReplicaHosts = "DB_REPLICA_HOSTS"
+	// EvSuffixForDBReplicaHealthCheckInterval environment variable name for interval in seconds at which the read replicas are pinged
+	EvSuffixForDBReplicaHealthCheckInterval = "DB_REPLICA_HEALTH_CHECK_INTERVAL"
+	// EvSuffixForDBReplicaSelection environment variable name for selection of read replica, valid values are round-robin and least-latency
+	EvSuffixForDBReplicaSelection = "DB_REPLICA_SELECTION"
+	// EvSuffixForDBReplicaStickiness environment variable name for seconds for which reads of a correlation id are routed to primary after its write
+	EvSuffixForDBReplicaStickiness = "DB_REPLICA_STICKINESS"
 	// EvSuffixForDBRequired environment variable name for database required flag
 	EvSuffixForDBRequired = "DB_REQUIRED"
 	// EvSuffixForDBUser environment variable name for database bind user
 	EvSuffixForDBUser = "DB_USER"
+	// EvSuffixForTransactionRetryAttempts environment variable name for number of attempts of RunInTransaction on deadlock / lock wait timeout
+	EvSuffixForTransactionRetryAttempts = "TRANSACTION_RETRY_ATTEMPTS"
+	// EvSuffixForTransactionRetryDelay environment variable name for delay in milliseconds before the first retry of RunInTransaction
+	EvSuffixForTransactionRetryDelay = "TRANSACTION_RETRY_DELAY"
+	// EvSuffixForSoftDeleteRetention environment variable name for comma separated table:hours after which the soft deleted records of the table are purged
+	EvSuffixForSoftDeleteRetention = "SOFT_DELETE_RETENTION"
+	// EvSuffixForSoftDeleteRetentionBatchSize environment variable name for number of soft deleted records purged in one statement
+	EvSuffixForSoftDeleteRetentionBatchSize = "SOFT_DELETE_RETENTION_BATCH_SIZE"
+	// EvSuffixForSoftDeleteRetentionDryRun environment variable name for only reporting the soft deleted records eligible to purge
+	EvSuffixForSoftDeleteRetentionDryRun = "SOFT_DELETE_RETENTION_DRY_RUN"
+	// EvSuffixForSoftDeleteRetentionInterval environment variable name for interval in minutes at which the soft deleted records are purged
+	EvSuffixForSoftDeleteRetentionInterval = "SOFT_DELETE_RETENTION_INTERVAL"
 	// EvSuffixForHTTPIdleTimeout environment variable name for HTT idle timeout
 	EvSuffixForHTTPIdleTimeout = "HTTP_IDLE_TIMEOUT"
 	// EvSuffixForHTTPReadTimeout environment variable name for HTTP read timeout
@@ -38,6 +60,42 @@ const (
 	EvSuffixForGormSlowThreshold = "GORM_SLOW_THRESHOLD"
 	// EvSuffixForEnableHealthLog environment variable name for log level
 	EvSuffixForEnableHealthLog = "ENABLE_HEALTH_LOG"
+	// EvSuffixForEnableEventOutbox environment variable name for enabling transactional outbox for events
+	EvSuffixForEnableEventOutbox = "ENABLE_EVENT_OUTBOX"
+	// EvSuffixForEventOutboxBatchSize environment variable name for number of outbox events relayed in one go
+	EvSuffixForEventOutboxBatchSize = "EVENT_OUTBOX_BATCH_SIZE"
+	// EvSuffixForEventOutboxMaxAttempts environment variable name for number of publish attempts of an outbox event
+	EvSuffixForEventOutboxMaxAttempts = "EVENT_OUTBOX_MAX_ATTEMPTS"
+	// EvSuffixForEventOutboxPollInterval environment variable name for outbox poll interval in seconds
+	EvSuffixForEventOutboxPollInterval = "EVENT_OUTBOX_POLL_INTERVAL"
+	// EvSuffixForEventOutboxRetention environment variable name for retention of published outbox events in hours
+	EvSuffixForEventOutboxRetention = "EVENT_OUTBOX_RETENTION"
+	// EvSuffixForEventTransport environment variable name for event transport, valid values are rabbitmq and memory
+	EvSuffixForEventTransport = "EVENT_TRANSPORT"
+	// EvSuffixForEventSpoolDir environment variable name for directory to spool unconfirmed / overflow events
+	EvSuffixForEventSpoolDir = "EVENT_SPOOL_DIR"
+	// EvSuffixForEventSpoolMaxMessages environment variable name for maximum number of events that can be spooled
+	EvSuffixForEventSpoolMaxMessages = "EVENT_SPOOL_MAX_MESSAGES"
+	// EvSuffixForEventPublishConfirmTimeout environment variable name for publish confirmation timeout in seconds
+	EvSuffixForEventPublishConfirmTimeout = "EVENT_PUBLISH_CONFIRM_TIMEOUT"
+	// EvSuffixForEventCloudEventsEnabled environment variable name for publishing the events in CloudEvents envelope
+	EvSuffixForEventCloudEventsEnabled = "EVENT_CLOUDEVENTS_ENABLED"
+	// EvSuffixForEventSchemaVersion environment variable name for schema version of the event payloads which do not specify it
+	EvSuffixForEventSchemaVersion = "EVENT_SCHEMA_VERSION"
+	// EvSuffixForEventIdempotencyStore environment variable name for store of processed events, valid values are db and memcached, empty disables it
+	EvSuffixForEventIdempotencyStore = "EVENT_IDEMPOTENCY_STORE"
+	// EvSuffixForEventIdempotencyRetention environment variable name for retention of processed events in hours
+	EvSuffixForEventIdempotencyRetention = "EVENT_IDEMPOTENCY_RETENTION"
+	// EvSuffixForEventManualAck environment variable name for acknowledging the received events only after they are handled
+	EvSuffixForEventManualAck = "EVENT_MANUAL_ACK"
+	// EvSuffixForEventMaxRedeliveries environment variable name for number of redeliveries after which the failed event is dead-lettered
+	EvSuffixForEventMaxRedeliveries = "EVENT_MAX_REDELIVERIES"
+	// EvSuffixForEventRetryDelay environment variable name for delay in seconds before the first redelivery of failed event
+	EvSuffixForEventRetryDelay = "EVENT_RETRY_DELAY"
+	// EvSuffixForEventRetryMaxDelay environment variable name for maximum delay in seconds before redelivery of failed event
+	EvSuffixForEventRetryMaxDelay = "EVENT_RETRY_MAX_DELAY"
+	// EvSuffixForEventPrefetchCount environment variable name for number of unacknowledged events delivered to a monitor
+	EvSuffixForEventPrefetchCount = "EVENT_PREFETCH_COUNT"
 	// EvSuffixForEnableMetrics environment variable name for enable metrics
 	EvSuffixForEnableMetrics = "ENABLE_METRICS"
 	// EvSuffixForGormMetricsRefresh environment variable name for gorm metrics refresh interval
@@ -52,6 +110,28 @@ const (
 	EvSuffixForMemCachedPort = "MEMCACHED_PORT"
 	// EvSuffixForMemCachedRequired environment variable name for memcached required flag
 	EvSuffixForMemCachedRequired = "MEMCACHED_REQUIRED"
+	// EvSuffixForCacheTTL environment variable name for time to live in seconds of the cached records
+	EvSuffixForCacheTTL = "CACHE_TTL"
+	// EvSuffixForCacheLRUSize environment variable name for number of entries cached in memory when memcached is not required
+	EvSuffixForCacheLRUSize = "CACHE_LRU_SIZE"
+	// EvSuffixForCacheLRUTTL environment variable name for time to live in seconds of the records cached in memory, caps CACHE_TTL as the other instances do not see the invalidation
+	EvSuffixForCacheLRUTTL = "CACHE_LRU_TTL"
+	// EvSuffixForQueueHost environment variable name for RabbitMQ host
+	EvSuffixForQueueHost = "QUEUE_HOST"
+	// EvSuffixForQueuePort environment variable name for RabbitMQ port
+	EvSuffixForQueuePort = "QUEUE_PORT"
+	// EvSuffixForQueueUser environment variable name for RabbitMQ user
+	EvSuffixForQueueUser = "QUEUE_USER"
+	// EvSuffixForQueuePassword environment variable name for RabbitMQ password
+	EvSuffixForQueuePassword = "QUEUE_PWD"
+	// EvSuffixForQueueTLSEnabled environment variable name for enabling TLS for RabbitMQ connection
+	EvSuffixForQueueTLSEnabled = "QUEUE_TLS_ENABLED"
+	// EvSuffixForQueueCACert environment variable name for RabbitMQ CA certificate
+	EvSuffixForQueueCACert = "QUEUE_RMQ_CA_CERT"
+	// EvSuffixForQueueClientCert environment variable name for RabbitMQ client certificate
+	EvSuffixForQueueClientCert = "QUEUE_RMQ_CERT"
+	// EvSuffixForQueueClientCertKey environment variable name for RabbitMQ client certificate key
+	EvSuffixForQueueClientCertKey = "QUEUE_RMQ_CERT_KEY"
 	// EvSuffixForSkipInsecureTLSVerification environment variable name for skipping insecure tls verification
 	EvSuffixForSkipInsecureTLSVerification = "TLS_INSECURE_SKIP_VERIFY"
 	// EvSuffixForEnableTLS environment variable name for enabling tls
@@ -62,4 +142,34 @@ const (
 	EvSuffixForTLSCert = "TLS_CRT"
 	// EvSuffixForTLSKey environment variable name for tls private key
 	EvSuffixForTLSKey = "TLS_KEY"
+	// EvSuffixForStartupTimeout environment variable name for time in seconds within which the app components should start
+	EvSuffixForStartupTimeout = "STARTUP_TIMEOUT"
+	// EvSuffixForShutdownTimeout environment variable name for time in seconds within which the app components should stop
+	EvSuffixForShutdownTimeout = "SHUTDOWN_TIMEOUT"
+	// EvSuffixForShutdownDrainDelay environment variable name for time in seconds the app reports not ready before it stops the components
+	EvSuffixForShutdownDrainDelay = "SHUTDOWN_DRAIN_DELAY"
+	// EvSuffixForHealthCheckTimeout environment variable name for time in seconds within which a health check should complete
+	EvSuffixForHealthCheckTimeout = "HEALTH_CHECK_TIMEOUT"
+	// EvSuffixForHealthCheckCacheTTL environment variable name for time in seconds for which the result of health checks is reused
+	EvSuffixForHealthCheckCacheTTL = "HEALTH_CHECK_CACHE_TTL"
+	// EvSuffixForHealthDiskPath environment variable name for path of the file system checked for available disk space
+	EvSuffixForHealthDiskPath = "HEALTH_DISK_PATH"
+	// EvSuffixForHealthDiskMinFreeMB environment variable name for minimum available disk space in MB, 0 disables the disk check
+	EvSuffixForHealthDiskMinFreeMB = "HEALTH_DISK_MIN_FREE_MB"
+	// EvSuffixForTracingExporter environment variable name for exporter of the trace spans, valid values are none, stdout, file and otlp
+	EvSuffixForTracingExporter = "TRACING_EXPORTER"
+	// EvSuffixForTracingFilePath environment variable name for file to which the file exporter appends the spans
+	EvSuffixForTracingFilePath = "TRACING_FILE_PATH"
+	// EvSuffixForTracingOTLPEndpoint environment variable name for host:port of the OpenTelemetry collector
+	EvSuffixForTracingOTLPEndpoint = "TRACING_OTLP_ENDPOINT"
+	// EvSuffixForTracingOTLPInsecure environment variable name for exporting the spans to the collector without TLS
+	EvSuffixForTracingOTLPInsecure = "TRACING_OTLP_INSECURE"
+	// EvSuffixForTracingSampleRatio environment variable name for ratio of the traces started by the app that are sampled
+	EvSuffixForTracingSampleRatio = "TRACING_SAMPLE_RATIO"
+	// EvSuffixForTenantIsolation environment variable name for restricting the statements on tenant scoped entities to the tenant of the token
+	EvSuffixForTenantIsolation = "TENANT_ISOLATION"
+	// EvSuffixForAuditTrail environment variable name for recording the changes made through the audited repositories
+	EvSuffixForAuditTrail = "AUDIT_TRAIL"
+	// EvSuffixForAuditEventTopic environment variable name for topic of the event dispatched per audited change, no event if not set
+	EvSuffixForAuditEventTopic = "AUDIT_EVENT_TOPIC"
 )
@@ -1,12 +1,14 @@
 package context
 
 import (
+	gocontext "context"
 	"strings"
 
 	microappError "github.com/islax/microapp/error"
 	"github.com/islax/microapp/log"
 	"github.com/islax/microapp/repository"
 	"github.com/islax/microapp/security"
+	"github.com/islax/microapp/tracing"
 	"github.com/rs/zerolog"
 	uuid "github.com/satori/go.uuid"
 )
@@ -15,9 +17,11 @@ import (
 type ExecutionContext interface {
 	AddLoggerStrFields(strFields map[string]string)
 	GetActionName() string
+	GetContext() gocontext.Context
 	GetCorrelationID() string
 	GetDefaultLogger() *zerolog.Logger
 	GetToken() *security.JwtToken
+	GetTraceID() string
 	GetUOW() *repository.UnitOfWork
 	SetUOW(*repository.UnitOfWork)
 	Logger(eventType, eventCode string) *zerolog.Logger
@@ -36,10 +40,16 @@ type executionContextImpl struct {
 	Token         *security.JwtToken
 	Action        string
 	logger        zerolog.Logger
+	ctx           gocontext.Context
 }
 
 // NewExecutionContext creates new execution context
 func NewExecutionContext(token *security.JwtToken, correlationID string, action string, logger zerolog.Logger) ExecutionContext {
+	return NewExecutionContextWithContext(gocontext.Background(), token, correlationID, action, logger)
+}
+
+// NewExecutionContextWithContext creates new execution context which carries the given context, trace id of the context is added to the logger
+func NewExecutionContextWithContext(ctx gocontext.Context, token *security.JwtToken, correlationID string, action string, logger zerolog.Logger) ExecutionContext {
 	cid := correlationID
 	if len(strings.TrimSpace(cid)) == 0 {
 		cid = uuid.NewV4().String()
@@ -77,7 +87,11 @@ func NewExecutionContext(token *security.JwtToken, correlationID string, action
 			Str("correlationId", cid).Logger()
 	}
 
-	return &executionContextImpl{CorrelationID: cid, Token: token, Action: action, logger: executionCtxLogger}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		executionCtxLogger = executionCtxLogger.With().Str("traceId", traceID).Str("spanId", tracing.SpanID(ctx)).Logger()
+	}
+
+	return &executionContextImpl{CorrelationID: cid, Token: token, Action: action, logger: executionCtxLogger, ctx: ctx}
 }
 
 // AddLoggerStrFields adds given string fields to the context logger
@@ -94,13 +108,18 @@ func (context *executionContextImpl) SubContextWithAddlFieldsAndUoW(uow *reposit
 	for k, v := range additionalFields {
 		loggerWith = loggerWith.Str(k, v)
 	}
-	return &executionContextImpl{context.CorrelationID, uow, context.Token, context.Action, loggerWith.Logger()}
+	return &executionContextImpl{context.CorrelationID, uow, context.Token, context.Action, loggerWith.Logger(), context.ctx}
 }
 
 func (context *executionContextImpl) GetActionName() string {
 	return context.Action
 }
 
+// GetContext returns the context carrying the trace and deadline of the execution
+func (context *executionContextImpl) GetContext() gocontext.Context {
+	return context.ctx
+}
+
 func (context *executionContextImpl) GetCorrelationID() string {
 	return context.CorrelationID
 }
@@ -113,6 +132,11 @@ func (context *executionContextImpl) GetToken() *security.JwtToken {
 	return context.Token
 }
 
+// GetTraceID returns the trace id of the execution, empty if it is not traced
+func (context *executionContextImpl) GetTraceID() string {
+	return tracing.TraceID(context.ctx)
+}
+
 func (context *executionContextImpl) GetUOW() *repository.UnitOfWork {
 	return context.UOW
 }
@@ -168,7 +192,7 @@ func (context *executionContextImpl) SubContext(additionalFields map[string]stri
 	for k, v := range additionalFields {
 		loggerWith = loggerWith.Str(k, v)
 	}
-	return &executionContextImpl{context.CorrelationID, context.UOW, context.Token, context.Action, loggerWith.Logger()}
+	return &executionContextImpl{context.CorrelationID, context.UOW, context.Token, context.Action, loggerWith.Logger(), context.ctx}
 }
 
 func (context *executionContextImpl) SubContextWithToken(token *security.JwtToken, additionalFields map[string]string) ExecutionContext {
@@ -177,7 +201,7 @@ func (context *executionContextImpl) SubContextWithToken(token *security.JwtToke
 		loggerWith = loggerWith.Str(k, v)
 	}
 
-	return &executionContextImpl{context.CorrelationID, context.UOW, token, context.Action, loggerWith.Logger()}
+	return &executionContextImpl{context.CorrelationID, context.UOW, token, context.Action, loggerWith.Logger(), context.ctx}
 }
 
 func (context *executionContextImpl) SubContextWithTokenAndUoW(token *security.JwtToken, uow *repository.UnitOfWork, additionalFields map[string]string) ExecutionContext {
@@ -186,7 +210,7 @@ func (context *executionContextImpl) SubContextWithTokenAndUoW(token *security.J
 		loggerWith = loggerWith.Str(k, v)
 	}
 
-	return &executionContextImpl{context.CorrelationID, uow, token, context.Action, loggerWith.Logger()}
+	return &executionContextImpl{context.CorrelationID, uow, token, context.Action, loggerWith.Logger(), context.ctx}
 }
 
 func (context *executionContextImpl) SubContextWithUoW(uow *repository.UnitOfWork, additionalFields map[string]string) ExecutionContext {
@@ -195,5 +219,5 @@ func (context *executionContextImpl) SubContextWithUoW(uow *repository.UnitOfWor
 		loggerWith = loggerWith.Str(k, v)
 	}
 
-	return &executionContextImpl{context.CorrelationID, uow, context.Token, context.Action, loggerWith.Logger()}
+	return &executionContextImpl{context.CorrelationID, uow, context.Token, context.Action, loggerWith.Logger(), context.ctx}
 }
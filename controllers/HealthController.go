@@ -1,25 +1,70 @@
 package controllers
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/islax/microapp/health"
+	"github.com/islax/microapp/web"
 )
 
 // HealthController provides method to check health and readiness
 type HealthController struct {
+	checks *health.Checks
 }
 
-// NewHealthController returns a new instance of HealthController
+// NewHealthController returns a new instance of HealthController.
+// App.Initialize sets the checks of the app on it, so the readiness probe covers the database and RabbitMQ.
 func NewHealthController() *HealthController {
 	return &HealthController{}
 }
 
+// NewHealthControllerWithChecks returns a new instance of HealthController which serves the liveness and readiness probes from the given checks
+func NewHealthControllerWithChecks(checks *health.Checks) *HealthController {
+	return &HealthController{checks: checks}
+}
+
+// SetDefaultChecks sets the checks to serve if the controller is created without checks
+func (controller *HealthController) SetDefaultChecks(checks *health.Checks) {
+	if controller.checks == nil {
+		controller.checks = checks
+	}
+}
+
 // RegisterRoutes implements interface RouteSpecifier
 func (controller *HealthController) RegisterRoutes(router *mux.Router) {
 	healthRouter := router.PathPrefix("/health").Subrouter()
 	healthRouter.HandleFunc("", controller.healthCheck).Methods("GET")
+	healthRouter.HandleFunc("/live", controller.liveness).Methods("GET")
+	healthRouter.HandleFunc("/ready", controller.readiness).Methods("GET")
 }
 
 func (controller *HealthController) healthCheck(w http.ResponseWriter, r *http.Request) {
 }
+
+func (controller *HealthController) liveness(w http.ResponseWriter, r *http.Request) {
+	if controller.checks == nil {
+		respondReport(w, health.Report{Status: health.StatusUp, Checks: []health.CheckResult{}})
+		return
+	}
+	// Report is shared by the concurrent probes, so the checks are not cancelled along with the request
+	respondReport(w, controller.checks.Liveness.Run(context.Background()))
+}
+
+func (controller *HealthController) readiness(w http.ResponseWriter, r *http.Request) {
+	if controller.checks == nil {
+		respondReport(w, health.Report{Status: health.StatusUp, Checks: []health.CheckResult{}})
+		return
+	}
+	respondReport(w, controller.checks.Readiness.Run(context.Background()))
+}
+
+func respondReport(w http.ResponseWriter, report health.Report) {
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	web.RespondJSON(w, status, report)
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/islax/microapp/health"
+)
+
+func TestHealthControllerServesDefaultChecks(t *testing.T) {
+	checks := health.NewChecks(time.Second, 0)
+	checks.AddReadinessCheck(health.CheckerFunc("database", func(ctx context.Context) error { return errors.New("unreachable") }))
+
+	controller := NewHealthController()
+	controller.SetDefaultChecks(checks)
+	router := mux.NewRouter()
+	controller.RegisterRoutes(router)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest("GET", "/health/ready", nil))
+	if response.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness to fail with the default checks, got %v", response.Code)
+	}
+
+	controller = NewHealthControllerWithChecks(health.NewChecks(time.Second, 0))
+	controller.SetDefaultChecks(checks)
+	router = mux.NewRouter()
+	controller.RegisterRoutes(router)
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest("GET", "/health/ready", nil))
+	if response.Code != http.StatusOK {
+		t.Errorf("Expected readiness to use the checks of the controller, got %v", response.Code)
+	}
+}
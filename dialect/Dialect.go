@@ -0,0 +1,83 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"gorm.io/gorm"
+)
+
+const (
+	// MySQL dialect, the default
+	MySQL = "mysql"
+	// Postgres dialect for PostgreSQL
+	Postgres = "postgres"
+	// SQLite dialect, the database name of the connection is the file path
+	SQLite = "sqlite"
+)
+
+// Connection holds the details to connect to the database
+type Connection struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+}
+
+// TLSConfig holds the certificates to connect to the database over TLS
+type TLSConfig struct {
+	CAPath   string
+	CertPath string
+	KeyPath  string
+}
+
+// PoolConfig configures the connection pool
+type PoolConfig struct {
+	MaxOpenConnections int
+	MaxIdleConnections int
+	ConnectionLifetime time.Duration
+}
+
+// Dialect builds the connections to a kind of database for gorm and golang-migrate
+type Dialect interface {
+	// Name returns the name of the dialect
+	Name() string
+	// DriverName returns the database/sql driver name
+	DriverName() string
+	// ConfigureTLS prepares the TLS configuration used by the subsequent DSNs
+	ConfigureTLS(tlsConfig TLSConfig) error
+	// DSN returns the data source name of the connection
+	DSN(connection Connection) string
+	// Dialector returns the gorm dialector on the connection pool
+	Dialector(sqlDB *sql.DB) gorm.Dialector
+	// MigrationDriver returns the golang-migrate driver on the connection pool
+	MigrationDriver(sqlDB *sql.DB) (database.Driver, error)
+}
+
+// New returns the dialect of the name, empty name returns MySQL
+func New(name string) (Dialect, error) {
+	switch name {
+	case MySQL, "":
+		return &mysqlDialect{}, nil
+	case Postgres, "postgresql":
+		return &postgresDialect{}, nil
+	case SQLite, "sqlite3":
+		return &sqliteDialect{}, nil
+	}
+	return nil, fmt.Errorf("unsupported database dialect: %v", name)
+}
+
+// Open opens the connection pool of the dsn and the gorm DB on it
+func Open(dialect Dialect, dsn string, gormConfig *gorm.Config, poolConfig PoolConfig) (*gorm.DB, error) {
+	sqlDB, err := sql.Open(dialect.DriverName(), dsn)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetConnMaxLifetime(poolConfig.ConnectionLifetime)
+	sqlDB.SetMaxIdleConns(poolConfig.MaxIdleConnections)
+	sqlDB.SetMaxOpenConns(poolConfig.MaxOpenConnections)
+	return gorm.Open(dialect.Dialector(sqlDB), gormConfig)
+}
@@ -0,0 +1,130 @@
+package dialect
+
+import (
+	"errors"
+	"testing"
+
+	gomysqldriver "github.com/go-sql-driver/mysql"
+	microappError "github.com/islax/microapp/error"
+	"github.com/jackc/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+func TestDSN(t *testing.T) {
+	connection := Connection{Host: "db", Port: "5432", User: "admin", Password: "p@ss word", Name: "islax"}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{MySQL, "admin:p@ss word@tcp(db:5432)/islax?multiStatements=true&charset=utf8&parseTime=True&loc=Local&tls=preferred"},
+		{Postgres, "postgres://admin:p%40ss%20word@db:5432/islax?sslmode=prefer"},
+		{SQLite, "islax"},
+	}
+	for _, test := range tests {
+		dialect, err := New(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dsn := dialect.DSN(connection); dsn != test.expected {
+			t.Errorf("Expected %v DSN [%v], got [%v]", test.name, test.expected, dsn)
+		}
+	}
+
+	postgres, _ := New(Postgres)
+	postgres.ConfigureTLS(TLSConfig{CAPath: "/certs/ca.crt"})
+	if dsn := postgres.DSN(connection); dsn != "postgres://admin:p%40ss%20word@db:5432/islax?sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.crt" {
+		t.Errorf("Expected verify-full DSN with CA, got [%v]", dsn)
+	}
+
+	if _, err := New("oracle"); err == nil {
+		t.Error("Expected unsupported dialect error")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	sqlite, _ := New(SQLite)
+	db, err := Open(sqlite, sqlite.DSN(Connection{Name: "file::memory:"}), &gorm.Config{}, PoolConfig{MaxOpenConnections: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if db.Dialector.Name() != "sqlite" {
+		t.Errorf("Expected sqlite dialector, got %v", db.Dialector.Name())
+	}
+	if _, err := sqlite.MigrationDriver(sqlDB); err != nil {
+		t.Errorf("Expected migration driver, got %v", err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{&gomysqldriver.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, true},
+		{&gomysqldriver.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, true},
+		{&gomysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry"}, false},
+		{microappError.NewDatabaseError(&gomysqldriver.MySQLError{Number: 1213}), true},
+		{&pgconn.PgError{Code: "40001"}, true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{errors.New("connection refused"), false},
+	}
+	for _, test := range tests {
+		if IsRetryable(test.err) != test.retryable {
+			t.Errorf("Expected retryable %v for %v", test.retryable, test.err)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err        error
+		kind       string
+		constraint string
+		column     string
+	}{
+		{&gomysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'x' for key 'widgets.idx_widgets_name'"}, microappError.ViolationDuplicateKey, "idx_widgets_name", ""},
+		{&gomysqldriver.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`islax`.`orders`, CONSTRAINT `fk_orders_customer` FOREIGN KEY (`customerId`) REFERENCES `customers` (`id`))"}, microappError.ViolationForeignKey, "fk_orders_customer", "customerId"},
+		{&gomysqldriver.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails (`islax`.`orders`, CONSTRAINT `fk_orders_customer` FOREIGN KEY (`customerId`) REFERENCES `customers` (`id`))"}, microappError.ViolationReferenced, "fk_orders_customer", "customerId"},
+		{&gomysqldriver.MySQLError{Number: 1406, Message: "Data too long for column 'name' at row 1"}, microappError.ViolationDataTooLong, "", "name"},
+		{&gomysqldriver.MySQLError{Number: 1048, Message: "Column 'name' cannot be null"}, microappError.ViolationNotNull, "", "name"},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "widgets_name_key", Detail: "Key (name)=(x) already exists."}, microappError.ViolationDuplicateKey, "widgets_name_key", "name"},
+		{&pgconn.PgError{Code: "23503", ConstraintName: "fk_orders_customer", Detail: `Key (customer_id)=(1) is not present in table "customers".`}, microappError.ViolationForeignKey, "fk_orders_customer", "customer_id"},
+		{&pgconn.PgError{Code: "23503", ConstraintName: "fk_orders_customer", Detail: `Key (id)=(1) is still referenced from table "orders".`}, microappError.ViolationReferenced, "fk_orders_customer", "id"},
+		{&pgconn.PgError{Code: "23502", ColumnName: "name"}, microappError.ViolationNotNull, "", "name"},
+		{&pgconn.PgError{Code: "22001"}, microappError.ViolationDataTooLong, "", ""},
+	}
+	for _, test := range tests {
+		violation, ok := microappError.NewDatabaseError(test.err).(microappError.ConstraintViolationError)
+		if !ok || violation.Kind != test.kind || violation.Constraint != test.constraint || violation.Column != test.column {
+			t.Errorf("Expected %v violation of %v on %v for %v, got %+v", test.kind, test.constraint, test.column, test.err, violation)
+		}
+	}
+	if ClassifyError(&gomysqldriver.MySQLError{Number: 1213}) != nil || ClassifyError(errors.New("connection refused")) != nil {
+		t.Error("Expected other errors not to be classified")
+	}
+
+	sqlite, _ := New(SQLite)
+	db, err := Open(sqlite, sqlite.DSN(Connection{Name: "file::memory:"}), &gorm.Config{}, PoolConfig{MaxOpenConnections: 1, MaxIdleConnections: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE)").Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("INSERT INTO widgets (id, name) VALUES (1, 'first')")
+	err = microappError.NewDatabaseError(db.Exec("INSERT INTO widgets (id, name) VALUES (2, 'first')").Error)
+	if violation, ok := err.(microappError.ConstraintViolationError); !ok || violation.Kind != microappError.ViolationDuplicateKey || violation.Column != "name" {
+		t.Errorf("Expected duplicate name, got %v", err)
+	}
+	err = microappError.NewDatabaseError(db.Exec("INSERT INTO widgets (id) VALUES (3)").Error)
+	if !microappError.IsConstraintViolationError(err, microappError.ViolationNotNull) {
+		t.Errorf("Expected not null violation, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package dialect
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	gomysqldriver "github.com/go-sql-driver/mysql"
+	microappError "github.com/islax/microapp/error"
+	"github.com/jackc/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	mysqlErrorBadNull                 = 1048
+	mysqlErrorDuplicateEntry          = 1062
+	mysqlErrorLockWaitTimeout         = 1205
+	mysqlErrorDeadlock                = 1213
+	mysqlErrorNoDefaultForField       = 1364
+	mysqlErrorDataTooLong             = 1406
+	mysqlErrorRowIsReferenced         = 1451
+	mysqlErrorNoReferencedRow         = 1452
+	postgresStringDataRightTruncation = "22001"
+	postgresNotNullViolation          = "23502"
+	postgresForeignKeyViolation       = "23503"
+	postgresUniqueViolation           = "23505"
+	postgresSerializationFailure      = "40001"
+	postgresDeadlockDetected          = "40P01"
+)
+
+var (
+	mysqlDuplicateKeyPattern = regexp.MustCompile("for key '([^']+)'")
+	mysqlForeignKeyPattern   = regexp.MustCompile("CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`")
+	mysqlColumnPattern       = regexp.MustCompile("(?:Column|column|Field) '([^']+)'")
+	postgresKeyPattern       = regexp.MustCompile(`Key \(([^)]+)\)`)
+	sqliteColumnPattern      = regexp.MustCompile(`constraint failed: ([^,]+)`)
+)
+
+func init() {
+	microappError.RegisterDatabaseErrorClassifier(ClassifyError)
+}
+
+// sqlStateError is implemented by the errors of the PostgreSQL drivers
+type sqlStateError interface {
+	SQLState() string
+}
+
+// IsRetryable returns whether the error is a transient failure of the transaction which may succeed on retrying the whole transaction,
+// i.e. deadlock, lock wait timeout, serialization failure or busy database
+func IsRetryable(err error) bool {
+	var mysqlError *gomysqldriver.MySQLError
+	if errors.As(err, &mysqlError) {
+		return mysqlError.Number == mysqlErrorDeadlock || mysqlError.Number == mysqlErrorLockWaitTimeout
+	}
+	var postgresError sqlStateError
+	if errors.As(err, &postgresError) {
+		return postgresError.SQLState() == postgresSerializationFailure || postgresError.SQLState() == postgresDeadlockDetected
+	}
+	var sqliteError sqlite3.Error
+	if errors.As(err, &sqliteError) {
+		return sqliteError.Code == sqlite3.ErrBusy || sqliteError.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// ClassifyError translates the constraint violations reported by the MySQL, PostgreSQL and SQLite drivers
+// into ConstraintViolationError, returns nil for the other errors.
+// It is registered with the error package, so NewDatabaseError returns the classified errors.
+func ClassifyError(err error) microappError.DatabaseError {
+	var mysqlError *gomysqldriver.MySQLError
+	if errors.As(err, &mysqlError) {
+		return classifyMySQLError(mysqlError, err)
+	}
+	var postgresError *pgconn.PgError
+	if errors.As(err, &postgresError) {
+		return classifyPostgresError(postgresError, err)
+	}
+	var sqliteError sqlite3.Error
+	if errors.As(err, &sqliteError) {
+		return classifySQLiteError(sqliteError, err)
+	}
+	return nil
+}
+
+func classifyMySQLError(mysqlError *gomysqldriver.MySQLError, err error) microappError.DatabaseError {
+	switch mysqlError.Number {
+	case mysqlErrorDuplicateEntry:
+		constraint := submatch(mysqlDuplicateKeyPattern, mysqlError.Message)
+		// MySQL 8 qualifies the key with the table
+		constraint = constraint[strings.LastIndex(constraint, ".")+1:]
+		return microappError.NewConstraintViolationError(microappError.ViolationDuplicateKey, constraint, "", err)
+	case mysqlErrorRowIsReferenced, mysqlErrorNoReferencedRow:
+		var constraint, column string
+		if match := mysqlForeignKeyPattern.FindStringSubmatch(mysqlError.Message); match != nil {
+			constraint, column = match[1], match[2]
+		}
+		kind := microappError.ViolationForeignKey
+		if mysqlError.Number == mysqlErrorRowIsReferenced {
+			kind = microappError.ViolationReferenced
+		}
+		return microappError.NewConstraintViolationError(kind, constraint, column, err)
+	case mysqlErrorDataTooLong:
+		return microappError.NewConstraintViolationError(microappError.ViolationDataTooLong, "", submatch(mysqlColumnPattern, mysqlError.Message), err)
+	case mysqlErrorBadNull, mysqlErrorNoDefaultForField:
+		return microappError.NewConstraintViolationError(microappError.ViolationNotNull, "", submatch(mysqlColumnPattern, mysqlError.Message), err)
+	}
+	return nil
+}
+
+func classifyPostgresError(postgresError *pgconn.PgError, err error) microappError.DatabaseError {
+	switch postgresError.Code {
+	case postgresUniqueViolation:
+		return microappError.NewConstraintViolationError(microappError.ViolationDuplicateKey, postgresError.ConstraintName, singleColumn(submatch(postgresKeyPattern, postgresError.Detail)), err)
+	case postgresForeignKeyViolation:
+		kind := microappError.ViolationForeignKey
+		// Same code is reported for the delete of a referenced record, i.e. Key (id)=(1) is still referenced from table "orders".
+		if strings.Contains(postgresError.Detail, "is still referenced") {
+			kind = microappError.ViolationReferenced
+		}
+		return microappError.NewConstraintViolationError(kind, postgresError.ConstraintName, singleColumn(submatch(postgresKeyPattern, postgresError.Detail)), err)
+	case postgresStringDataRightTruncation:
+		return microappError.NewConstraintViolationError(microappError.ViolationDataTooLong, "", postgresError.ColumnName, err)
+	case postgresNotNullViolation:
+		return microappError.NewConstraintViolationError(microappError.ViolationNotNull, postgresError.ConstraintName, postgresError.ColumnName, err)
+	}
+	return nil
+}
+
+func classifySQLiteError(sqliteError sqlite3.Error, err error) microappError.DatabaseError {
+	// SQLite reports the columns qualified with the table, i.e. "UNIQUE constraint failed: widgets.name"
+	column := submatch(sqliteColumnPattern, sqliteError.Error())
+	column = column[strings.LastIndex(column, ".")+1:]
+	switch sqliteError.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return microappError.NewConstraintViolationError(microappError.ViolationDuplicateKey, "", column, err)
+	case sqlite3.ErrConstraintForeignKey:
+		return microappError.NewConstraintViolationError(microappError.ViolationForeignKey, "", "", err)
+	case sqlite3.ErrConstraintNotNull:
+		return microappError.NewConstraintViolationError(microappError.ViolationNotNull, "", column, err)
+	}
+	return nil
+}
+
+// submatch returns the first group of the pattern in the message, empty if it does not match
+func submatch(pattern *regexp.Regexp, message string) string {
+	if match := pattern.FindStringSubmatch(message); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// singleColumn returns the column of a single column key, empty for a composite key
+func singleColumn(columns string) string {
+	if strings.Contains(columns, ",") {
+		return ""
+	}
+	return strings.Trim(columns, `"`)
+}
@@ -0,0 +1,76 @@
+package dialect
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	gomysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	gormmysqldriver "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlTLSConfigName is the name of the TLS configuration registered with go-sql-driver
+const mysqlTLSConfigName = "custom"
+
+type mysqlDialect struct {
+	tlsConfigured bool
+}
+
+// Name implements Dialect
+func (dialect *mysqlDialect) Name() string {
+	return MySQL
+}
+
+// DriverName implements Dialect
+func (dialect *mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+// ConfigureTLS registers the certificates with go-sql-driver, connections fall back to preferred TLS without certificates if it fails
+func (dialect *mysqlDialect) ConfigureTLS(tlsConfig TLSConfig) error {
+	rootCertPool := x509.NewCertPool()
+	pem, err := ioutil.ReadFile(tlsConfig.CAPath)
+	if err != nil {
+		return err
+	}
+	if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
+		return errors.New("failed to append CA certificate")
+	}
+	certs, err := tls.LoadX509KeyPair(tlsConfig.CertPath, tlsConfig.KeyPath)
+	if err != nil {
+		return err
+	}
+	if err := gomysqldriver.RegisterTLSConfig(mysqlTLSConfigName, &tls.Config{
+		RootCAs:      rootCertPool,
+		Certificates: []tls.Certificate{certs},
+	}); err != nil {
+		return err
+	}
+	dialect.tlsConfigured = true
+	return nil
+}
+
+// DSN implements Dialect
+func (dialect *mysqlDialect) DSN(connection Connection) string {
+	tlsMode := "preferred"
+	if dialect.tlsConfigured {
+		tlsMode = mysqlTLSConfigName
+	}
+	return fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?multiStatements=true&charset=utf8&parseTime=True&loc=Local&tls=%v", connection.User, connection.Password, connection.Host, connection.Port, connection.Name, tlsMode)
+}
+
+// Dialector implements Dialect
+func (dialect *mysqlDialect) Dialector(sqlDB *sql.DB) gorm.Dialector {
+	return gormmysqldriver.New(gormmysqldriver.Config{Conn: sqlDB})
+}
+
+// MigrationDriver implements Dialect
+func (dialect *mysqlDialect) MigrationDriver(sqlDB *sql.DB) (database.Driver, error) {
+	return mysql.WithInstance(sqlDB, &mysql.Config{})
+}
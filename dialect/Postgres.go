@@ -0,0 +1,64 @@
+package dialect
+
+import (
+	"database/sql"
+	"net"
+	"net/url"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	gormpostgresdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type postgresDialect struct {
+	tlsConfig TLSConfig
+}
+
+// Name implements Dialect
+func (dialect *postgresDialect) Name() string {
+	return Postgres
+}
+
+// DriverName implements Dialect
+func (dialect *postgresDialect) DriverName() string {
+	return "pgx"
+}
+
+// ConfigureTLS keeps the certificate paths to be passed in the DSN
+func (dialect *postgresDialect) ConfigureTLS(tlsConfig TLSConfig) error {
+	dialect.tlsConfig = tlsConfig
+	return nil
+}
+
+// DSN returns the URL of the connection, the server certificate is verified if CA is configured else TLS is preferred
+func (dialect *postgresDialect) DSN(connection Connection) string {
+	query := url.Values{}
+	query.Set("sslmode", "prefer")
+	if dialect.tlsConfig.CAPath != "" {
+		query.Set("sslmode", "verify-full")
+		query.Set("sslrootcert", dialect.tlsConfig.CAPath)
+	}
+	if dialect.tlsConfig.CertPath != "" && dialect.tlsConfig.KeyPath != "" {
+		query.Set("sslcert", dialect.tlsConfig.CertPath)
+		query.Set("sslkey", dialect.tlsConfig.KeyPath)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(connection.User, connection.Password),
+		Host:     net.JoinHostPort(connection.Host, connection.Port),
+		Path:     "/" + connection.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// Dialector implements Dialect
+func (dialect *postgresDialect) Dialector(sqlDB *sql.DB) gorm.Dialector {
+	return gormpostgresdriver.New(gormpostgresdriver.Config{Conn: sqlDB})
+}
+
+// MigrationDriver implements Dialect
+func (dialect *postgresDialect) MigrationDriver(sqlDB *sql.DB) (database.Driver, error) {
+	return postgres.WithInstance(sqlDB, &postgres.Config{})
+}
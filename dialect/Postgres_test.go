@@ -0,0 +1,169 @@
+package dialect_test
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/audit"
+	"github.com/islax/microapp/dialect"
+	"github.com/islax/microapp/event/outbox"
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/model"
+	"github.com/islax/microapp/repository"
+	"github.com/islax/microapp/repository/generic"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	model.TenantBase
+	Name string
+}
+
+// openPostgres returns the gorm.DB of the postgres dialect which does not execute the statements but records their SQL, no server is needed
+func openPostgres(t *testing.T) (*gorm.DB, *[]string) {
+	postgres, err := dialect.New(dialect.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := sql.Open(postgres.DriverName(), postgres.DSN(dialect.Connection{Host: "localhost", Port: "5432", User: "admin", Password: "admin", Name: "islax"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.Dialector(sqlDB), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := make([]string, 0)
+	record := func(db *gorm.DB) {
+		statements = append(statements, db.Statement.SQL.String())
+	}
+	db.Callback().Create().After("gorm:create").Register("test:record", record)
+	db.Callback().Query().After("gorm:query").Register("test:record", record)
+	db.Callback().Update().After("gorm:update").Register("test:record", record)
+	db.Callback().Delete().After("gorm:delete").Register("test:record", record)
+	db.Callback().Raw().After("gorm:raw").Register("test:record", record)
+	return db, &statements
+}
+
+func expectStatement(t *testing.T, statements []string, expected ...string) {
+	t.Helper()
+	for _, statement := range statements {
+		matched := true
+		for _, part := range expected {
+			if !strings.Contains(statement, part) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return
+		}
+	}
+	t.Errorf("Expected a statement containing %q, got %q", expected, statements)
+}
+
+func TestPostgresMigration(t *testing.T) {
+	db, statements := openPostgres(t)
+	if err := db.Migrator().CreateTable(&outbox.Entry{}, &audit.Entry{}, &widget{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range *statements {
+		if strings.Contains(statement, "mediumtext") {
+			t.Errorf("Expected no mediumtext column on postgres, got %v", statement)
+		}
+	}
+	expectStatement(t, *statements, `CREATE TABLE "eventOutbox"`, `"payload" text`)
+	expectStatement(t, *statements, `"changes" text`)
+	expectStatement(t, *statements, `"tenantId" varchar(36)`, `"createdOn" timestamptz`)
+}
+
+func TestPostgresQueries(t *testing.T) {
+	db, statements := openPostgres(t)
+	uow := repository.NewUnitOfWork(db, true, zerolog.Nop(), log.Config{})
+	tenantID := uuid.NewV4()
+
+	var widgets []widget
+	if err := repository.NewRepository().GetAllForTenant(uow, &widgets, tenantID, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectStatement(t, *statements, `SELECT * FROM "widgets"`, `"tenantId" = $1`)
+
+	*statements = (*statements)[:0]
+	paginator := &repository.CursorPaginator{Limit: 10, SortColumns: []repository.SortColumn{{Name: "createdOn", Direction: "DESC"}}}
+	if _, err := generic.NewRepository[widget]().PageByCursorForTenant(uow, tenantID, paginator); err != nil {
+		t.Fatal(err)
+	}
+	expectStatement(t, *statements, `"tenantId" = $1`, `ORDER BY "createdOn" DESC,"id" DESC`)
+
+	*statements = (*statements)[:0]
+	if _, err := audit.NewRecorder(audit.Config{}).EntityTrail(uow, "widget", uuid.NewV4().String()); err != nil {
+		t.Fatal(err)
+	}
+	expectStatement(t, *statements, `"entity" = $1`, `"entityId" = $2`, `ORDER BY "createdOn" DESC`)
+
+	*statements = (*statements)[:0]
+	entity := &widget{TenantBase: model.TenantBase{ID: uuid.NewV4(), TenantID: tenantID, UpdatedAt: time.Now()}, Name: "a"}
+	// No row is affected in dry run, so the update fails with conflict after the statement is built
+	repository.NewRepository().CheckVersionAndUpdate(uow, entity, nil)
+	expectStatement(t, *statements, `UPDATE "widgets"`, `WHERE "modifiedOn" = $`)
+
+	*statements = (*statements)[:0]
+	relay := outbox.NewRelay(db, nil, zerolog.Nop(), outbox.RelayConfig{MaxAttempts: 3, BatchSize: 10, PollInterval: time.Minute})
+	if _, err := relay.Backlog(); err != nil {
+		t.Fatal(err)
+	}
+	expectStatement(t, *statements, `"publishedOn" IS NULL`, `"attempts" >= $1`)
+}
+
+// TestPostgresServer migrates and queries a PostgreSQL server, it runs only if ISLA_TEST_POSTGRES_DSN is set
+func TestPostgresServer(t *testing.T) {
+	dsn := os.Getenv("ISLA_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("ISLA_TEST_POSTGRES_DSN is not set")
+	}
+	postgres, _ := dialect.New(dialect.Postgres)
+	db, err := dialect.Open(postgres, dsn, &gorm.Config{}, dialect.PoolConfig{MaxOpenConnections: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrator().DropTable(&outbox.Entry{}, &audit.Entry{}, &widget{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&outbox.Entry{}, &audit.Entry{}, &widget{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tenantID := uuid.NewV4()
+	uow := repository.NewUnitOfWork(db, false, zerolog.Nop(), log.Config{})
+	entity := &widget{TenantBase: model.TenantBase{ID: uuid.NewV4(), TenantID: tenantID}, Name: "a"}
+	if err := repository.NewRepository().Add(uow, entity); err != nil {
+		t.Fatal(err)
+	}
+	entity.Name = "b"
+	if err := repository.NewRepository().CheckVersionAndUpdate(uow, entity, nil); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+
+	uow = repository.NewUnitOfWork(db, true, zerolog.Nop(), log.Config{})
+	page, err := generic.NewRepository[widget]().PageForTenant(uow, tenantID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Items[0].Name != "b" {
+		t.Errorf("Expected the updated widget of the tenant, got %+v", page)
+	}
+	if _, err := audit.NewRecorder(audit.Config{}).QueryForTenant(uow, tenantID); err != nil {
+		t.Error(err)
+	}
+	if _, err := outbox.NewRelay(db, nil, zerolog.Nop(), outbox.RelayConfig{MaxAttempts: 3, BatchSize: 10, PollInterval: time.Minute}).Backlog(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,42 @@
+package dialect
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	gormsqlitedriver "gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type sqliteDialect struct{}
+
+// Name implements Dialect
+func (dialect *sqliteDialect) Name() string {
+	return SQLite
+}
+
+// DriverName implements Dialect
+func (dialect *sqliteDialect) DriverName() string {
+	return gormsqlitedriver.DriverName
+}
+
+// ConfigureTLS is not applicable to SQLite
+func (dialect *sqliteDialect) ConfigureTLS(tlsConfig TLSConfig) error {
+	return nil
+}
+
+// DSN returns the database name of the connection as the file path, it may have the query parameters of go-sqlite3
+func (dialect *sqliteDialect) DSN(connection Connection) string {
+	return connection.Name
+}
+
+// Dialector implements Dialect
+func (dialect *sqliteDialect) Dialector(sqlDB *sql.DB) gorm.Dialector {
+	return &gormsqlitedriver.Dialector{Conn: sqlDB}
+}
+
+// MigrationDriver implements Dialect
+func (dialect *sqliteDialect) MigrationDriver(sqlDB *sql.DB) (database.Driver, error) {
+	return sqlite3.WithInstance(sqlDB, &sqlite3.Config{})
+}
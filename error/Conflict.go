@@ -0,0 +1,17 @@
+package error
+
+// IsConflictError returns whether the given error is a ConflictError
+func IsConflictError(err error) bool {
+	_, ok := err.(ConflictError)
+	return ok
+}
+
+// NewConflictError creates a new conflict error, returned when the record is modified since it is read
+func NewConflictError(err error) ConflictError {
+	return ConflictError{&databaseErrorImpl{createUnexpectedErrorImpl(ErrorCodeConflict, err)}}
+}
+
+// ConflictError is a DatabaseError indicating failed optimistic concurrency check
+type ConflictError struct {
+	*databaseErrorImpl
+}
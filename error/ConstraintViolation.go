@@ -0,0 +1,102 @@
+package error
+
+import "sync"
+
+const (
+	// ViolationDuplicateKey is the violation of a unique or primary key constraint
+	ViolationDuplicateKey = "duplicateKey"
+	// ViolationForeignKey is the violation of a foreign key constraint by a reference to a record which does not exist
+	ViolationForeignKey = "foreignKey"
+	// ViolationReferenced is the violation of a foreign key constraint by deleting or changing the key of a record which is referenced
+	ViolationReferenced = "referenced"
+	// ViolationDataTooLong is a value exceeding the length of its column
+	ViolationDataTooLong = "dataTooLong"
+	// ViolationNotNull is a null value for a not null column
+	ViolationNotNull = "notNull"
+)
+
+// DatabaseErrorClassifier translates a driver error into a typed DatabaseError, returns nil if the error is not known to it
+type DatabaseErrorClassifier func(err error) DatabaseError
+
+var (
+	classifiers      []DatabaseErrorClassifier
+	classifiersMutex sync.RWMutex
+
+	constraintFields      = make(map[string]string)
+	constraintFieldsMutex sync.RWMutex
+)
+
+// RegisterDatabaseErrorClassifier registers the classifier used by NewDatabaseError to translate the driver errors
+func RegisterDatabaseErrorClassifier(classifier DatabaseErrorClassifier) {
+	classifiersMutex.Lock()
+	defer classifiersMutex.Unlock()
+	classifiers = append(classifiers, classifier)
+}
+
+// RegisterConstraintField maps the constraint (e.g. unique index) to the API field reported by ConstraintViolationError.ValidationError
+func RegisterConstraintField(constraint string, field string) {
+	constraintFieldsMutex.Lock()
+	defer constraintFieldsMutex.Unlock()
+	constraintFields[constraint] = field
+}
+
+func classify(err error) DatabaseError {
+	classifiersMutex.RLock()
+	defer classifiersMutex.RUnlock()
+	for _, classifier := range classifiers {
+		if classified := classifier(err); classified != nil {
+			return classified
+		}
+	}
+	return nil
+}
+
+// IsConstraintViolationError returns whether the given error is a ConstraintViolationError of the kind, any kind if empty
+func IsConstraintViolationError(err error, kind string) bool {
+	violation, ok := err.(ConstraintViolationError)
+	return ok && (kind == "" || violation.Kind == kind)
+}
+
+// NewConstraintViolationError creates a new constraint violation error.
+// 'kind' should be one of the Violation constants, 'constraint' and 'column' are empty if the driver does not report them.
+func NewConstraintViolationError(kind string, constraint string, column string, err error) ConstraintViolationError {
+	return ConstraintViolationError{&databaseErrorImpl{createUnexpectedErrorImpl(ErrorCodeDatabaseFailure, err)}, kind, constraint, column}
+}
+
+// ConstraintViolationError is a DatabaseError indicating the record violates a constraint of the database
+type ConstraintViolationError struct {
+	*databaseErrorImpl
+	Kind       string
+	Constraint string
+	Column     string
+}
+
+// ErrorCode returns the error code of the violation to report against the field
+func (e ConstraintViolationError) ErrorCode() string {
+	switch e.Kind {
+	case ViolationDuplicateKey:
+		return ErrorCodeDuplicateValue
+	case ViolationForeignKey, ViolationReferenced:
+		return ErrorCodeInvalidReference
+	case ViolationDataTooLong:
+		return ErrorCodeTooLong
+	case ViolationNotNull:
+		return ErrorCodeRequired
+	}
+	return ErrorCodeInvalidValue
+}
+
+// ValidationError returns the violation as field validation error. The field is the one registered for the constraint (RegisterConstraintField)
+// else the column, "payload" if neither is known or the record is referenced by another record, so that the database names are not exposed.
+func (e ConstraintViolationError) ValidationError() ValidationError {
+	constraintFieldsMutex.RLock()
+	field := constraintFields[e.Constraint]
+	constraintFieldsMutex.RUnlock()
+	if field == "" && e.Kind != ViolationReferenced {
+		field = e.Column
+	}
+	if field == "" {
+		field = "payload"
+	}
+	return NewInvalidFieldsError(map[string]string{field: e.ErrorCode()})
+}
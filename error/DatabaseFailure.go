@@ -6,8 +6,11 @@ import (
 	"gorm.io/gorm"
 )
 
-// NewDatabaseError creates a new database error
+// NewDatabaseError creates a new database error, the constraint violations are translated by the registered classifiers
 func NewDatabaseError(err error) DatabaseError {
+	if classified := classify(err); classified != nil {
+		return classified
+	}
 	return &databaseErrorImpl{createUnexpectedErrorImpl(ErrorCodeDatabaseFailure, err)}
 }
 
@@ -51,6 +51,11 @@ func (e unexpectedErrorImpl) GetCause() error {
 	return e.cause
 }
 
+// Unwrap returns the cause, so that errors.Is and errors.As see through the unexpected error
+func (e unexpectedErrorImpl) Unwrap() error {
+	return e.cause
+}
+
 // GetErrCode returns the error code
 func (e unexpectedErrorImpl) GetErrorCode() string {
 	return e.errCode
@@ -4,6 +4,8 @@ package error
 const (
 	// ErrorCodeAPICallFailure error code for API call failure
 	ErrorCodeAPICallFailure = "Key_APICallFailure"
+	// ErrorCodeConflict error code for resource modified since it is read
+	ErrorCodeConflict = "Key_Conflict"
 	// ErrorCodeCryptoFailure error code for encrypt / decrypt / hashing failure
 	ErrorCodeCryptoFailure = "Key_CryptoFailure"
 	// ErrorCodeDatabaseFailure error code for database falure
@@ -24,6 +26,8 @@ const (
 	ErrorCodeInvalidJSON = "Key_InvalidJSON"
 	// ErrorCodeInvalidPublicKey error code for invalid public cert
 	ErrorCodeInvalidPublicKey = "Key_InvalidPublicKey"
+	// ErrorCodeInvalidReference error code for reference to a non existent or in use record
+	ErrorCodeInvalidReference = "Key_InvalidReference"
 	// ErrorCodeInvalidRequestPayload error code for invalid request payload
 	ErrorCodeInvalidRequestPayload = "Key_InvalidRequestPayload"
 	// ErrorCodeInvalidValue error code for invalid value
@@ -32,10 +36,14 @@ const (
 	ErrorCodeJSONMarshalFailure = "Key_JSONMarshalFailure"
 	// ErrorCodeNotExists error code for not exists
 	ErrorCodeNotExists = "Key_NotExists"
+	// ErrorCodePreconditionFailed error code for failed If-Match / If-None-Match precondition
+	ErrorCodePreconditionFailed = "Key_PreconditionFailed"
 	// ErrorCodeReadWriteFailure error code for io error
 	ErrorCodeReadWriteFailure = "Key_ReadWriteFailure"
 	// ErrorCodeRequired error code for required fields
 	ErrorCodeRequired = "Key_Required"
 	// ErrorCodeStringExpected error code for string type
 	ErrorCodeStringExpected = "Key_StringExpected"
+	// ErrorCodeTooLong error code for value exceeding the length of the field
+	ErrorCodeTooLong = "Key_TooLong"
 )
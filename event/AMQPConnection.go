@@ -0,0 +1,81 @@
+package event
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+// AMQPConnectionConfig represents the settings to connect to RabbitMQ
+type AMQPConnectionConfig struct {
+	Host          string
+	Port          string
+	User          string
+	Password      string
+	TLS           bool
+	CACert        string // PEM encoded CA certificate, required if TLS is enabled
+	ClientCert    string // PEM encoded client certificate, required if TLS is enabled
+	ClientCertKey string // PEM encoded client certificate key, required if TLS is enabled
+}
+
+// AMQPConnectionConfigFromEnv reads RabbitMQ connection settings from ISLA_QUEUE_* environment variables
+func AMQPConnectionConfigFromEnv() AMQPConnectionConfig {
+	connectionConfig := AMQPConnectionConfig{Host: "localhost", Port: "5672", User: "guest", Password: "guest"}
+	if queueHost, ok := os.LookupEnv("ISLA_QUEUE_HOST"); ok {
+		connectionConfig.Host = queueHost
+	}
+	if queuePassword, ok := os.LookupEnv("ISLA_QUEUE_PWD"); ok {
+		connectionConfig.Password = queuePassword
+	}
+	if queueUser, ok := os.LookupEnv("ISLA_QUEUE_USER"); ok {
+		connectionConfig.User = queueUser
+	}
+	if queuePort, ok := os.LookupEnv("ISLA_QUEUE_PORT"); ok {
+		connectionConfig.Port = queuePort
+	}
+	if tls, ok := os.LookupEnv("ISLA_QUEUE_TLS_ENABLED"); ok {
+		connectionConfig.TLS, _ = strconv.ParseBool(tls)
+	}
+	connectionConfig.CACert, _ = os.LookupEnv("ISLA_QUEUE_RMQ_CA_CERT")
+	connectionConfig.ClientCert, _ = os.LookupEnv("ISLA_QUEUE_RMQ_CERT")
+	connectionConfig.ClientCertKey, _ = os.LookupEnv("ISLA_QUEUE_RMQ_CERT_KEY")
+	return connectionConfig
+}
+
+// ConnectionString returns the connection string and the connection string with masked credentials to be used for logging
+func (connectionConfig AMQPConnectionConfig) ConnectionString() (string, string) {
+	queueProtocol := "amqp"
+	if connectionConfig.TLS {
+		queueProtocol = "amqps"
+	}
+	return fmt.Sprintf("%v://%v:%v@%v:%v/", queueProtocol, connectionConfig.User, connectionConfig.Password, connectionConfig.Host, connectionConfig.Port),
+		fmt.Sprintf("%v://%v:%v@%v:%v/", queueProtocol, "######", "######", connectionConfig.Host, connectionConfig.Port)
+}
+
+// DialAMQP connects to the RabbitMQ with given settings
+func DialAMQP(connectionConfig AMQPConnectionConfig) (*amqp.Connection, error) {
+	var cfg *tls.Config = nil
+	if connectionConfig.TLS {
+		if strings.TrimSpace(connectionConfig.CACert) == "" || strings.TrimSpace(connectionConfig.ClientCert) == "" || strings.TrimSpace(connectionConfig.ClientCertKey) == "" {
+			return nil, fmt.Errorf("One or more client certificates not found")
+		}
+
+		cfg = &tls.Config{}
+		cfg.RootCAs = x509.NewCertPool()
+		cfg.RootCAs.AppendCertsFromPEM([]byte(connectionConfig.CACert))
+
+		cert, err := tls.X509KeyPair([]byte(connectionConfig.ClientCert), []byte(connectionConfig.ClientCertKey))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+
+	connectionString, _ := connectionConfig.ConnectionString()
+	return amqp.DialTLS(connectionString, cfg)
+}
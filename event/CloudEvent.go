@@ -0,0 +1,111 @@
+package event
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+	"github.com/streadway/amqp"
+)
+
+// CloudEventsContentType is the content type of the events published in CloudEvents structured mode
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEventsSpecVersion is the version of CloudEvents specification the envelope conforms to
+const CloudEventsSpecVersion = "1.0"
+
+// CloudEvent represents the CloudEvents 1.0 envelope of the event, tenant, schema version and correlation id are carried as extension attributes
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	TenantID        string          `json:"tenantid,omitempty"`
+	SchemaVersion   string          `json:"schemaversion,omitempty"`
+	CorrelationID   string          `json:"correlationid,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// CloudEventsConfig represents the settings of the CloudEvents envelope
+type CloudEventsConfig struct {
+	Source               string // Source of the events, usually the name of the app
+	DefaultSchemaVersion string // Schema version of the payloads which do not implement SchemaVersioner
+}
+
+// SchemaVersioner is implemented by the event payloads to specify the version of their schema
+type SchemaVersioner interface {
+	SchemaVersion() string
+}
+
+// ParseCloudEvent parses the event published in CloudEvents structured mode, it returns nil if the content type is not CloudEvents
+func ParseCloudEvent(contentType string, body []byte) (*CloudEvent, error) {
+	if !strings.HasPrefix(contentType, CloudEventsContentType) {
+		return nil, nil
+	}
+	cloudEvent := &CloudEvent{}
+	if err := json.Unmarshal(body, cloudEvent); err != nil {
+		return nil, err
+	}
+	return cloudEvent, nil
+}
+
+// Payload returns the data of the event
+func (cloudEvent *CloudEvent) Payload() []byte {
+	if cloudEvent.DataBase64 != nil {
+		return cloudEvent.DataBase64
+	}
+	return cloudEvent.Data
+}
+
+// wrapInCloudEvent replaces the body of the publishing with the CloudEvents envelope
+func wrapInCloudEvent(cloudEventsConfig *CloudEventsConfig, command *queueCommand, routingKey string, publishing *amqp.Publishing) error {
+	schemaVersion := command.schemaVersion
+	if schemaVersion == "" {
+		schemaVersion = cloudEventsConfig.DefaultSchemaVersion
+	}
+	cloudEvent := &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              command.messageID,
+		Source:          cloudEventsConfig.Source,
+		Type:            routingKey,
+		Time:            command.createdOn.UTC(),
+		DataContentType: publishing.ContentType,
+		TenantID:        tenantIDFromToken(command.token),
+		SchemaVersion:   schemaVersion,
+		CorrelationID:   command.corelationID,
+	}
+	if json.Valid(publishing.Body) {
+		cloudEvent.Data = publishing.Body
+	} else {
+		cloudEvent.DataBase64 = publishing.Body
+	}
+
+	body, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+	publishing.ContentType = CloudEventsContentType
+	publishing.Timestamp = cloudEvent.Time
+	publishing.Type = routingKey
+	publishing.AppId = cloudEventsConfig.Source
+	publishing.Body = body
+	return nil
+}
+
+// tenantIDFromToken reads the tenant claim of the token, the token is not verified as it is verified by the consumers of the event
+func tenantIDFromToken(rawToken string) string {
+	splitted := strings.Split(rawToken, " ")
+	if len(splitted) != 2 {
+		return ""
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(splitted[1], claims); err != nil {
+		return ""
+	}
+	tenantID, _ := claims["tenant"].(string)
+	return tenantID
+}
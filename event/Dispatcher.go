@@ -1,6 +1,53 @@
 package event
 
+import (
+	"context"
+	"time"
+)
+
 // Dispatcher interface must be implemented by Queue
 type Dispatcher interface {
 	DispatchEvent(token string, corelationID string, topic string, payload interface{})
 }
+
+// NonBlockingDispatcher is implemented by dispatchers that can dispatch without blocking the caller
+type NonBlockingDispatcher interface {
+	TryDispatchEvent(token string, corelationID string, topic string, payload interface{}) error
+}
+
+// ContextDispatcher is implemented by dispatchers that propagate the trace of the context to the consumers of the event
+type ContextDispatcher interface {
+	DispatchEventWithContext(ctx context.Context, token string, corelationID string, topic string, payload interface{})
+}
+
+// Publisher is implemented by dispatchers that can publish a message synchronously and report the failure
+type Publisher interface {
+	Publish(message *Message) error
+}
+
+// ReadinessWaiter is implemented by dispatchers that connect asynchronously
+type ReadinessWaiter interface {
+	WaitUntilReady(ctx context.Context) error
+}
+
+// ConnectionStater is implemented by dispatchers and monitors that hold a connection to the broker
+type ConnectionStater interface {
+	IsConnected() bool
+}
+
+// Closer is implemented by dispatchers that hold the events to be published in background
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Message represents an event to be published
+type Message struct {
+	ID            string
+	Token         string
+	CorrelationID string
+	Topic         string
+	Payload       interface{}
+	SchemaVersion string          // Version of the payload schema, read from the payload if it implements SchemaVersioner
+	Time          time.Time       // Time at which the event occurred, current time is used if not set
+	Context       context.Context // Trace of the context is propagated to the consumers of the event, optional
+}
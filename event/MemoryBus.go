@@ -0,0 +1,130 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MemoryMessage represents an event on the in-process bus
+type MemoryMessage struct {
+	RoutingKey  string
+	MessageID   string
+	ContentType string
+	Headers     map[string]interface{}
+	Body        []byte
+}
+
+// memoryQueueCapacity is the number of messages a queue of the memory bus holds till they are consumed
+const memoryQueueCapacity = 1000
+
+// ErrMemoryQueueFull is returned by MemoryBus.Publish if a queue bound to the routing key is full, the message is not delivered to that queue
+var ErrMemoryQueueFull = errors.New("memory bus queue is full")
+
+// MemoryBus is an in-process topic exchange, it is meant for unit tests and single binary deployments
+type MemoryBus struct {
+	mutex                  sync.RWMutex
+	queues                 map[string]*memoryQueue
+	queueCapacity          int
+	exclusiveQueueSequence uint64
+}
+
+type memoryQueue struct {
+	name        string
+	bindings    []string
+	messages    chan *MemoryMessage
+	subscribers int
+}
+
+// NewMemoryBus creates a new in-process bus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{queues: make(map[string]*memoryQueue), queueCapacity: memoryQueueCapacity}
+}
+
+// Publish routes the message to all the queues bound to its routing key without waiting for the consumers.
+// Publish does not block, the queues which are full are skipped and ErrMemoryQueueFull is returned.
+func (bus *MemoryBus) Publish(message *MemoryMessage) error {
+	bus.mutex.RLock()
+	matchedQueues := make([]*memoryQueue, 0)
+	for _, queue := range bus.queues {
+		for _, binding := range queue.bindings {
+			if matchTopic(binding, message.RoutingKey) {
+				matchedQueues = append(matchedQueues, queue)
+				break
+			}
+		}
+	}
+	bus.mutex.RUnlock()
+
+	fullQueues := make([]string, 0)
+	for _, queue := range matchedQueues {
+		select {
+		case queue.messages <- message:
+		default:
+			fullQueues = append(fullQueues, queue.name)
+		}
+	}
+	if len(fullQueues) > 0 {
+		return fmt.Errorf("%w: %v", ErrMemoryQueueFull, strings.Join(fullQueues, ", "))
+	}
+	return nil
+}
+
+// Subscribe binds the named queue to the given routing keys and returns the channel to consume from and the function to unsubscribe.
+// Subscribers of the same named queue compete for the messages, an empty queue name creates a queue exclusive to the subscriber.
+func (bus *MemoryBus) Subscribe(queueName string, routingKeys []string) (<-chan *MemoryMessage, func()) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	if queueName == "" {
+		bus.exclusiveQueueSequence++
+		queueName = fmt.Sprintf("exclusive.%d", bus.exclusiveQueueSequence)
+	}
+
+	queue, ok := bus.queues[queueName]
+	if !ok {
+		queue = &memoryQueue{name: queueName, messages: make(chan *MemoryMessage, bus.queueCapacity)}
+		bus.queues[queueName] = queue
+	}
+	for _, routingKey := range routingKeys {
+		queue.bindings = append(queue.bindings, strings.ReplaceAll(routingKey, "_", "."))
+	}
+	queue.subscribers++
+
+	var unsubscribeOnce sync.Once
+	return queue.messages, func() {
+		unsubscribeOnce.Do(func() {
+			bus.mutex.Lock()
+			defer bus.mutex.Unlock()
+			queue.subscribers--
+			if queue.subscribers == 0 {
+				delete(bus.queues, queueName)
+			}
+		})
+	}
+}
+
+// matchTopic checks the routing key against the binding, '*' matches exactly one word and '#' matches zero or more words
+func matchTopic(binding string, routingKey string) bool {
+	return matchTopicWords(strings.Split(binding, "."), strings.Split(routingKey, "."))
+}
+
+func matchTopicWords(bindingWords []string, routingKeyWords []string) bool {
+	if len(bindingWords) == 0 {
+		return len(routingKeyWords) == 0
+	}
+	switch bindingWords[0] {
+	case "#":
+		for i := 0; i <= len(routingKeyWords); i++ {
+			if matchTopicWords(bindingWords[1:], routingKeyWords[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(routingKeyWords) > 0 && matchTopicWords(bindingWords[1:], routingKeyWords[1:])
+	default:
+		return len(routingKeyWords) > 0 && bindingWords[0] == routingKeyWords[0] && matchTopicWords(bindingWords[1:], routingKeyWords[1:])
+	}
+}
@@ -0,0 +1,35 @@
+package event
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryBusPublish(t *testing.T) {
+	bus := NewMemoryBus()
+	bus.queueCapacity = 1
+	tenants, unsubscribeTenants := bus.Subscribe("tenants", []string{"tenant.*"})
+	defer unsubscribeTenants()
+	all, unsubscribeAll := bus.Subscribe("all", []string{"#"})
+	defer unsubscribeAll()
+
+	if err := bus.Publish(&MemoryMessage{RoutingKey: "tenant.added", MessageID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(&MemoryMessage{RoutingKey: "user.added", MessageID: "2"}); !errors.Is(err, ErrMemoryQueueFull) || err.Error() != "memory bus queue is full: all" {
+		t.Fatalf("Expected [%v: all], Got [%v]", ErrMemoryQueueFull, err)
+	}
+
+	if message := <-tenants; message.MessageID != "1" {
+		t.Errorf("Expected message 1 on tenants queue, Got %v", message.MessageID)
+	}
+	if message := <-all; message.MessageID != "1" {
+		t.Errorf("Expected message 1 on all queue, Got %v", message.MessageID)
+	}
+	if err := bus.Publish(&MemoryMessage{RoutingKey: "user.added", MessageID: "3"}); err != nil {
+		t.Errorf("Expected message to be published once the queue is consumed, Got %v", err)
+	}
+	if message := <-all; message.MessageID != "3" {
+		t.Errorf("Expected message 3 on all queue, Got %v", message.MessageID)
+	}
+}
@@ -0,0 +1,43 @@
+package event
+
+import "context"
+
+// MemoryEventDispatcher is an event dispatcher that sends event to the in-process bus
+type MemoryEventDispatcher struct {
+	bus         *MemoryBus
+	cloudEvents *CloudEventsConfig
+}
+
+// NewMemoryEventDispatcher create and returns a new MemoryEventDispatcher
+func NewMemoryEventDispatcher(bus *MemoryBus) *MemoryEventDispatcher {
+	return &MemoryEventDispatcher{bus: bus}
+}
+
+// NewMemoryEventDispatcherWithCloudEvents create and returns a new MemoryEventDispatcher which wraps the events in CloudEvents envelope
+func NewMemoryEventDispatcherWithCloudEvents(bus *MemoryBus, cloudEvents *CloudEventsConfig) *MemoryEventDispatcher {
+	return &MemoryEventDispatcher{bus: bus, cloudEvents: cloudEvents}
+}
+
+// DispatchEvent dispatches events to the in-process bus
+func (eventDispatcher *MemoryEventDispatcher) DispatchEvent(token string, corelationID string, topic string, payload interface{}) {
+	eventDispatcher.TryDispatchEvent(token, corelationID, topic, payload)
+}
+
+// DispatchEventWithContext dispatches events to the in-process bus, trace of the context is propagated in the event headers
+func (eventDispatcher *MemoryEventDispatcher) DispatchEventWithContext(ctx context.Context, token string, corelationID string, topic string, payload interface{}) {
+	eventDispatcher.Publish(&Message{Token: token, CorrelationID: corelationID, Topic: topic, Payload: payload, Context: ctx})
+}
+
+// TryDispatchEvent dispatches events to the in-process bus, returns error if the payload can not be encoded
+func (eventDispatcher *MemoryEventDispatcher) TryDispatchEvent(token string, corelationID string, topic string, payload interface{}) error {
+	return eventDispatcher.Publish(&Message{Token: token, CorrelationID: corelationID, Topic: topic, Payload: payload})
+}
+
+// Publish publishes the given message to the in-process bus
+func (eventDispatcher *MemoryEventDispatcher) Publish(message *Message) error {
+	routingKey, publishing, err := toPublishing(newQueueCommand(message), eventDispatcher.cloudEvents)
+	if err != nil {
+		return err
+	}
+	return eventDispatcher.bus.Publish(&MemoryMessage{RoutingKey: routingKey, MessageID: publishing.MessageId, ContentType: publishing.ContentType, Headers: publishing.Headers, Body: publishing.Body})
+}
@@ -1,25 +1,54 @@
 package event
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/islax/microapp/tracing"
 	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
 	"github.com/streadway/amqp"
 )
 
+var (
+	errPublishNacked           = errors.New("publish not acknowledged by the broker")
+	errPublishConfirmTimedOut  = errors.New("timed out waiting for publish confirmation")
+	errPublishChannelNotActive = errors.New("publish channel is not active")
+)
+
 type queueCommand struct {
-	token        string
-	topic        string
-	corelationID string
-	payload      interface{}
+	messageID     string
+	token         string
+	topic         string
+	corelationID  string
+	payload       interface{}
+	schemaVersion string
+	createdOn     time.Time
+	traceHeaders  map[string]string // W3C trace context of the producer span
+	spoolFile     string
+}
+
+// newQueueCommand creates the command to publish the message, id and time of the message are set if missing so that they remain same across the publish attempts
+func newQueueCommand(message *Message) *queueCommand {
+	command := &queueCommand{messageID: message.ID, token: message.Token, topic: message.Topic, corelationID: message.CorrelationID, payload: message.Payload, schemaVersion: message.SchemaVersion, createdOn: message.Time}
+	if command.messageID == "" {
+		command.messageID = uuid.NewV4().String()
+	}
+	if command.createdOn.IsZero() {
+		command.createdOn = time.Now()
+	}
+	if schemaVersioner, ok := message.Payload.(SchemaVersioner); ok && command.schemaVersion == "" {
+		command.schemaVersion = schemaVersioner.SchemaVersion()
+	}
+	if message.Context != nil {
+		command.traceHeaders = tracing.InjectProducerSpan(message.Context, strings.ReplaceAll(command.topic, "_", "."), command.messageID)
+	}
+	return command
 }
 
 type retryCommand struct {
@@ -27,32 +56,94 @@ type retryCommand struct {
 	command    *queueCommand
 }
 
+// RabbitMQDispatcherConfig represents the configuration for RabbitMQEventDispatcher
+type RabbitMQDispatcherConfig struct {
+	Connection          *AMQPConnectionConfig // Settings to connect to RabbitMQ, read from environment variables if not set
+	SpoolDir            string                // Directory to spool the unconfirmed / overflow events, spool is disabled if empty
+	SpoolMaxMessages    int                   // Maximum number of events that can be spooled, 0 means unlimited
+	ConfirmTimeout      time.Duration         // Duration to wait for the broker to confirm a synchronously published event
+	SpoolReplayInterval time.Duration         // Interval at which the spooled events are replayed
+	CloudEvents         *CloudEventsConfig    // Settings of the CloudEvents envelope, events are published without envelope if not set
+}
+
+// pendingConfirm represents a published event waiting for confirmation from the broker
+type pendingConfirm struct {
+	command    *queueCommand
+	retryCount int
+	done       chan error // Set for synchronous publish only
+}
+
+// confirmTracker tracks the publish confirmations of a channel, delivery tags are scoped to the channel
+type confirmTracker struct {
+	mutex       sync.Mutex
+	channel     *amqp.Channel
+	deliveryTag uint64
+	outstanding map[uint64]*pendingConfirm
+}
+
 // RabbitMQEventDispatcher is an event dispatcher that sends event to the RabbitMQ Exchange
 type RabbitMQEventDispatcher struct {
 	logger                 *zerolog.Logger
 	exchangeName           string
+	config                 RabbitMQDispatcherConfig
 	connection             *amqp.Connection
 	channel                *amqp.Channel
+	tracker                *confirmTracker
+	spool                  *spool
 	sendChannel            chan *queueCommand
 	retryChannel           chan *retryCommand
+	replayChannel          chan struct{}
 	connectionCloseChannel chan *amqp.Error
 	connectionMutex        sync.Mutex
+	ready                  chan struct{}
+	readyOnce              sync.Once
+	closed                 chan struct{}
+	closeOnce              sync.Once
 }
 
 // NewRabbitMQEventDispatcher create and returns a new RabbitMQEventDispatcher
 func NewRabbitMQEventDispatcher(logger *zerolog.Logger) (*RabbitMQEventDispatcher, error) {
+	return NewRabbitMQEventDispatcherWithConfig(logger, RabbitMQDispatcherConfig{})
+}
+
+// NewRabbitMQEventDispatcherWithConfig create and returns a new RabbitMQEventDispatcher which spools the events as per given configuration
+func NewRabbitMQEventDispatcherWithConfig(logger *zerolog.Logger, config RabbitMQDispatcherConfig) (*RabbitMQEventDispatcher, error) {
 	sendChannel := make(chan *queueCommand, 200)
 	retryChannel := make(chan *retryCommand, 200)
 	connectionCloseChannel := make(chan *amqp.Error)
 
 	ctxLogger := logger.With().Str("module", "RabbitMQEventDispatcher").Logger()
 
+	if config.Connection == nil {
+		connectionConfig := AMQPConnectionConfigFromEnv()
+		config.Connection = &connectionConfig
+	}
+	if config.ConfirmTimeout <= 0 {
+		config.ConfirmTimeout = 30 * time.Second
+	}
+	if config.SpoolReplayInterval <= 0 {
+		config.SpoolReplayInterval = 10 * time.Second
+	}
+
 	dispatcher := &RabbitMQEventDispatcher{
 		logger:                 &ctxLogger,
 		exchangeName:           "isla_exchange",
+		config:                 config,
 		sendChannel:            sendChannel,
 		retryChannel:           retryChannel,
+		replayChannel:          make(chan struct{}, 1),
 		connectionCloseChannel: connectionCloseChannel,
+		ready:                  make(chan struct{}),
+		closed:                 make(chan struct{}),
+	}
+
+	if strings.TrimSpace(config.SpoolDir) != "" {
+		spool, err := newSpool(config.SpoolDir, config.SpoolMaxMessages)
+		if err != nil {
+			return nil, err
+		}
+		dispatcher.spool = spool
+		go dispatcher.replaySpool()
 	}
 
 	go dispatcher.rabbitConnector()
@@ -63,9 +154,138 @@ func NewRabbitMQEventDispatcher(logger *zerolog.Logger) (*RabbitMQEventDispatche
 	return dispatcher, nil
 }
 
-// DispatchEvent dispatches events to the message queue
+// DispatchEvent dispatches events to the message queue, if the queue is full the event is spooled to disk and if the spool is full too, it waits for the queue
 func (eventDispatcher *RabbitMQEventDispatcher) DispatchEvent(token string, corelationID string, topic string, payload interface{}) {
-	eventDispatcher.sendChannel <- &queueCommand{token: token, topic: topic, payload: payload}
+	command := newQueueCommand(&Message{Token: token, CorrelationID: corelationID, Topic: topic, Payload: payload})
+	if err := eventDispatcher.tryDispatch(command); err != nil {
+		eventDispatcher.sendChannel <- command
+	}
+}
+
+// DispatchEventWithContext dispatches events to the message queue, trace of the context is propagated in the event headers
+func (eventDispatcher *RabbitMQEventDispatcher) DispatchEventWithContext(ctx context.Context, token string, corelationID string, topic string, payload interface{}) {
+	command := newQueueCommand(&Message{Token: token, CorrelationID: corelationID, Topic: topic, Payload: payload, Context: ctx})
+	if err := eventDispatcher.tryDispatch(command); err != nil {
+		eventDispatcher.sendChannel <- command
+	}
+}
+
+// TryDispatchEvent dispatches events to the message queue without blocking, returns ErrSpoolFull if the event can neither be queued nor spooled
+func (eventDispatcher *RabbitMQEventDispatcher) TryDispatchEvent(token string, corelationID string, topic string, payload interface{}) error {
+	return eventDispatcher.tryDispatch(newQueueCommand(&Message{Token: token, CorrelationID: corelationID, Topic: topic, Payload: payload}))
+}
+
+// IsConnected returns true if the dispatcher is connected to RabbitMQ
+func (eventDispatcher *RabbitMQEventDispatcher) IsConnected() bool {
+	eventDispatcher.connectionMutex.Lock()
+	defer eventDispatcher.connectionMutex.Unlock()
+
+	return eventDispatcher.connection != nil && !eventDispatcher.connection.IsClosed()
+}
+
+// WaitUntilReady blocks till the dispatcher is connected to RabbitMQ for the first time or the context is done
+func (eventDispatcher *RabbitMQEventDispatcher) WaitUntilReady(ctx context.Context) error {
+	select {
+	case <-eventDispatcher.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close waits till the queued events are published and confirmed or the context is done, and closes the connection.
+// Events which could not be published before the context is done are spooled if the spool is enabled, else they are dropped and only their count is logged.
+// Events replayed from the spool are kept in their spool file to be replayed on next start.
+func (eventDispatcher *RabbitMQEventDispatcher) Close(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	var err error
+drain:
+	for !eventDispatcher.drained() {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break drain
+		case <-ticker.C:
+		}
+	}
+
+	eventDispatcher.closeOnce.Do(func() {
+		close(eventDispatcher.closed)
+	})
+
+	if eventDispatcher.spool != nil {
+		for {
+			select {
+			case command := <-eventDispatcher.sendChannel:
+				eventDispatcher.spoolOnClose(command)
+				continue
+			case retry := <-eventDispatcher.retryChannel:
+				eventDispatcher.spoolOnClose(retry.command)
+				continue
+			default:
+			}
+			break
+		}
+	}
+	if pending := len(eventDispatcher.sendChannel) + len(eventDispatcher.retryChannel); pending > 0 {
+		eventDispatcher.logger.Warn().Int("pending", pending).Msg("Closing dispatcher with events not published.")
+	}
+
+	eventDispatcher.connectionMutex.Lock()
+	defer eventDispatcher.connectionMutex.Unlock()
+	if eventDispatcher.connection != nil {
+		eventDispatcher.connection.Close()
+	}
+	return err
+}
+
+// spoolOnClose spools the event which is not published, the event replayed from the spool is only released as it is still in its spool file
+func (eventDispatcher *RabbitMQEventDispatcher) spoolOnClose(command *queueCommand) {
+	if command.spoolFile != "" {
+		eventDispatcher.spool.release(command.spoolFile)
+		return
+	}
+	eventDispatcher.spoolCommand(command)
+}
+
+// drained returns true if there are no events waiting to be published or confirmed
+func (eventDispatcher *RabbitMQEventDispatcher) drained() bool {
+	if len(eventDispatcher.sendChannel) > 0 || len(eventDispatcher.retryChannel) > 0 {
+		return false
+	}
+	tracker := eventDispatcher.currentTracker()
+	if tracker == nil {
+		return true
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	return len(tracker.outstanding) == 0
+}
+
+// SpooledCount returns the number of events waiting in the spool
+func (eventDispatcher *RabbitMQEventDispatcher) SpooledCount() int {
+	if eventDispatcher.spool == nil {
+		return 0
+	}
+	return eventDispatcher.spool.size()
+}
+
+func (eventDispatcher *RabbitMQEventDispatcher) tryDispatch(command *queueCommand) error {
+	select {
+	case eventDispatcher.sendChannel <- command:
+		return nil
+	default:
+	}
+
+	if eventDispatcher.spool == nil {
+		return ErrSpoolFull
+	}
+	if err := eventDispatcher.spoolCommand(command); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (eventDispatcher *RabbitMQEventDispatcher) start() {
@@ -74,11 +294,9 @@ func (eventDispatcher *RabbitMQEventDispatcher) start() {
 		var command *queueCommand
 		var retryCount int
 
-		// Ensure that connection process is not going on
-		eventDispatcher.connectionMutex.Lock()
-		eventDispatcher.connectionMutex.Unlock()
-
 		select {
+		case <-eventDispatcher.closed:
+			return
 		case commandFromSendChannel := <-eventDispatcher.sendChannel:
 			command = commandFromSendChannel
 		case commandFromRetryChannel := <-eventDispatcher.retryChannel:
@@ -86,78 +304,298 @@ func (eventDispatcher *RabbitMQEventDispatcher) start() {
 			retryCount = commandFromRetryChannel.retryCount
 		}
 
-		routingKey := strings.ReplaceAll(command.topic, "_", ".")
-		var body []byte
-		var err error
+		routingKey, publishing, err := toPublishing(command, eventDispatcher.config.CloudEvents)
+		if err != nil {
+			eventDispatcher.logger.Error().Msg("Failed to convert payload to JSON" + ": " + err.Error())
+			//TODO: Can we log this message
+			if command.spoolFile != "" {
+				eventDispatcher.spool.remove(command.spoolFile)
+			}
+			continue
+		}
 
-		body, isByteMessage := command.payload.([]byte)
-		if !isByteMessage {
-			body, err = json.Marshal(command.payload)
-			if err != nil {
-				eventDispatcher.logger.Error().Msg("Failed to convert payload to JSON" + ": " + err.Error())
-				//TODO: Can we log this message
+		if err = eventDispatcher.publish(routingKey, publishing, &pendingConfirm{command: command, retryCount: retryCount}); err != nil {
+			eventDispatcher.handlePublishFailure(command, retryCount, err)
+		} else {
+			eventDispatcher.logger.Trace().Msg("Sent message to queue")
+		}
+	}
+}
+
+// Publish publishes the given message to the exchange and returns once the broker confirms it
+func (eventDispatcher *RabbitMQEventDispatcher) Publish(message *Message) error {
+	routingKey, publishing, err := toPublishing(newQueueCommand(message), eventDispatcher.config.CloudEvents)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	if err := eventDispatcher.publish(routingKey, publishing, &pendingConfirm{done: done}); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(eventDispatcher.config.ConfirmTimeout):
+		return errPublishConfirmTimedOut
+	}
+}
+
+// publish publishes to the exchange and tracks the confirmation
+func (eventDispatcher *RabbitMQEventDispatcher) publish(routingKey string, publishing amqp.Publishing, pending *pendingConfirm) error {
+	tracker := eventDispatcher.currentTracker()
+	if tracker == nil {
+		return errPublishChannelNotActive
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if tracker.outstanding == nil {
+		return errPublishChannelNotActive
+	}
+	if err := tracker.channel.Publish(eventDispatcher.exchangeName, routingKey, false, false, publishing); err != nil {
+		return err
+	}
+	tracker.deliveryTag++
+	tracker.outstanding[tracker.deliveryTag] = pending
+	return nil
+}
+
+// currentTracker returns the confirm tracker of the current channel, it is swapped under the connection mutex on reconnect
+func (eventDispatcher *RabbitMQEventDispatcher) currentTracker() *confirmTracker {
+	eventDispatcher.connectionMutex.Lock()
+	defer eventDispatcher.connectionMutex.Unlock()
+	return eventDispatcher.tracker
+}
+
+// monitorConfirms resolves the outstanding events of a channel as and when the broker confirms them
+func (eventDispatcher *RabbitMQEventDispatcher) monitorConfirms(tracker *confirmTracker, confirms chan amqp.Confirmation) {
+	for confirm := range confirms {
+		tracker.mutex.Lock()
+		pending, ok := tracker.outstanding[confirm.DeliveryTag]
+		delete(tracker.outstanding, confirm.DeliveryTag)
+		tracker.mutex.Unlock()
+
+		if ok {
+			if confirm.Ack {
+				eventDispatcher.handlePublishSuccess(pending)
+			} else {
+				eventDispatcher.handlePendingFailure(pending, errPublishNacked)
 			}
 		}
+	}
 
-		if err == nil {
-			err = eventDispatcher.channel.Publish(
-				eventDispatcher.exchangeName,
-				routingKey,
-				false,
-				false,
-				amqp.Publishing{
-					ContentType: "application/json",
-					Body:        body,
-					Headers:     map[string]interface{}{"X-Authorization": command.token, "X-Correlation-ID": command.corelationID},
-				})
+	// Channel is closed, events which are not confirmed yet are considered as failed
+	tracker.mutex.Lock()
+	outstanding := tracker.outstanding
+	tracker.outstanding = nil
+	tracker.mutex.Unlock()
 
-			if err != nil {
-				if retryCount < 3 {
-					eventDispatcher.logger.Warn().Msg("Publish to queue failed. Trying again ... Error: " + err.Error())
+	for _, pending := range outstanding {
+		eventDispatcher.handlePendingFailure(pending, amqp.ErrClosed)
+	}
+}
 
-					go func(command *queueCommand, retryCount int) {
-						time.Sleep(time.Second)
-						eventDispatcher.retryChannel <- &retryCommand{retryCount: retryCount, command: command}
-					}(command, retryCount+1)
-				} else {
-					eventDispatcher.logger.Error().Msg("Failed to publish to an Exchange" + ": " + err.Error())
-					//TODO: Can we log this message
+func (eventDispatcher *RabbitMQEventDispatcher) handlePublishSuccess(pending *pendingConfirm) {
+	if pending.done != nil {
+		pending.done <- nil
+		return
+	}
+	if pending.command.spoolFile != "" {
+		if err := eventDispatcher.spool.remove(pending.command.spoolFile); err != nil {
+			eventDispatcher.logger.Warn().Err(err).Msg("Failed to remove the confirmed event from spool.")
+		}
+	}
+}
+
+func (eventDispatcher *RabbitMQEventDispatcher) handlePendingFailure(pending *pendingConfirm, err error) {
+	if pending.done != nil {
+		pending.done <- err
+		return
+	}
+	eventDispatcher.handlePublishFailure(pending.command, pending.retryCount, err)
+}
+
+// handlePublishFailure spools the failed event to be replayed later, if spool is not enabled it retries for 3 times
+func (eventDispatcher *RabbitMQEventDispatcher) handlePublishFailure(command *queueCommand, retryCount int, err error) {
+	if eventDispatcher.spool != nil {
+		if command.spoolFile != "" {
+			eventDispatcher.spool.release(command.spoolFile)
+			return
+		}
+		if spoolErr := eventDispatcher.spoolCommand(command); spoolErr == nil {
+			eventDispatcher.logger.Warn().Msg("Publish to queue failed, event is spooled. Error: " + err.Error())
+			return
+		}
+	}
+
+	if retryCount < 3 {
+		eventDispatcher.logger.Warn().Msg("Publish to queue failed. Trying again ... Error: " + err.Error())
+
+		go func(command *queueCommand, retryCount int) {
+			time.Sleep(time.Second)
+			eventDispatcher.retryChannel <- &retryCommand{retryCount: retryCount, command: command}
+		}(command, retryCount+1)
+	} else {
+		eventDispatcher.logger.Error().Msg("Failed to publish to an Exchange" + ": " + err.Error())
+		//TODO: Can we log this message
+	}
+}
+
+// spoolCommand encodes and persists the event to the spool
+func (eventDispatcher *RabbitMQEventDispatcher) spoolCommand(command *queueCommand) error {
+	if _, isByteMessage := command.payload.([]byte); !isByteMessage {
+		body, err := json.Marshal(command.payload)
+		if err != nil {
+			return err
+		}
+		command.payload = body
+	}
+
+	if _, err := eventDispatcher.spool.write(command); err != nil {
+		if err == ErrSpoolFull {
+			eventDispatcher.logger.Warn().Msg("Event spool is full.")
+		} else {
+			eventDispatcher.logger.Error().Err(err).Msg("Failed to spool the event.")
+		}
+		return err
+	}
+	return nil
+}
+
+// replaySpool queues the spooled events on reconnect and periodically
+func (eventDispatcher *RabbitMQEventDispatcher) replaySpool() {
+	ticker := time.NewTicker(eventDispatcher.config.SpoolReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-eventDispatcher.closed:
+			return
+		case <-ticker.C:
+		case <-eventDispatcher.replayChannel:
+		}
+
+		if eventDispatcher.currentTracker() == nil {
+			continue
+		}
+
+		names, err := eventDispatcher.spool.list()
+		if err != nil {
+			eventDispatcher.logger.Error().Err(err).Msg("Failed to read the event spool.")
+			continue
+		}
+		for _, name := range names {
+			command, err := eventDispatcher.spool.checkout(name)
+			if err != nil {
+				eventDispatcher.logger.Error().Err(err).Str("spoolFile", name).Msg("Failed to read the spooled event.")
+				continue
+			}
+			if command != nil {
+				select {
+				case eventDispatcher.sendChannel <- command:
+				case <-eventDispatcher.closed:
+					eventDispatcher.spool.release(name)
+					return
 				}
-			} else {
-				eventDispatcher.logger.Trace().Msg("Sent message to queue")
 			}
 		}
 	}
 }
 
+// toPublishing encodes the command, the payload is wrapped in CloudEvents envelope if its settings are given
+func toPublishing(command *queueCommand, cloudEventsConfig *CloudEventsConfig) (string, amqp.Publishing, error) {
+	body, isByteMessage := command.payload.([]byte)
+	if !isByteMessage {
+		var err error
+		if body, err = json.Marshal(command.payload); err != nil {
+			return "", amqp.Publishing{}, err
+		}
+	}
+
+	routingKey := strings.ReplaceAll(command.topic, "_", ".")
+	publishing := amqp.Publishing{
+		ContentType: "application/json",
+		MessageId:   command.messageID,
+		Body:        body,
+		Headers:     map[string]interface{}{"X-Authorization": command.token, "X-Correlation-ID": command.corelationID},
+	}
+	for name, value := range command.traceHeaders {
+		publishing.Headers[name] = value
+	}
+	if cloudEventsConfig != nil {
+		if err := wrapInCloudEvent(cloudEventsConfig, command, routingKey, &publishing); err != nil {
+			return "", amqp.Publishing{}, err
+		}
+	}
+	return routingKey, publishing, nil
+}
+
 func (eventDispatcher *RabbitMQEventDispatcher) rabbitConnector() {
 	var rabbitErr *amqp.Error
 
 	for {
-		rabbitErr = <-eventDispatcher.connectionCloseChannel
+		var ok bool
+		select {
+		case <-eventDispatcher.closed:
+			return
+		case rabbitErr, ok = <-eventDispatcher.connectionCloseChannel:
+			if !ok {
+				return // Connection is closed by the dispatcher
+			}
+		}
 		if rabbitErr != nil {
+			// Dial without holding the mutex, so that IsConnected and Close are not blocked while the broker is down
+			connection, channel, connected := connectToRabbitMQ(eventDispatcher.logger, *eventDispatcher.config.Connection, eventDispatcher.exchangeName, eventDispatcher.closed)
+			if !connected {
+				return
+			}
+
 			eventDispatcher.connectionMutex.Lock()
+			select {
+			case <-eventDispatcher.closed:
+				eventDispatcher.connectionMutex.Unlock()
+				connection.Close()
+				return
+			default:
+			}
 
-			connectionString, isTLS, connectionStringForLog := getQueueConnectionString()
-			connection, channel := connectToRabbitMQ(eventDispatcher.logger, connectionString, isTLS, eventDispatcher.exchangeName, connectionStringForLog)
+			tracker := &confirmTracker{channel: channel, outstanding: make(map[uint64]*pendingConfirm)}
+			go eventDispatcher.monitorConfirms(tracker, channel.NotifyPublish(make(chan amqp.Confirmation, 200)))
 
 			eventDispatcher.connection = connection
 			eventDispatcher.channel = channel
+			eventDispatcher.tracker = tracker
 			eventDispatcher.connectionCloseChannel = make(chan *amqp.Error)
 
 			eventDispatcher.connection.NotifyClose(eventDispatcher.connectionCloseChannel)
 
 			eventDispatcher.connectionMutex.Unlock()
+			eventDispatcher.readyOnce.Do(func() {
+				close(eventDispatcher.ready)
+			})
+
+			// Replay the events spooled while the connection was down
+			if eventDispatcher.spool != nil {
+				select {
+				case eventDispatcher.replayChannel <- struct{}{}:
+				default:
+				}
+			}
 		}
 	}
 }
 
-func connectToRabbitMQ(logger *zerolog.Logger, connectionString string, isTLS bool, exchangeName string, connectionStringForLog string) (*amqp.Connection, *amqp.Channel) {
+// connectToRabbitMQ dials till the connection and the confirm mode channel are established, returns false if closed is closed in between
+func connectToRabbitMQ(logger *zerolog.Logger, connectionConfig AMQPConnectionConfig, exchangeName string, closed <-chan struct{}) (*amqp.Connection, *amqp.Channel, bool) {
+	_, connectionStringForLog := connectionConfig.ConnectionString()
 	logger.Debug().Msg("Connecting to queue " + connectionStringForLog)
 	for {
 
-		conn, err := dialAMQP(connectionString, isTLS)
-		logger.Info().Msg(fmt.Sprintf("Connection String and TLS valus is %v     %v", connectionStringForLog, isTLS))
+		conn, err := DialAMQP(connectionConfig)
+		logger.Info().Msg(fmt.Sprintf("Connection String and TLS valus is %v     %v", connectionStringForLog, connectionConfig.TLS))
 
 		if err == nil {
 			logger.Info().Msg("RabittMQ connected")
@@ -169,71 +607,20 @@ func connectToRabbitMQ(logger *zerolog.Logger, connectionString string, isTLS bo
 				err = ch.ExchangeDeclare(exchangeName, "topic", true, false, false, false, nil)
 				if err != nil {
 					logger.Warn().Msg("Failed to declare an exchange" + ": " + err.Error())
+				} else if err = ch.Confirm(false); err != nil {
+					logger.Warn().Msg("Failed to put channel in confirm mode" + ": " + err.Error())
 				} else {
-					return conn, ch
+					return conn, ch, true
 				}
 			}
 			conn.Close()
 		}
 
 		logger.Warn().Msgf("Cannot connect to RabbitMQ, Error [%v]. Trying again...", err)
-		time.Sleep(5 * time.Second)
-	}
-}
-
-func dialAMQP(connectionString string, isTLS bool) (*amqp.Connection, error) {
-	var cfg *tls.Config = nil
-	if isTLS {
-		caCert, _ := os.LookupEnv("ISLA_QUEUE_RMQ_CA_CERT")
-		clientCert, _ := os.LookupEnv("ISLA_QUEUE_RMQ_CERT")
-		clientCertKey, _ := os.LookupEnv("ISLA_QUEUE_RMQ_CERT_KEY")
-
-		if strings.TrimSpace(caCert) == "" || strings.TrimSpace(clientCert) == "" || strings.TrimSpace(clientCertKey) == "" {
-			return nil, fmt.Errorf("One or more client certificates not found")
-		}
-
-		cfg = &tls.Config{}
-		cfg.RootCAs = x509.NewCertPool()
-		cfg.RootCAs.AppendCertsFromPEM([]byte(caCert))
-
-		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientCertKey))
-		if err != nil {
-			return nil, err
-		}
-		cfg.Certificates = append(cfg.Certificates, cert)
-	}
-
-	return amqp.DialTLS(connectionString, cfg)
-}
-
-//TODO: read from config
-func getQueueConnectionString() (string, bool, string) {
-	var queueHost, queuePort, queueUser, queuePassword string
-	isTLS := false
-	queueProtocol := "amqp"
-	queueHost, ok := os.LookupEnv("ISLA_QUEUE_HOST")
-	if !ok {
-		queueHost = "localhost"
-	}
-	queuePassword, ok = os.LookupEnv("ISLA_QUEUE_PWD")
-	if !ok {
-		queuePassword = "guest"
-	}
-	queueUser, ok = os.LookupEnv("ISLA_QUEUE_USER")
-	if !ok {
-		queueUser = "guest"
-	}
-	queuePort, ok = os.LookupEnv("ISLA_QUEUE_PORT")
-	if !ok {
-		queuePort = "5672"
-	}
-	tls, ok := os.LookupEnv("ISLA_QUEUE_TLS_ENABLED")
-	if ok {
-		isTLS, _ = strconv.ParseBool(tls)
-		if isTLS {
-			queueProtocol = "amqps"
+		select {
+		case <-closed:
+			return nil, nil, false
+		case <-time.After(5 * time.Second):
 		}
 	}
-
-	return fmt.Sprintf("%v://%v:%v@%v:%v/", queueProtocol, queueUser, queuePassword, queueHost, queuePort), isTLS, fmt.Sprintf("%v://%v:%v@%v:%v/", queueProtocol, "######", "######", queueHost, queuePort)
 }
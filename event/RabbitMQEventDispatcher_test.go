@@ -0,0 +1,63 @@
+package event
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRabbitMQEventDispatcherCloseSpoolsPendingEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newSpool(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := make([]string, 0)
+	for _, messageID := range []string{"1", "2"} {
+		name, err := s.write(&queueCommand{messageID: messageID, topic: "tenant_added", payload: []byte(`{}`)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		replayed = append(replayed, name)
+	}
+
+	logger := zerolog.Nop()
+	dispatcher := &RabbitMQEventDispatcher{logger: &logger, spool: s, sendChannel: make(chan *queueCommand, 10), retryChannel: make(chan *retryCommand, 10), closed: make(chan struct{})}
+	for _, name := range replayed {
+		command, err := s.checkout(name)
+		if err != nil || command == nil {
+			t.Fatalf("Unable to checkout spooled event: %v", err)
+		}
+		dispatcher.sendChannel <- command
+	}
+	dispatcher.sendChannel <- newQueueCommand(&Message{Topic: "tenant_added", Payload: map[string]string{"id": "3"}})
+	dispatcher.retryChannel <- &retryCommand{retryCount: 1, command: newQueueCommand(&Message{Topic: "tenant_added", Payload: map[string]string{"id": "4"}})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := dispatcher.Close(ctx); err != context.Canceled {
+		t.Errorf("Expected [%v], Got [%v]", context.Canceled, err)
+	}
+
+	names, err := s.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 4 || names[0] != replayed[0] || names[1] != replayed[1] {
+		t.Errorf("Expected replayed events to stay in their spool files and the others to be spooled, Got %v", names)
+	}
+	if s.size() != 4 {
+		t.Errorf("Expected spool size [4], Got [%v]", s.size())
+	}
+	if command, _ := s.checkout(replayed[0]); command == nil {
+		t.Error("Expected replayed event to be released")
+	}
+}
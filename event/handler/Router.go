@@ -0,0 +1,200 @@
+package handler
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/islax/microapp/config"
+	microappCtx "github.com/islax/microapp/context"
+	"github.com/islax/microapp/event/idempotency"
+	"github.com/islax/microapp/event/monitor"
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/security"
+	"github.com/islax/microapp/tracing"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Validator is implemented by the event payloads which validate themselves after they are decoded
+type Validator interface {
+	Validate() error
+}
+
+// ExecutionContextFactory creates the execution context in which the event is handled, ctx carries the trace of the consumer span
+type ExecutionContextFactory func(ctx gocontext.Context, token *security.JwtToken, correlationID string, action string) microappCtx.ExecutionContext
+
+var (
+	executionContextType = reflect.TypeOf((*microappCtx.ExecutionContext)(nil)).Elem()
+	resultType           = reflect.TypeOf(monitor.Result{})
+)
+
+type route struct {
+	action      string
+	payloadType reflect.Type
+	handlerFunc reflect.Value
+}
+
+// Router decodes the received events into the payload types registered for their routing keys and invokes the handlers
+type Router struct {
+	config     *config.Config
+	logger     zerolog.Logger
+	newContext ExecutionContextFactory
+	mutex      sync.RWMutex
+	routes     map[string]*route
+
+	idempotencyStore idempotency.Store
+}
+
+// NewRouter creates a new event router, tokens of the events are validated using the JWT settings in the config
+func NewRouter(config *config.Config, logger zerolog.Logger, newContext ExecutionContextFactory) *Router {
+	return &Router{config: config, logger: logger.With().Str("module", "EventRouter").Logger(), newContext: newContext, routes: make(map[string]*route)}
+}
+
+// Handle registers the handler for the routing key, the handler should be of the form
+//
+//	func(context microappCtx.ExecutionContext, payload *Payload) monitor.Result
+//
+// where Payload is the struct into which the event payload is decoded. Action is used as action name of the execution context.
+func (router *Router) Handle(routingKey string, action string, handlerFunc interface{}) error {
+	handlerValue := reflect.ValueOf(handlerFunc)
+	handlerType := handlerValue.Type()
+	if handlerType.Kind() != reflect.Func || handlerType.NumIn() != 2 || handlerType.NumOut() != 1 ||
+		handlerType.In(0) != executionContextType || handlerType.In(1).Kind() != reflect.Ptr || handlerType.Out(0) != resultType {
+		return fmt.Errorf("handler of %v should be func(microappCtx.ExecutionContext, *Payload) monitor.Result, got %v", routingKey, handlerType)
+	}
+
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	routingKey = normalizeRoutingKey(routingKey)
+	if _, ok := router.routes[routingKey]; ok {
+		return fmt.Errorf("handler of %v is already registered", routingKey)
+	}
+	router.routes[routingKey] = &route{action: action, payloadType: handlerType.In(1).Elem(), handlerFunc: handlerValue}
+	return nil
+}
+
+// UseIdempotencyStore makes the router skip the events already processed by the handler, events are identified by their message id.
+// Action of the route identifies the consumer, so the same event is processed once by each route it is routed to.
+func (router *Router) UseIdempotencyStore(store idempotency.Store) {
+	router.idempotencyStore = store
+}
+
+// RoutingKeys returns the routing keys which have handlers, to be monitored by the event monitor
+func (router *Router) RoutingKeys() []string {
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+	routingKeys := make([]string, 0, len(router.routes))
+	for routingKey := range router.routes {
+		routingKeys = append(routingKeys, routingKey)
+	}
+	sort.Strings(routingKeys)
+	return routingKeys
+}
+
+// Start routes the events received on the channel till it is closed
+func (router *Router) Start(eventSignal chan *monitor.EventInfo) {
+	for eventInfo := range eventSignal {
+		eventInfo.Complete(router.Route(eventInfo))
+	}
+}
+
+// Route decodes the event and invokes its handler, events without a handler are acknowledged.
+// Events which cannot be decoded, fail validation or carry an invalid token are rejected, a panic in the handler is reported as a failure to be retried.
+func (router *Router) Route(eventInfo *monitor.EventInfo) (result monitor.Result) {
+	logger := router.logger.With().Str("event", eventInfo.Name).Str("correlationId", eventInfo.CorelationID).Logger()
+
+	router.mutex.RLock()
+	route, ok := router.routes[normalizeRoutingKey(eventInfo.Name)]
+	router.mutex.RUnlock()
+	if !ok {
+		logger.Debug().Msg("No handler registered for the event.")
+		return monitor.Ack()
+	}
+
+	parentContext := eventInfo.Context
+	if parentContext == nil {
+		parentContext = gocontext.Background()
+	}
+	ctx, span := tracing.StartConsumerSpan(parentContext, eventInfo.Name, eventInfo.ID)
+	defer func() {
+		if result.Action != monitor.ActionAck {
+			description := result.Action.String()
+			if result.Err != nil {
+				span.RecordError(result.Err)
+				description = result.Err.Error()
+			}
+			span.SetStatus(codes.Error, description)
+		}
+		span.End()
+	}()
+	// Recovers from a panic in validation, context creation, claim or the handler, registered first so that it runs after the other deferred functions
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.Error().Str("eventType", log.EventTypeUnexpectedErr).Str("eventCode", log.EventCodeUnknown).Str("stack", string(debug.Stack())).Msgf("Recovered from panic in event handler: %v", recovered)
+			result = monitor.Requeue(fmt.Errorf("event handler panicked: %v", recovered), 0)
+		}
+	}()
+
+	payload := reflect.New(route.payloadType)
+	if err := json.Unmarshal([]byte(eventInfo.Payload), payload.Interface()); err != nil {
+		logger.Error().Err(err).Str("eventType", log.EventTypeValidationErr).Str("eventCode", log.EventCodeInvalidData).Str("payload", eventInfo.Payload).Msg("Unable to decode the event.")
+		return monitor.Nack(err)
+	}
+	if validator, ok := payload.Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			logger.Error().Err(err).Str("eventType", log.EventTypeValidationErr).Str("eventCode", log.EventCodeInvalidData).Str("payload", eventInfo.Payload).Msg("Invalid event.")
+			return monitor.Nack(err)
+		}
+	}
+
+	var token *security.JwtToken
+	if eventInfo.RawToken != "" {
+		var err error
+		if token, err = security.GetTokenFromRawAuthHeader(router.config, eventInfo.RawToken); err != nil {
+			logger.Error().Err(err).Str("eventType", log.EventTypeAuthenticationErr).Msg("Invalid token in the event.")
+			return monitor.Nack(err)
+		}
+	}
+
+	context := router.newContext(ctx, token, eventInfo.CorelationID, route.action)
+	if uow := context.GetUOW(); uow != nil {
+		defer uow.Complete() // Rolls back if the handler did not commit
+	}
+
+	handled := false
+	if router.idempotencyStore != nil && eventInfo.ID != "" {
+		status, err := router.idempotencyStore.Claim(context, route.action, eventInfo.ID)
+		if err != nil {
+			context.LogError(err, "Unable to claim the event for processing.")
+			return monitor.Requeue(err, 0)
+		}
+		switch status {
+		case idempotency.StatusProcessed:
+			context.GetDefaultLogger().Debug().Str("messageId", eventInfo.ID).Msg("Skipping the event already processed.")
+			return monitor.Ack()
+		case idempotency.StatusInProgress:
+			return monitor.Requeue(errors.New("event is being processed by another consumer"), 0)
+		}
+		defer func() {
+			// The event is not marked processed if the handler panicked, result is set by the recovery only after this runs
+			if err := router.idempotencyStore.Complete(context, route.action, eventInfo.ID, handled && result.Action == monitor.ActionAck); err != nil {
+				context.LogError(err, "Unable to mark the event processed.")
+			}
+		}()
+	}
+
+	result = route.handlerFunc.Call([]reflect.Value{reflect.ValueOf(context), payload})[0].Interface().(monitor.Result)
+	handled = true
+	return result
+}
+
+func normalizeRoutingKey(routingKey string) string {
+	return strings.ReplaceAll(routingKey, "_", ".")
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	microappCtx "github.com/islax/microapp/context"
+	"github.com/islax/microapp/event/monitor"
+	"github.com/islax/microapp/security"
+	"github.com/rs/zerolog"
+)
+
+type testPayload struct {
+	Name string `json:"name"`
+}
+
+func (payload *testPayload) Validate() error {
+	if payload.Name == "" {
+		return errors.New("name is required")
+	}
+	if payload.Name == "validation-panic" {
+		panic("test")
+	}
+	return nil
+}
+
+func TestRoute(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+	router := NewRouter(nil, logger, func(ctx context.Context, token *security.JwtToken, correlationID string, action string) microappCtx.ExecutionContext {
+		return microappCtx.NewExecutionContext(token, correlationID, action, logger)
+	})
+
+	var received *testPayload
+	if err := router.Handle("test_added", "test.add", func(context microappCtx.ExecutionContext, payload *testPayload) monitor.Result {
+		if payload.Name == "panic" {
+			panic("test")
+		}
+		received = payload
+		return monitor.Ack()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Handle("test.invalid", "test.invalid", func(payload *testPayload) {}); err == nil {
+		t.Error("Expected error registering handler with invalid signature")
+	}
+
+	tests := []struct {
+		name     string
+		event    string
+		payload  string
+		expected monitor.Action
+	}{
+		{"Valid", "test.added", `{"name":"a"}`, monitor.ActionAck},
+		{"Malformed", "test.added", `{"name":`, monitor.ActionNack},
+		{"Invalid", "test.added", `{}`, monitor.ActionNack},
+		{"Panic", "test.added", `{"name":"panic"}`, monitor.ActionRequeue},
+		{"ValidationPanic", "test.added", `{"name":"validation-panic"}`, monitor.ActionRequeue},
+		{"Unrouted", "test.deleted", `{}`, monitor.ActionAck},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := router.Route(&monitor.EventInfo{Name: test.event, Payload: test.payload})
+			if result.Action != test.expected {
+				t.Errorf("Expected %v, Actual %v (%v)", test.expected, result.Action, result.Err)
+			}
+		})
+	}
+	if received == nil || received.Name != "a" {
+		t.Errorf("Expected payload to be decoded, Actual %v", received)
+	}
+}
@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+	"time"
+
+	microappCtx "github.com/islax/microapp/context"
+	microappError "github.com/islax/microapp/error"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProcessedEvent represents an event processed by a consumer
+type ProcessedEvent struct {
+	Consumer    string    `gorm:"column:consumer;type:varchar(100);primary_key"`
+	MessageID   string    `gorm:"column:messageId;type:varchar(64);primary_key"`
+	ProcessedOn time.Time `gorm:"column:processedOn;index"`
+}
+
+// TableName returns the name of processed events table
+func (ProcessedEvent) TableName() string {
+	return "processedEvent"
+}
+
+// DBStore records the processed events in the database as part of the unit of work in which the event is processed,
+// so the event is recorded processed only if the side effects of processing it are committed
+type DBStore struct {
+	db *gorm.DB
+}
+
+// NewDBStore creates a store which records the processed events in the database
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db.Session(&gorm.Session{NewDB: true})}
+}
+
+// Migrate creates / updates the processed events table
+func (store *DBStore) Migrate() error {
+	return store.db.AutoMigrate(&ProcessedEvent{})
+}
+
+// Claim inserts the processed event record in the unit of work of the context. If another instance is processing the event,
+// the insert waits for its transaction to complete.
+func (store *DBStore) Claim(context microappCtx.ExecutionContext, consumer string, messageID string) (Status, error) {
+	db := store.db
+	if uow := context.GetUOW(); uow != nil {
+		db = uow.DB
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&ProcessedEvent{Consumer: consumer, MessageID: messageID, ProcessedOn: time.Now()})
+	if result.Error != nil {
+		return StatusClaimed, microappError.NewDatabaseError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return StatusProcessed, nil
+	}
+	return StatusClaimed, nil
+}
+
+// Complete is a no-op as the claim is committed or rolled back along with the unit of work
+func (store *DBStore) Complete(context microappCtx.ExecutionContext, consumer string, messageID string, processed bool) error {
+	return nil
+}
+
+// Purge removes the records of the events processed before the given time
+func (store *DBStore) Purge(before time.Time) (int64, error) {
+	result := store.db.Where(clause.Lt{Column: clause.Column{Name: "processedOn"}, Value: before}).Delete(&ProcessedEvent{})
+	if result.Error != nil {
+		return 0, microappError.NewDatabaseError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,55 @@
+package idempotency
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	microappCtx "github.com/islax/microapp/context"
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/repository"
+	"github.com/rs/zerolog"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDBStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(os.Stdout)
+	store := NewDBStore(db)
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	claim := func(commit bool) Status {
+		context := microappCtx.NewExecutionContext(nil, "", "test", logger)
+		uow := repository.NewUnitOfWork(db, false, logger, log.Config{})
+		defer uow.Complete()
+		context.SetUOW(uow)
+		status, err := store.Claim(context, "test.consumer", "message-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if commit {
+			uow.Commit()
+		}
+		return status
+	}
+
+	if status := claim(false); status != StatusClaimed {
+		t.Errorf("Expected event to be claimed, Actual %v", status)
+	}
+	if status := claim(true); status != StatusClaimed {
+		t.Errorf("Expected event to be claimed again after rollback, Actual %v", status)
+	}
+	if status := claim(true); status != StatusProcessed {
+		t.Errorf("Expected event to be processed, Actual %v", status)
+	}
+
+	if purged, err := store.Purge(time.Now().Add(time.Minute)); err != nil || purged != 1 {
+		t.Errorf("Expected 1 record to be purged, Actual %v (%v)", purged, err)
+	}
+}
@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	microappCtx "github.com/islax/microapp/context"
+)
+
+const (
+	processingValue = "processing"
+	processedValue  = "processed"
+
+	// claimAttempts is the number of times the claim is added again if the record expires or is released before it is read
+	claimAttempts = 3
+)
+
+// MemcachedStore records the processed events in memcached, the records expire after the retention.
+// Unlike DBStore the record is not part of the unit of work, if the consumer crashes after committing the side effects
+// and before completing the event, the event is processed again once the processing timeout elapses.
+type MemcachedStore struct {
+	client            *memcache.Client
+	retention         time.Duration
+	processingTimeout time.Duration
+}
+
+// NewMemcachedStore creates a store which records the processed events in memcached for the retention,
+// an event claimed for processing is reclaimable after the processing timeout
+func NewMemcachedStore(client *memcache.Client, retention time.Duration, processingTimeout time.Duration) *MemcachedStore {
+	if processingTimeout <= 0 {
+		processingTimeout = 5 * time.Minute
+	}
+	return &MemcachedStore{client: client, retention: retention, processingTimeout: processingTimeout}
+}
+
+// Claim adds the processing record of the event, which fails if the event is already processed or being processed.
+// The event is reported in progress if the record keeps disappearing between the add and the get, so that it is retried later.
+func (store *MemcachedStore) Claim(context microappCtx.ExecutionContext, consumer string, messageID string) (Status, error) {
+	key := store.key(consumer, messageID)
+	for attempt := 0; attempt < claimAttempts; attempt++ {
+		err := store.client.Add(&memcache.Item{Key: key, Value: []byte(processingValue), Expiration: expiration(store.processingTimeout)})
+		if err == nil {
+			return StatusClaimed, nil
+		}
+		if err != memcache.ErrNotStored {
+			return StatusClaimed, err
+		}
+
+		item, err := store.client.Get(key)
+		if err == memcache.ErrCacheMiss {
+			continue // Claim expired or released in the meantime
+		}
+		if err != nil {
+			return StatusClaimed, err
+		}
+		if string(item.Value) == processedValue {
+			return StatusProcessed, nil
+		}
+		return StatusInProgress, nil
+	}
+	return StatusInProgress, nil
+}
+
+// Complete marks the event processed for the retention, or deletes the claim if the event is not processed
+func (store *MemcachedStore) Complete(context microappCtx.ExecutionContext, consumer string, messageID string, processed bool) error {
+	key := store.key(consumer, messageID)
+	if !processed {
+		if err := store.client.Delete(key); err != nil && err != memcache.ErrCacheMiss {
+			return err
+		}
+		return nil
+	}
+	return store.client.Set(&memcache.Item{Key: key, Value: []byte(processedValue), Expiration: expiration(store.retention)})
+}
+
+// Purge returns 0 as the records expire by themselves
+func (store *MemcachedStore) Purge(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (store *MemcachedStore) key(consumer string, messageID string) string {
+	return fmt.Sprintf("processedevent:%v:%v", strings.ReplaceAll(consumer, " ", "_"), messageID)
+}
+
+// expiration converts the duration to memcached expiration, durations over 30 days are converted to unix time as required by memcached
+func expiration(duration time.Duration) int32 {
+	if duration > 30*24*time.Hour {
+		return int32(time.Now().Add(duration).Unix())
+	}
+	return int32(duration / time.Second)
+}
@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Purger periodically removes the records of the events processed before the retention window
+type Purger struct {
+	store     Store
+	retention time.Duration
+	interval  time.Duration
+	logger    zerolog.Logger
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewPurger creates a purger which removes the records older than retention at every interval
+func NewPurger(store Store, retention time.Duration, interval time.Duration, logger zerolog.Logger) *Purger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Purger{store: store, retention: retention, interval: interval, logger: logger.With().Str("module", "IdempotencyPurger").Logger(), stop: make(chan struct{})}
+}
+
+// Start starts purging in background
+func (purger *Purger) Start() {
+	go func() {
+		ticker := time.NewTicker(purger.interval)
+		defer ticker.Stop()
+		for {
+			purger.purge()
+			select {
+			case <-purger.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops purging
+func (purger *Purger) Stop() {
+	purger.stopOnce.Do(func() {
+		close(purger.stop)
+	})
+}
+
+func (purger *Purger) purge() {
+	if purger.retention <= 0 {
+		return
+	}
+	purged, err := purger.store.Purge(time.Now().Add(-purger.retention))
+	if err != nil {
+		purger.logger.Error().Err(err).Msg("Unable to purge processed events.")
+		return
+	}
+	if purged > 0 {
+		purger.logger.Debug().Int64("purged", purged).Msg("Purged processed events.")
+	}
+}
@@ -0,0 +1,29 @@
+package idempotency
+
+import (
+	"time"
+
+	microappCtx "github.com/islax/microapp/context"
+)
+
+// Status represents whether the event can be processed by the consumer
+type Status int
+
+const (
+	// StatusClaimed means the event is not processed yet and it is claimed by the caller for processing
+	StatusClaimed Status = iota
+	// StatusProcessed means the event is already processed by the consumer
+	StatusProcessed
+	// StatusInProgress means the event is being processed by another instance of the consumer
+	StatusInProgress
+)
+
+// Store records the events processed by the consumers so that the side effects of redelivered events are not applied again
+type Store interface {
+	// Claim claims the event for processing by the consumer, the claim is made as part of the unit of work of the context if the store is transactional
+	Claim(context microappCtx.ExecutionContext, consumer string, messageID string) (Status, error)
+	// Complete marks the claimed event processed, or releases the claim if the event is not processed so that its redelivery is processed
+	Complete(context microappCtx.ExecutionContext, consumer string, messageID string, processed bool) error
+	// Purge removes the records of the events processed before the given time, the stores which expire the records by themselves return 0
+	Purge(before time.Time) (int64, error)
+}
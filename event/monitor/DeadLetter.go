@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/islax/microapp/event"
+	"github.com/rs/zerolog"
+)
+
+// RedriveDeadLetters moves the events from the dead-letter queue of the named queue back to the queue, at most limit events are moved if limit is greater than 0.
+// The events dead-lettered while redriving, including the redriven events failing again, are not moved as the redrive is bounded by the depth of the queue at start.
+// Failure metadata is removed from the events so that they get all the redeliveries permitted by the retry policy again.
+func RedriveDeadLetters(logger *zerolog.Logger, connectionConfig event.AMQPConnectionConfig, queueName string, limit int) (int, error) {
+	if queueName == "" {
+		return 0, fmt.Errorf("queue name is required to redrive dead-letter queue")
+	}
+
+	queueConnection, err := event.DialAMQP(connectionConfig)
+	if err != nil {
+		return 0, err
+	}
+	defer queueConnection.Close()
+
+	queueChannel, err := queueConnection.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer queueChannel.Close()
+	if err := declareDeadLetterQueue(queueChannel, queueName); err != nil {
+		return 0, err
+	}
+	deadLetterQueue, err := queueChannel.QueueInspect(queueName + deadLetterQueueSuffix)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 || limit > deadLetterQueue.Messages {
+		limit = deadLetterQueue.Messages
+	}
+	publishChannel, err := newConfirmingChannel(queueChannel)
+	if err != nil {
+		return 0, err
+	}
+
+	redriven := 0
+	for redriven < limit {
+		delivery, ok, err := publishChannel.Get(queueName+deadLetterQueueSuffix, false)
+		if err != nil {
+			return redriven, err
+		}
+		if !ok {
+			break
+		}
+
+		publishing := republishing(&delivery)
+		for _, header := range []string{HeaderRedeliveries, HeaderOriginalQueue, HeaderFailureAction, HeaderFailureReason, HeaderFailedOn} {
+			delete(publishing.Headers, header)
+		}
+		if err := publishChannel.publishAndConfirm("", queueName, publishing); err != nil {
+			delivery.Nack(false, true)
+			return redriven, err
+		}
+		if err := delivery.Ack(false); err != nil {
+			return redriven, err
+		}
+		redriven++
+		logger.Info().Str("event", publishing.Headers[HeaderOriginalRoutingKey].(string)).Str("messageId", delivery.MessageId).Msgf("Redriven event to queue %v", queueName)
+	}
+	return redriven, nil
+}
@@ -1,9 +1,118 @@
 package monitor
 
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/tracing"
+)
+
+const (
+	// HeaderRedeliveries header carrying number of times the event is redelivered after failure
+	HeaderRedeliveries = "x-redeliveries"
+	// HeaderOriginalRoutingKey header carrying routing key of the event when it is redelivered or dead-lettered
+	HeaderOriginalRoutingKey = "x-original-routing-key"
+	// HeaderOriginalQueue header carrying the queue from which the event is dead-lettered
+	HeaderOriginalQueue = "x-original-queue"
+	// HeaderFailureReason header carrying the error due to which the event is dead-lettered
+	HeaderFailureReason = "x-failure-reason"
+	// HeaderFailureAction header carrying the action (nack or requeue) due to which the event is dead-lettered
+	HeaderFailureAction = "x-failure-action"
+	// HeaderFailedOn header carrying the time (RFC3339) at which the event is dead-lettered
+	HeaderFailedOn = "x-failed-on"
+)
+
 // EventInfo represents the message received from queu
 type EventInfo struct {
 	RawToken     string
 	CorelationID string
 	Name         string
 	Payload      string
+	Redeliveries int // Number of times the event is redelivered after failure
+
+	// Attributes of the CloudEvents envelope, only ID is set for the events published without envelope
+	ID            string
+	Source        string
+	Time          time.Time
+	TenantID      string
+	SchemaVersion string
+
+	Context context.Context // Carries the trace context propagated in the headers of the event
+
+	complete     func(result Result)
+	completeOnce sync.Once
+}
+
+// newEventInfo creates EventInfo from the received message, payload is unwrapped if the message is a CloudEvent
+func newEventInfo(routingKey string, messageID string, contentType string, headers map[string]interface{}, body []byte) *EventInfo {
+	token, _ := headers["X-Authorization"].(string)
+	corelationID, _ := headers["X-Correlation-ID"].(string)
+	if originalRoutingKey, ok := headers[HeaderOriginalRoutingKey].(string); ok && originalRoutingKey != "" {
+		routingKey = originalRoutingKey
+	}
+
+	eventInfo := &EventInfo{
+		ID:           messageID,
+		CorelationID: corelationID,
+		Payload:      string(body),
+		RawToken:     token,
+		Redeliveries: headerInt(headers, HeaderRedeliveries),
+		Context:      tracing.ExtractHeaders(context.Background(), headers),
+
+		Name: routingKey,
+	}
+
+	// Payload is retained as is if the envelope can not be parsed, so that the handler rejects it
+	if cloudEvent, err := event.ParseCloudEvent(contentType, body); err == nil && cloudEvent != nil {
+		eventInfo.ID = cloudEvent.ID
+		eventInfo.Source = cloudEvent.Source
+		eventInfo.Time = cloudEvent.Time
+		eventInfo.TenantID = cloudEvent.TenantID
+		eventInfo.SchemaVersion = cloudEvent.SchemaVersion
+		eventInfo.Payload = string(cloudEvent.Payload())
+		if eventInfo.CorelationID == "" {
+			eventInfo.CorelationID = cloudEvent.CorrelationID
+		}
+	}
+	return eventInfo
+}
+
+// Complete reports the result of handling the event to the monitor, only the first result is considered.
+// It has no effect if the monitor acknowledges the events on receipt.
+func (eventInfo *EventInfo) Complete(result Result) {
+	eventInfo.completeOnce.Do(func() {
+		if eventInfo.complete != nil {
+			eventInfo.complete(result)
+		}
+	})
+}
+
+// Ack acknowledges the event
+func (eventInfo *EventInfo) Ack() {
+	eventInfo.Complete(Ack())
+}
+
+// Nack moves the event to the dead-letter queue
+func (eventInfo *EventInfo) Nack(err error) {
+	eventInfo.Complete(Nack(err))
+}
+
+// Requeue redelivers the event after the given delay, 0 uses the delay of the retry policy
+func (eventInfo *EventInfo) Requeue(err error, delay time.Duration) {
+	eventInfo.Complete(Requeue(err, delay))
+}
+
+func headerInt(headers map[string]interface{}, name string) int {
+	switch value := headers[name].(type) {
+	case int:
+		return value
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	default:
+		return 0
+	}
 }
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"github.com/islax/microapp/event"
 	"github.com/rs/zerolog"
 )
 
@@ -14,7 +15,7 @@ type EventMonitor interface {
 // NewEventMonitor creates a new eventMonitor that publishes received events to the specified channel
 func NewEventMonitor(logger *zerolog.Logger, eventsToMonitor []string, eventSignal chan *EventInfo) (EventMonitor, error) {
 	ctxLogger := logger.With().Str("module", "RabbitMQEventMonitor").Logger()
-	monitor := &rabbitMQEventMonitor{logger: &ctxLogger, eventSignal: eventSignal}
+	monitor := &rabbitMQEventMonitor{logger: &ctxLogger, connectionConfig: event.AMQPConnectionConfigFromEnv(), eventSignal: eventSignal}
 
 	err := monitor.initialize(eventsToMonitor)
 	if err != nil {
@@ -27,7 +28,44 @@ func NewEventMonitor(logger *zerolog.Logger, eventsToMonitor []string, eventSign
 // NewEventMonitorForQueue creates a new eventMonitor that publishes received events from a named queue to the specified channel
 func NewEventMonitorForQueue(logger *zerolog.Logger, queueName string, eventsToMonitor []string, eventSignal chan *EventInfo) (EventMonitor, error) {
 	ctxLogger := logger.With().Str("module", "RabbitMQEventMonitor").Logger()
-	monitor := &rabbitMQEventMonitor{logger: &ctxLogger, queueName: queueName, eventSignal: eventSignal}
+	monitor := &rabbitMQEventMonitor{logger: &ctxLogger, connectionConfig: event.AMQPConnectionConfigFromEnv(), queueName: queueName, eventSignal: eventSignal}
+
+	err := monitor.initialize(eventsToMonitor)
+	if err != nil {
+		return nil, err
+	}
+
+	return monitor, nil
+}
+
+// NewRabbitMQEventMonitor creates a new eventMonitor that connects to RabbitMQ with given settings and publishes received events from a named queue to the specified channel
+func NewRabbitMQEventMonitor(logger *zerolog.Logger, connectionConfig event.AMQPConnectionConfig, queueName string, eventsToMonitor []string, eventSignal chan *EventInfo) (EventMonitor, error) {
+	return NewRabbitMQEventMonitorWithRetryPolicy(logger, connectionConfig, queueName, eventsToMonitor, eventSignal, DefaultRetryPolicy())
+}
+
+// NewRabbitMQEventMonitorWithRetryPolicy creates a new eventMonitor that connects to RabbitMQ with given settings and retries the failed events as per the given policy.
+// With manual acknowledgement the receiver of the event should report the result of handling it using EventInfo.Complete.
+func NewRabbitMQEventMonitorWithRetryPolicy(logger *zerolog.Logger, connectionConfig event.AMQPConnectionConfig, queueName string, eventsToMonitor []string, eventSignal chan *EventInfo, retryPolicy RetryPolicy) (EventMonitor, error) {
+	ctxLogger := logger.With().Str("module", "RabbitMQEventMonitor").Logger()
+	monitor := &rabbitMQEventMonitor{logger: &ctxLogger, connectionConfig: connectionConfig, queueName: queueName, eventSignal: eventSignal, retryPolicy: retryPolicy}
+
+	err := monitor.initialize(eventsToMonitor)
+	if err != nil {
+		return nil, err
+	}
+
+	return monitor, nil
+}
+
+// NewMemoryEventMonitor creates a new eventMonitor that publishes events received on the in-process bus to the specified channel
+func NewMemoryEventMonitor(logger *zerolog.Logger, bus *event.MemoryBus, queueName string, eventsToMonitor []string, eventSignal chan *EventInfo) (EventMonitor, error) {
+	return NewMemoryEventMonitorWithRetryPolicy(logger, bus, queueName, eventsToMonitor, eventSignal, DefaultRetryPolicy())
+}
+
+// NewMemoryEventMonitorWithRetryPolicy creates a new eventMonitor on the in-process bus that retries the failed events as per the given policy
+func NewMemoryEventMonitorWithRetryPolicy(logger *zerolog.Logger, bus *event.MemoryBus, queueName string, eventsToMonitor []string, eventSignal chan *EventInfo, retryPolicy RetryPolicy) (EventMonitor, error) {
+	ctxLogger := logger.With().Str("module", "MemoryEventMonitor").Logger()
+	monitor := &memoryEventMonitor{logger: &ctxLogger, bus: bus, queueName: queueName, eventSignal: eventSignal, retryPolicy: retryPolicy}
 
 	err := monitor.initialize(eventsToMonitor)
 	if err != nil {
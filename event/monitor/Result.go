@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"time"
+)
+
+// Action represents what is done with the received event once it is handled
+type Action int
+
+const (
+	// ActionAck acknowledges the event, it is removed from the queue
+	ActionAck Action = iota
+	// ActionNack rejects the event, it is moved to the dead-letter queue without further retries
+	ActionNack
+	// ActionRequeue redelivers the event after a delay, till the redeliveries permitted by the retry policy are exhausted
+	ActionRequeue
+)
+
+func (action Action) String() string {
+	switch action {
+	case ActionAck:
+		return "ack"
+	case ActionNack:
+		return "nack"
+	case ActionRequeue:
+		return "requeue"
+	default:
+		return "unknown"
+	}
+}
+
+// Result represents the outcome of handling an event
+type Result struct {
+	Action Action
+	Delay  time.Duration // Delay before the event is redelivered, 0 uses the delay of the retry policy
+	Err    error         // Error due to which the event is rejected or requeued
+}
+
+// Ack returns the result which acknowledges the event
+func Ack() Result {
+	return Result{Action: ActionAck}
+}
+
+// Nack returns the result which moves the event to the dead-letter queue
+func Nack(err error) Result {
+	return Result{Action: ActionNack, Err: err}
+}
+
+// Requeue returns the result which redelivers the event after the given delay, 0 uses the delay of the retry policy
+func Requeue(err error, delay time.Duration) Result {
+	return Result{Action: ActionRequeue, Err: err, Delay: delay}
+}
+
+// RetryPolicy represents how the failed events are retried by the event monitor
+type RetryPolicy struct {
+	ManualAck       bool          // Events are acknowledged only when the handler completes them, otherwise they are acknowledged on receipt
+	MaxRedeliveries int           // Number of redeliveries after which the event is moved to the dead-letter queue
+	InitialDelay    time.Duration // Delay before the first redelivery, doubled for every subsequent redelivery
+	MaxDelay        time.Duration // Maximum delay before redelivery
+	PrefetchCount   int           // Number of unacknowledged events delivered to the monitor, 0 is unlimited
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is specified, events are acknowledged on receipt
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRedeliveries: 5, InitialDelay: 5 * time.Second, MaxDelay: 5 * time.Minute}
+}
+
+// delay returns the delay before the redelivery of an event which is already redelivered the given number of times
+func (policy RetryPolicy) delay(redeliveries int) time.Duration {
+	delay := policy.InitialDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 0; i < redeliveries && (policy.MaxDelay <= 0 || delay < policy.MaxDelay); i++ {
+		delay *= 2
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/islax/microapp/event"
+	"github.com/rs/zerolog"
+)
+
+type memoryEventMonitor struct {
+	logger          *zerolog.Logger
+	bus             *event.MemoryBus
+	queueName       string
+	eventSignal     chan *EventInfo
+	eventsToMonitor []string
+	retryPolicy     RetryPolicy
+
+	mutex       sync.Mutex
+	unsubscribe func()
+	stop        chan struct{}
+}
+
+func (monitor *memoryEventMonitor) initialize(eventsToMonitor []string) error {
+	monitor.eventsToMonitor = eventsToMonitor
+	return nil
+}
+
+func (monitor *memoryEventMonitor) Start() {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+
+	if monitor.unsubscribe != nil {
+		return
+	}
+	messages, unsubscribe := monitor.bus.Subscribe(monitor.queueName, monitor.eventsToMonitor)
+	monitor.unsubscribe = unsubscribe
+	monitor.stop = make(chan struct{})
+
+	go monitor.monitorQueueAndProcessMessages(messages, monitor.stop)
+	monitor.logger.Debug().Msg("Listening to in-process bus")
+}
+
+func (monitor *memoryEventMonitor) Stop() {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+
+	if monitor.unsubscribe == nil {
+		return
+	}
+	monitor.unsubscribe()
+	close(monitor.stop)
+	monitor.unsubscribe = nil
+}
+
+func (monitor *memoryEventMonitor) monitorQueueAndProcessMessages(messages <-chan *event.MemoryMessage, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case message := <-messages:
+			monitor.deliver(message, stop)
+		}
+	}
+}
+
+func (monitor *memoryEventMonitor) deliver(message *event.MemoryMessage, stop chan struct{}) {
+	eventInfo := newEventInfo(message.RoutingKey, message.MessageID, message.ContentType, message.Headers, message.Body)
+	if monitor.retryPolicy.ManualAck {
+		eventInfo.complete = func(result Result) {
+			monitor.complete(message, eventInfo.Redeliveries, result, stop)
+		}
+	}
+	monitor.eventSignal <- eventInfo
+}
+
+// complete redelivers the requeued events to the same monitor after the delay, the bus does not have a dead-letter queue so the rejected events are logged and dropped
+func (monitor *memoryEventMonitor) complete(message *event.MemoryMessage, redeliveries int, result Result, stop chan struct{}) {
+	switch result.Action {
+	case ActionAck:
+		return
+	case ActionRequeue:
+		if redeliveries < monitor.retryPolicy.MaxRedeliveries {
+			delay := result.Delay
+			if delay <= 0 {
+				delay = monitor.retryPolicy.delay(redeliveries)
+			}
+			headers := make(map[string]interface{}, len(message.Headers)+1)
+			for key, value := range message.Headers {
+				headers[key] = value
+			}
+			headers[HeaderRedeliveries] = redeliveries + 1
+			redelivery := &event.MemoryMessage{RoutingKey: message.RoutingKey, MessageID: message.MessageID, ContentType: message.ContentType, Headers: headers, Body: message.Body}
+			time.AfterFunc(delay, func() {
+				select {
+				case <-stop:
+				default:
+					monitor.deliver(redelivery, stop)
+				}
+			})
+			return
+		}
+	}
+	monitor.logger.Error().Err(result.Err).Str("event", message.RoutingKey).Str("action", result.Action.String()).Int("redeliveries", redeliveries).Str("payload", string(message.Body)).Msg("Dropping the failed event.")
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/event"
+	"github.com/rs/zerolog"
+)
+
+func TestMemoryEventMonitorRequeue(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+	bus := event.NewMemoryBus()
+	eventSignal := make(chan *EventInfo, 10)
+	monitor, err := NewMemoryEventMonitorWithRetryPolicy(&logger, bus, "test", []string{"tenant_added"}, eventSignal, RetryPolicy{ManualAck: true, MaxRedeliveries: 2, InitialDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitor.Start()
+	defer monitor.Stop()
+
+	event.NewMemoryEventDispatcher(bus).DispatchEvent("token", "correlation", "tenant_added", map[string]string{"id": "1"})
+
+	for expected := 0; expected <= 2; expected++ {
+		select {
+		case eventInfo := <-eventSignal:
+			if eventInfo.Name != "tenant.added" || eventInfo.Redeliveries != expected {
+				t.Fatalf("Expected tenant.added with %v redeliveries, Actual %v with %v", expected, eventInfo.Name, eventInfo.Redeliveries)
+			}
+			eventInfo.Requeue(errors.New("failed"), 0)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected redelivery %v", expected)
+		}
+	}
+
+	select {
+	case eventInfo := <-eventSignal:
+		t.Fatalf("Expected event to be dropped after exhausting redeliveries, Actual redelivery %v", eventInfo.Redeliveries)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+type versionedPayload struct {
+	ID string `json:"id"`
+}
+
+func (versionedPayload) SchemaVersion() string {
+	return "2.0"
+}
+
+func TestMemoryEventMonitorCloudEvents(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+	bus := event.NewMemoryBus()
+	eventSignal := make(chan *EventInfo, 1)
+	monitor, err := NewMemoryEventMonitor(&logger, bus, "", []string{"tenant_added"}, eventSignal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitor.Start()
+	defer monitor.Stop()
+
+	dispatcher := event.NewMemoryEventDispatcherWithCloudEvents(bus, &event.CloudEventsConfig{Source: "Test App", DefaultSchemaVersion: "1.0"})
+	dispatcher.DispatchEvent("", "correlation", "tenant_added", versionedPayload{ID: "1"})
+
+	select {
+	case eventInfo := <-eventSignal:
+		if eventInfo.ID == "" || eventInfo.Source != "Test App" || eventInfo.SchemaVersion != "2.0" || eventInfo.CorelationID != "correlation" || eventInfo.Time.IsZero() {
+			t.Errorf("Expected CloudEvent attributes to be parsed, Actual %+v", eventInfo)
+		}
+		if eventInfo.Payload != `{"id":"1"}` {
+			t.Errorf("Expected payload {\"id\":\"1\"}, Actual %v", eventInfo.Payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected event to be received")
+	}
+}
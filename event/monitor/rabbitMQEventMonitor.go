@@ -1,28 +1,43 @@
 package monitor
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/islax/microapp/event"
 	"github.com/rs/zerolog"
 	"github.com/streadway/amqp"
 )
 
+const (
+	deadLetterExchange      = "isla_dlx"
+	deadLetterQueueSuffix   = ".dlq"
+	retryQueuePrefix        = "isla_retry."
+	republishConfirmTimeout = 30 * time.Second
+)
+
 type rabbitMQEventMonitor struct {
-	logger          *zerolog.Logger
-	queueName       string
-	eventSignal     chan *EventInfo
-	eventsToMonitor []string
+	logger           *zerolog.Logger
+	connectionConfig event.AMQPConnectionConfig
+	queueName        string
+	eventSignal      chan *EventInfo
+	eventsToMonitor  []string
+	retryPolicy      RetryPolicy
 
+	// connectionMutex guards the connection which is replaced by rabbitConnector on reconnect
+	connectionMutex sync.Mutex
 	messageChanel   <-chan amqp.Delivery
 	queueConnection *amqp.Connection
 	queueChannel    *amqp.Channel
 
+	// publishChannel is used in confirm mode to move the failed events to retry and dead-letter queues
+	publishMutex        sync.Mutex
+	publishChannel      *confirmingChannel
+	declaredRetryQueues map[string]bool
+
 	connectionCloseChannel chan *amqp.Error
 }
 
@@ -38,26 +53,30 @@ func (monitor *rabbitMQEventMonitor) rabbitConnector() {
 	var rabbitErr *amqp.Error
 
 	for {
-		rabbitErr = <-monitor.connectionCloseChannel
+		var ok bool
+		if rabbitErr, ok = <-monitor.connectionCloseChannel; !ok {
+			return // Connection is closed by the monitor
+		}
 		if rabbitErr != nil {
-			connectionString, isTLS := getQueueConnectionString()
-			connection, queueChannel, messageChanel := monitor.connectToRabbitMQ(monitor.queueName, connectionString, isTLS, monitor.eventsToMonitor)
+			connection, queueChannel, messageChanel, queueName := monitor.connectToRabbitMQ(monitor.queueName, monitor.eventsToMonitor)
 
+			monitor.connectionMutex.Lock()
 			monitor.queueConnection = connection
 			monitor.queueChannel = queueChannel
 			monitor.messageChanel = messageChanel
+			monitor.connectionMutex.Unlock()
 
 			monitor.connectionCloseChannel = make(chan *amqp.Error)
-			monitor.queueConnection.NotifyClose(monitor.connectionCloseChannel)
+			connection.NotifyClose(monitor.connectionCloseChannel)
 
-			go monitor.monitorQueueAndProcessMessages()
+			go monitor.monitorQueueAndProcessMessages(messageChanel, queueName)
 		}
 	}
 }
 
-func (monitor *rabbitMQEventMonitor) connectToRabbitMQ(queueName string, connectionString string, isTLS bool, eventsToMonitor []string) (*amqp.Connection, *amqp.Channel, <-chan amqp.Delivery) {
+func (monitor *rabbitMQEventMonitor) connectToRabbitMQ(queueName string, eventsToMonitor []string) (*amqp.Connection, *amqp.Channel, <-chan amqp.Delivery, string) {
 	for {
-		queueConnection, err := dialAMQP(connectionString, isTLS)
+		queueConnection, err := event.DialAMQP(monitor.connectionConfig)
 
 		if err != nil {
 			monitor.logger.Error().Err(err).Msg("Unable to connect to rabbitMQ.")
@@ -102,117 +121,293 @@ func (monitor *rabbitMQEventMonitor) connectToRabbitMQ(queueName string, connect
 							}
 						}
 
-						messageChanel, err := queueChannel.Consume(
-							q.Name, // queue
-							"",     // consumer
-							true,   // auto ack
-							false,  // exclusive
-							false,  // no local
-							false,  // no wait
-							nil,    // args
-						)
-						if err != nil {
-							monitor.logger.Error().Err(err).Msg("Failed to register a consumer.")
+						if err = monitor.setupManualAck(queueConnection, queueChannel); err != nil {
+							monitor.logger.Error().Err(err).Msg("Failed to setup retry and dead-letter queues.")
 						} else {
-							return queueConnection, queueChannel, messageChanel
+							messageChanel, err := queueChannel.Consume(
+								q.Name,                         // queue
+								"",                             // consumer
+								!monitor.retryPolicy.ManualAck, // auto ack
+								false,                          // exclusive
+								false,                          // no local
+								false,                          // no wait
+								nil,                            // args
+							)
+							if err != nil {
+								monitor.logger.Error().Err(err).Msg("Failed to register a consumer.")
+							} else {
+								return queueConnection, queueChannel, messageChanel, q.Name
+							}
 						}
 					}
 				}
 			}
+			queueConnection.Close()
 		}
 		monitor.logger.Warn().Msgf("Cannot connect to RabbitMQ. Trying again ... Error %s", err.Error())
 		time.Sleep(5 * time.Second)
 	}
 }
 
-func (monitor *rabbitMQEventMonitor) monitorQueueAndProcessMessages() {
-	for message := range monitor.messageChanel {
-		payload := string(message.Body)
-		token := ""
-		corelationID := ""
-		authorizationHeader, ok := message.Headers["X-Authorization"]
-		if ok {
-			token = authorizationHeader.(string)
+// setupManualAck declares the dead-letter exchange and queue, and opens the channel to republish the failed events
+func (monitor *rabbitMQEventMonitor) setupManualAck(queueConnection *amqp.Connection, queueChannel *amqp.Channel) error {
+	if !monitor.retryPolicy.ManualAck {
+		return nil
+	}
+
+	if monitor.retryPolicy.PrefetchCount > 0 {
+		if err := queueChannel.Qos(monitor.retryPolicy.PrefetchCount, 0, false); err != nil {
+			return err
 		}
-		corelationIDHeader, ok := message.Headers["X-Correlation-ID"]
-		if ok {
-			corelationID = corelationIDHeader.(string)
+	}
+
+	if err := declareDeadLetterQueue(queueChannel, monitor.queueName); err != nil {
+		return err
+	}
+
+	channel, err := queueConnection.Channel()
+	if err != nil {
+		return err
+	}
+	publishChannel, err := newConfirmingChannel(channel)
+	if err != nil {
+		channel.Close()
+		return err
+	}
+
+	monitor.publishMutex.Lock()
+	monitor.publishChannel = publishChannel
+	monitor.declaredRetryQueues = make(map[string]bool)
+	monitor.publishMutex.Unlock()
+	return nil
+}
+
+func (monitor *rabbitMQEventMonitor) monitorQueueAndProcessMessages(messageChanel <-chan amqp.Delivery, queueName string) {
+	for message := range messageChanel {
+		eventInfo := newEventInfo(message.RoutingKey, message.MessageId, message.ContentType, message.Headers, message.Body)
+		if monitor.retryPolicy.ManualAck {
+			delivery := message
+			redeliveries := eventInfo.Redeliveries
+			// Quorum queues count the redeliveries of the events not acknowledged e.g. when the consumer crashes while handling the event
+			if deliveryCount := headerInt(message.Headers, "x-delivery-count"); deliveryCount > redeliveries {
+				redeliveries = deliveryCount
+			}
+			eventInfo.complete = func(result Result) {
+				monitor.complete(&delivery, queueName, redeliveries, result)
+			}
 		}
+		monitor.eventSignal <- eventInfo
+	}
+}
 
-		command := &EventInfo{
-			CorelationID: corelationID,
-			Payload:      payload,
-			RawToken:     token,
+// complete acknowledges the delivery, or moves it to the retry or dead-letter queue based on the result of the handler
+func (monitor *rabbitMQEventMonitor) complete(delivery *amqp.Delivery, queueName string, redeliveries int, result Result) {
+	logger := monitor.logger.With().Str("event", delivery.RoutingKey).Str("messageId", delivery.MessageId).Str("action", result.Action.String()).Int("redeliveries", redeliveries).Logger()
 
-			Name: message.RoutingKey,
+	switch result.Action {
+	case ActionAck:
+		if err := delivery.Ack(false); err != nil {
+			logger.Error().Err(err).Msg("Unable to acknowledge the event.")
 		}
+		return
+	case ActionRequeue:
+		if redeliveries < monitor.retryPolicy.MaxRedeliveries {
+			delay := result.Delay
+			if delay <= 0 {
+				delay = monitor.retryPolicy.delay(redeliveries)
+			}
+			logger.Warn().Err(result.Err).Dur("delay", delay).Msg("Requeuing the event.")
+			if err := monitor.republishAfterDelay(delivery, queueName, redeliveries+1, delay); err != nil {
+				logger.Error().Err(err).Msg("Unable to requeue the event.")
+				delivery.Nack(false, true)
+				return
+			}
+			delivery.Ack(false)
+			return
+		}
+	}
 
-		monitor.eventSignal <- command
+	logger.Error().Err(result.Err).Msg("Moving the event to dead-letter queue.")
+	if err := monitor.deadLetter(delivery, queueName, redeliveries, result); err != nil {
+		logger.Error().Err(err).Msg("Unable to move the event to dead-letter queue.")
+		delivery.Nack(false, true)
+		return
 	}
+	delivery.Ack(false)
 }
 
-func (monitor *rabbitMQEventMonitor) Start() {
-	monitor.connectionCloseChannel <- amqp.ErrClosed // Trigger the connection
+// republishAfterDelay publishes the event to a retry queue, from where it is dead-lettered back to the queue once the delay expires
+func (monitor *rabbitMQEventMonitor) republishAfterDelay(delivery *amqp.Delivery, queueName string, redeliveries int, delay time.Duration) error {
+	// Delay is rounded to seconds so that the events share the retry queues, each queue holds events of the same delay as messages expire only at the head of the queue
+	delayInSeconds := int64((delay + time.Second - 1) / time.Second)
+	retryQueueName := fmt.Sprintf("%v%v.%ds", retryQueuePrefix, queueName, delayInSeconds)
+
+	publishing := republishing(delivery)
+	publishing.Headers[HeaderRedeliveries] = int32(redeliveries)
+
+	monitor.publishMutex.Lock()
+	defer monitor.publishMutex.Unlock()
+
+	if monitor.publishChannel == nil {
+		return errors.New("publish channel is not open")
+	}
+	if !monitor.declaredRetryQueues[retryQueueName] {
+		_, err := monitor.publishChannel.QueueDeclare(
+			retryQueueName, // name
+			true,           // durable
+			false,          // delete when unused
+			false,          // exclusive
+			false,          // no-wait
+			amqp.Table{
+				"x-message-ttl":             delayInSeconds * 1000,
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+				"x-expires":                 delayInSeconds*2000 + 60000, // Unused retry queues are removed
+			},
+		)
+		if err != nil {
+			return err
+		}
+		monitor.declaredRetryQueues[retryQueueName] = true
+	}
+	return monitor.publish("", retryQueueName, publishing)
 }
 
-func (monitor *rabbitMQEventMonitor) Stop() {
-	monitor.queueChannel.Close()
-	monitor.queueConnection.Close()
+// deadLetter publishes the event with failure metadata to the dead-letter exchange
+func (monitor *rabbitMQEventMonitor) deadLetter(delivery *amqp.Delivery, queueName string, redeliveries int, result Result) error {
+	if monitor.queueName == "" {
+		// Dead-letter queue is not declared for the server named queues as they do not outlive the monitor
+		monitor.logger.Error().Str("event", delivery.RoutingKey).Str("payload", string(delivery.Body)).Msg("Dropping the failed event of unnamed queue.")
+		return nil
+	}
+
+	publishing := republishing(delivery)
+	publishing.Headers[HeaderRedeliveries] = int32(redeliveries)
+	publishing.Headers[HeaderOriginalQueue] = queueName
+	publishing.Headers[HeaderFailureAction] = result.Action.String()
+	publishing.Headers[HeaderFailedOn] = time.Now().UTC().Format(time.RFC3339)
+	if result.Err != nil {
+		publishing.Headers[HeaderFailureReason] = result.Err.Error()
+	}
+
+	monitor.publishMutex.Lock()
+	defer monitor.publishMutex.Unlock()
+
+	if monitor.publishChannel == nil {
+		return errors.New("publish channel is not open")
+	}
+	return monitor.publish(deadLetterExchange, queueName, publishing)
 }
 
-func dialAMQP(connectionString string, isTLS bool) (*amqp.Connection, error) {
-	var cfg *tls.Config = nil
-	if isTLS {
-		caCert, _ := os.LookupEnv("ISLA_QUEUE_RMQ_CA_CERT")
-		clientCert, _ := os.LookupEnv("ISLA_QUEUE_RMQ_CERT")
-		clientCertKey, _ := os.LookupEnv("ISLA_QUEUE_RMQ_CERT_KEY")
+// publish publishes and waits for the confirmation, caller should hold publishMutex
+func (monitor *rabbitMQEventMonitor) publish(exchange string, routingKey string, publishing amqp.Publishing) error {
+	return monitor.publishChannel.publishAndConfirm(exchange, routingKey, publishing)
+}
 
-		if strings.TrimSpace(caCert) == "" || strings.TrimSpace(clientCert) == "" || strings.TrimSpace(clientCertKey) == "" {
-			return nil, fmt.Errorf("One or more client certificates not found")
-		}
+func (monitor *rabbitMQEventMonitor) Start() {
+	monitor.connectionCloseChannel <- amqp.ErrClosed // Trigger the connection
+}
 
-		cfg = &tls.Config{}
-		cfg.RootCAs = x509.NewCertPool()
-		cfg.RootCAs.AppendCertsFromPEM([]byte(caCert))
+// IsConnected returns true if the monitor is connected to RabbitMQ
+func (monitor *rabbitMQEventMonitor) IsConnected() bool {
+	monitor.connectionMutex.Lock()
+	connection := monitor.queueConnection
+	monitor.connectionMutex.Unlock()
+	return connection != nil && !connection.IsClosed()
+}
 
-		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientCertKey))
-		if err != nil {
-			return nil, err
-		}
-		cfg.Certificates = append(cfg.Certificates, cert)
+func (monitor *rabbitMQEventMonitor) Stop() {
+	monitor.publishMutex.Lock()
+	if monitor.publishChannel != nil {
+		monitor.publishChannel.Close()
+		monitor.publishChannel = nil
 	}
+	monitor.publishMutex.Unlock()
 
-	return amqp.DialTLS(connectionString, cfg)
+	monitor.connectionMutex.Lock()
+	queueChannel, queueConnection := monitor.queueChannel, monitor.queueConnection
+	monitor.connectionMutex.Unlock()
+	if queueChannel != nil {
+		queueChannel.Close()
+	}
+	if queueConnection != nil {
+		queueConnection.Close()
+	}
 }
 
-//TODO: read from config
-func getQueueConnectionString() (string, bool) {
-	var queueHost, queuePort, queueUser, queuePassword string
-	isTLS := false
-	queueProtocol := "amqp"
-	queueHost, ok := os.LookupEnv("ISLA_QUEUE_HOST")
-	if !ok {
-		queueHost = "localhost"
+// declareDeadLetterQueue declares the dead-letter exchange and the dead-letter queue of the named queue
+func declareDeadLetterQueue(queueChannel *amqp.Channel, queueName string) error {
+	if err := queueChannel.ExchangeDeclare(deadLetterExchange, "direct", true, false, false, false, nil); err != nil {
+		return err
 	}
-	queuePassword, ok = os.LookupEnv("ISLA_QUEUE_PWD")
-	if !ok {
-		queuePassword = "guest"
+	if queueName == "" {
+		return nil
 	}
-	queueUser, ok = os.LookupEnv("ISLA_QUEUE_USER")
-	if !ok {
-		queueUser = "guest"
+	if _, err := queueChannel.QueueDeclare(queueName+deadLetterQueueSuffix, true, false, false, false, nil); err != nil {
+		return err
 	}
-	queuePort, ok = os.LookupEnv("ISLA_QUEUE_PORT")
-	if !ok {
-		queuePort = "5672"
+	return queueChannel.QueueBind(queueName+deadLetterQueueSuffix, queueName, deadLetterExchange, false, nil)
+}
+
+// republishing copies the delivery to be published again, routing key of the delivery is retained in the headers
+func republishing(delivery *amqp.Delivery) amqp.Publishing {
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		headers[key] = value
 	}
-	tls, ok := os.LookupEnv("ISLA_QUEUE_TLS_ENABLED")
-	if ok {
-		isTLS, _ = strconv.ParseBool(tls)
-		if isTLS {
-			queueProtocol = "amqps"
-		}
+	if _, ok := headers[HeaderOriginalRoutingKey]; !ok {
+		headers[HeaderOriginalRoutingKey] = delivery.RoutingKey
+	}
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   delivery.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: delivery.CorrelationId,
+		MessageId:     delivery.MessageId,
+		Timestamp:     delivery.Timestamp,
+		Type:          delivery.Type,
+		Body:          delivery.Body,
 	}
+}
+
+// confirmingChannel is a channel in confirm mode whose publishings wait for the confirmation, publishings should not be concurrent.
+// Confirmations are matched by delivery tag so that the late confirmation of a timed out publishing is not taken for the next one.
+type confirmingChannel struct {
+	*amqp.Channel
+	confirms  chan amqp.Confirmation
+	published uint64 // Delivery tag of the last publishing, the broker numbers the publishings of the channel from 1
+}
+
+func newConfirmingChannel(channel *amqp.Channel) (*confirmingChannel, error) {
+	if err := channel.Confirm(false); err != nil {
+		return nil, err
+	}
+	return &confirmingChannel{Channel: channel, confirms: channel.NotifyPublish(make(chan amqp.Confirmation, 1))}, nil
+}
 
-	return fmt.Sprintf("%v://%v:%v@%v:%v/", queueProtocol, queueUser, queuePassword, queueHost, queuePort), isTLS
+func (channel *confirmingChannel) publishAndConfirm(exchange string, routingKey string, publishing amqp.Publishing) error {
+	if err := channel.Publish(exchange, routingKey, false, false, publishing); err != nil {
+		return err
+	}
+	channel.published++
+	deliveryTag := channel.published
+
+	timeout := time.After(republishConfirmTimeout)
+	for {
+		select {
+		case confirmation, ok := <-channel.confirms:
+			if !ok {
+				return amqp.ErrClosed
+			}
+			if confirmation.DeliveryTag < deliveryTag {
+				continue // Confirmation of an earlier publishing which timed out
+			}
+			if !confirmation.Ack {
+				return errors.New("event is not confirmed by the broker")
+			}
+			return nil
+		case <-timeout:
+			return errors.New("timed out waiting for the broker to confirm the event")
+		}
+	}
 }
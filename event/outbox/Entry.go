@@ -0,0 +1,28 @@
+package outbox
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Entry represents an event staged in the outbox table, waiting to be relayed to the event dispatcher
+type Entry struct {
+	ID            uuid.UUID  `gorm:"type:varchar(36);primary_key;"`
+	Topic         string     `gorm:"column:topic;type:varchar(255)"`
+	Token         string     `gorm:"column:token;type:text"`
+	CorrelationID string     `gorm:"column:correlationId;type:varchar(64)"`
+	Payload       string     `gorm:"column:payload;type:text"`
+	SchemaVersion string     `gorm:"column:schemaVersion;type:varchar(32)"`
+	Attempts      int        `gorm:"column:attempts"`
+	LastError     string     `gorm:"column:lastError;type:text"`
+	NextAttemptOn time.Time  `gorm:"column:nextAttemptOn;index:outbox_pending"`
+	ClaimedUntil  *time.Time `gorm:"column:claimedUntil"`
+	PublishedOn   *time.Time `gorm:"column:publishedOn;index:outbox_pending"`
+	CreatedAt     time.Time  `gorm:"column:createdOn"`
+}
+
+// TableName returns the name of outbox table
+func (Entry) TableName() string {
+	return "eventOutbox"
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/repository"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migrate creates / updates the outbox table
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Entry{})
+}
+
+// Add stages the event in the outbox as part of the given unit of work, the event is relayed only if the unit of work is committed
+func Add(uow *repository.UnitOfWork, token string, correlationID string, topic string, payload interface{}) (uuid.UUID, error) {
+	id := uuid.NewV4()
+	return id, AddWithID(uow, id, token, correlationID, topic, payload)
+}
+
+// AddWithID stages the event with the given id in the outbox as part of the given unit of work.
+// Staging an event with an id which already exists in the outbox is ignored, which allows the callers to deduplicate the events.
+func AddWithID(uow *repository.UnitOfWork, id uuid.UUID, token string, correlationID string, topic string, payload interface{}) error {
+	body, isByteMessage := payload.([]byte)
+	if !isByteMessage {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return microappError.NewUnexpectedError(microappError.ErrorCodeJSONMarshalFailure, err)
+		}
+	}
+
+	schemaVersion := ""
+	if schemaVersioner, ok := payload.(event.SchemaVersioner); ok {
+		schemaVersion = schemaVersioner.SchemaVersion()
+	}
+
+	entry := &Entry{
+		ID:            id,
+		Topic:         topic,
+		Token:         token,
+		CorrelationID: correlationID,
+		Payload:       string(body),
+		SchemaVersion: schemaVersion,
+		NextAttemptOn: time.Now(),
+	}
+	if err := uow.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error; err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package outbox
+
+import (
+	"sync"
+	"time"
+
+	"github.com/islax/microapp/event"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RelayConfig represents the configuration for outbox relay
+type RelayConfig struct {
+	PollInterval time.Duration // Interval at which the outbox is checked for pending events
+	BatchSize    int           // Maximum number of events picked in one go
+	MaxAttempts  int           // Number of attempts after which the event is marked failed and not retried anymore
+	ClaimTimeout time.Duration // Duration for which an event picked by a relay is not picked by other relays
+	Retention    time.Duration // Duration for which published events are retained to deduplicate, 0 retains forever
+}
+
+// Stats represents the outbox backlog
+type Stats struct {
+	Pending int64 `json:"pending"` // Events waiting to be published
+	Failed  int64 `json:"failed"`  // Events which exhausted all attempts
+}
+
+var (
+	pendingGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "microapp_event_outbox_pending",
+		Help: "The number of events in the outbox waiting to be published.",
+	})
+	failedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "microapp_event_outbox_failed",
+		Help: "The number of events in the outbox which exhausted all publish attempts.",
+	})
+	publishedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "microapp_event_outbox_published_total",
+		Help: "The total number of events relayed from the outbox.",
+	})
+	registerMetricsOnce sync.Once
+)
+
+// Relay publishes the events staged in the outbox to the event publisher
+type Relay struct {
+	db        *gorm.DB
+	publisher event.Publisher
+	logger    zerolog.Logger
+	config    RelayConfig
+	notify    chan struct{}
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewRelay creates a new outbox relay, the publisher should report the failure so that the event is retried
+func NewRelay(db *gorm.DB, publisher event.Publisher, logger zerolog.Logger, config RelayConfig) *Relay {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.ClaimTimeout <= 0 {
+		config.ClaimTimeout = time.Minute
+	}
+
+	registerMetricsOnce.Do(func() {
+		_ = prometheus.Register(pendingGauge)
+		_ = prometheus.Register(failedGauge)
+		_ = prometheus.Register(publishedCounter)
+	})
+
+	return &Relay{
+		db:        db.Session(&gorm.Session{NewDB: true}),
+		publisher: publisher,
+		logger:    logger.With().Str("module", "EventOutboxRelay").Logger(),
+		config:    config,
+		notify:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start starts relaying the events in background
+func (relay *Relay) Start() {
+	go relay.run()
+}
+
+// Stop stops relaying the events
+func (relay *Relay) Stop() {
+	relay.stopOnce.Do(func() {
+		close(relay.stop)
+	})
+}
+
+// Notify wakes up the relay to publish pending events without waiting for the poll interval
+func (relay *Relay) Notify() {
+	select {
+	case relay.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Backlog returns number of pending and failed events in the outbox
+func (relay *Relay) Backlog() (Stats, error) {
+	stats := Stats{}
+	if err := relay.db.Model(&Entry{}).Where(unpublished()).Where(clause.Lt{Column: clause.Column{Name: "attempts"}, Value: relay.config.MaxAttempts}).Count(&stats.Pending).Error; err != nil {
+		return stats, err
+	}
+	if err := relay.db.Model(&Entry{}).Where(relay.failed()).Count(&stats.Failed).Error; err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// Retry resets the attempts of failed events so that they are picked again
+func (relay *Relay) Retry() (int64, error) {
+	result := relay.db.Model(&Entry{}).Where(relay.failed()).Updates(map[string]interface{}{"attempts": 0, "nextAttemptOn": time.Now()})
+	if result.Error == nil && result.RowsAffected > 0 {
+		relay.Notify()
+	}
+	return result.RowsAffected, result.Error
+}
+
+func (relay *Relay) run() {
+	ticker := time.NewTicker(relay.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		relay.publishPending()
+		relay.purgePublished()
+		relay.refreshMetrics()
+
+		select {
+		case <-relay.stop:
+			return
+		case <-ticker.C:
+		case <-relay.notify:
+		}
+	}
+}
+
+func (relay *Relay) publishPending() {
+	for {
+		var entries []Entry
+		now := time.Now()
+		err := relay.db.Where(unpublished()).
+			Where(clause.Lt{Column: clause.Column{Name: "attempts"}, Value: relay.config.MaxAttempts}).
+			Where(clause.Lte{Column: clause.Column{Name: "nextAttemptOn"}, Value: now}).
+			Where(unclaimed(now)).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: "createdOn"}}).Limit(relay.config.BatchSize).Find(&entries).Error
+		if err != nil {
+			relay.logger.Error().Err(err).Msg("Unable to read pending events from outbox.")
+			return
+		}
+
+		claimed := 0
+		for i := range entries {
+			select {
+			case <-relay.stop:
+				return
+			default:
+			}
+			if relay.claim(&entries[i]) {
+				claimed++
+				relay.publish(&entries[i])
+			}
+		}
+
+		// Nothing claimed means the batch is taken by other relays or the claims failed, wait for the next poll instead of reading it again
+		if len(entries) < relay.config.BatchSize || claimed == 0 {
+			return
+		}
+	}
+}
+
+// claim marks the entry as picked so that other relays (e.g. other replicas of the service) do not publish it
+func (relay *Relay) claim(entry *Entry) bool {
+	now := time.Now()
+	claimedUntil := now.Add(relay.config.ClaimTimeout)
+	result := relay.db.Model(&Entry{}).Where("id = ?", entry.ID).Where(unpublished()).Where(unclaimed(now)).Update("claimedUntil", claimedUntil)
+	if result.Error != nil {
+		relay.logger.Error().Err(result.Error).Str("eventId", entry.ID.String()).Msg("Unable to claim outbox event.")
+		return false
+	}
+	return result.RowsAffected == 1
+}
+
+func (relay *Relay) publish(entry *Entry) {
+	err := relay.dispatch(entry)
+	if err == nil {
+		if err := relay.db.Model(&Entry{}).Where("id = ?", entry.ID).Updates(map[string]interface{}{"publishedOn": time.Now(), "claimedUntil": nil}).Error; err != nil {
+			relay.logger.Error().Err(err).Str("eventId", entry.ID.String()).Msg("Unable to mark outbox event published.")
+			return
+		}
+		publishedCounter.Inc()
+		return
+	}
+
+	attempts := entry.Attempts + 1
+	logEvent := relay.logger.Warn()
+	if attempts >= relay.config.MaxAttempts {
+		logEvent = relay.logger.Error()
+	}
+	logEvent.Err(err).Str("eventId", entry.ID.String()).Str("topic", entry.Topic).Str("correlationId", entry.CorrelationID).Int("attempts", attempts).Msg("Unable to publish outbox event.")
+
+	if err := relay.db.Model(&Entry{}).Where("id = ?", entry.ID).Updates(map[string]interface{}{
+		"attempts":      attempts,
+		"lastError":     err.Error(),
+		"nextAttemptOn": time.Now().Add(backoff(attempts)),
+		"claimedUntil":  nil,
+	}).Error; err != nil {
+		relay.logger.Error().Err(err).Str("eventId", entry.ID.String()).Msg("Unable to update outbox event attempts.")
+	}
+}
+
+func (relay *Relay) dispatch(entry *Entry) error {
+	return relay.publisher.Publish(&event.Message{ID: entry.ID.String(), Token: entry.Token, CorrelationID: entry.CorrelationID, Topic: entry.Topic, Payload: []byte(entry.Payload), SchemaVersion: entry.SchemaVersion, Time: entry.CreatedAt})
+}
+
+// unpublished is built with clause, like the other conditions, so that the camelCase columns are quoted as per the dialect
+func unpublished() clause.Expression {
+	return clause.Eq{Column: clause.Column{Name: "publishedOn"}, Value: nil}
+}
+
+func unclaimed(now time.Time) clause.Expression {
+	return clause.Or(clause.Eq{Column: clause.Column{Name: "claimedUntil"}, Value: nil}, clause.Lt{Column: clause.Column{Name: "claimedUntil"}, Value: now})
+}
+
+func (relay *Relay) failed() clause.Expression {
+	return clause.And(unpublished(), clause.Gte{Column: clause.Column{Name: "attempts"}, Value: relay.config.MaxAttempts})
+}
+
+func (relay *Relay) purgePublished() {
+	if relay.config.Retention <= 0 {
+		return
+	}
+	if err := relay.db.Where(clause.Lt{Column: clause.Column{Name: "publishedOn"}, Value: time.Now().Add(-relay.config.Retention)}).Delete(&Entry{}).Error; err != nil {
+		relay.logger.Error().Err(err).Msg("Unable to purge published events from outbox.")
+	}
+}
+
+func (relay *Relay) refreshMetrics() {
+	stats, err := relay.Backlog()
+	if err != nil {
+		relay.logger.Error().Err(err).Msg("Unable to get outbox backlog.")
+		return
+	}
+	pendingGauge.Set(float64(stats.Pending))
+	failedGauge.Set(float64(stats.Failed))
+}
+
+// backoff returns the delay before the next attempt, doubling with each attempt upto 5 minutes
+func backoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < 5*time.Minute; i++ {
+		delay *= 2
+	}
+	if delay > 5*time.Minute {
+		delay = 5 * time.Minute
+	}
+	return delay
+}
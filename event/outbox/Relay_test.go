@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/repository"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testPublisher struct {
+	mutex    sync.Mutex
+	err      error
+	messages []*event.Message
+}
+
+func (publisher *testPublisher) Publish(message *event.Message) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	if publisher.err != nil {
+		return publisher.err
+	}
+	publisher.messages = append(publisher.messages, message)
+	return nil
+}
+
+func openOutbox(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+uuid.NewV4().String()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func stage(t *testing.T, db *gorm.DB, commit bool, topic string) uuid.UUID {
+	uow := repository.NewUnitOfWork(db, false, zerolog.New(os.Stdout), log.Config{})
+	defer uow.Complete()
+	id, err := Add(uow, "token", "correlation-1", topic, map[string]string{"name": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit {
+		uow.Commit()
+	}
+	return id
+}
+
+func getEntry(t *testing.T, db *gorm.DB, id uuid.UUID) Entry {
+	var entry Entry
+	if err := db.Where("id = ?", id).First(&entry).Error; err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestAdd(t *testing.T) {
+	db := openOutbox(t)
+
+	stage(t, db, false, "test.rolledback")
+	id := stage(t, db, true, "test.committed")
+
+	var entries []Entry
+	if err := db.Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != id || entries[0].Payload != `{"name":"a"}` {
+		t.Fatalf("Expected only the event of the committed unit of work, Actual %+v", entries)
+	}
+
+	uow := repository.NewUnitOfWork(db, false, zerolog.New(os.Stdout), log.Config{})
+	if err := AddWithID(uow, id, "token", "correlation-2", "test.committed", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+	if entry := getEntry(t, db, id); entry.CorrelationID != "correlation-1" {
+		t.Errorf("Expected event with existing id to be ignored, Actual %+v", entry)
+	}
+}
+
+func TestRelayPublish(t *testing.T) {
+	db := openOutbox(t)
+	publisher := &testPublisher{}
+	relay := NewRelay(db, publisher, zerolog.New(os.Stdout), RelayConfig{BatchSize: 1})
+
+	first := stage(t, db, true, "test.first")
+	second := stage(t, db, true, "test.second")
+	relay.publishPending()
+
+	if len(publisher.messages) != 2 || publisher.messages[0].ID != first.String() || publisher.messages[1].ID != second.String() {
+		t.Fatalf("Expected events to be published in order across batches, Actual %+v", publisher.messages)
+	}
+	if message := publisher.messages[0]; message.Topic != "test.first" || message.Token != "token" || message.CorrelationID != "correlation-1" || string(message.Payload.([]byte)) != `{"name":"a"}` {
+		t.Errorf("Expected the staged event to be published, Actual %+v", message)
+	}
+	if entry := getEntry(t, db, first); entry.PublishedOn == nil || entry.ClaimedUntil != nil {
+		t.Errorf("Expected event to be marked published, Actual %+v", entry)
+	}
+
+	relay.publishPending()
+	if len(publisher.messages) != 2 {
+		t.Errorf("Expected published events not to be published again, Actual %v", len(publisher.messages))
+	}
+	if stats, err := relay.Backlog(); err != nil || stats.Pending != 0 || stats.Failed != 0 {
+		t.Errorf("Expected empty backlog, Actual %+v (%v)", stats, err)
+	}
+}
+
+func TestRelayFailure(t *testing.T) {
+	db := openOutbox(t)
+	publisher := &testPublisher{err: errors.New("broker unavailable")}
+	relay := NewRelay(db, publisher, zerolog.New(os.Stdout), RelayConfig{MaxAttempts: 2})
+
+	id := stage(t, db, true, "test.added")
+	relay.publishPending()
+
+	entry := getEntry(t, db, id)
+	if entry.Attempts != 1 || entry.LastError != "broker unavailable" || entry.PublishedOn != nil || entry.ClaimedUntil != nil {
+		t.Fatalf("Expected failed attempt to be recorded, Actual %+v", entry)
+	}
+	if !entry.NextAttemptOn.After(time.Now()) {
+		t.Errorf("Expected next attempt to be backed off, Actual %v", entry.NextAttemptOn)
+	}
+
+	relay.publishPending()
+	if entry := getEntry(t, db, id); entry.Attempts != 1 {
+		t.Errorf("Expected event not to be attempted before its next attempt, Actual %v attempts", entry.Attempts)
+	}
+
+	db.Model(&Entry{}).Where("id = ?", id).Update("nextAttemptOn", time.Now().Add(-time.Second))
+	relay.publishPending()
+	if stats, err := relay.Backlog(); err != nil || stats.Pending != 0 || stats.Failed != 1 {
+		t.Errorf("Expected event to fail after max attempts, Actual %+v (%v)", stats, err)
+	}
+
+	publisher.err = nil
+	if retried, err := relay.Retry(); err != nil || retried != 1 {
+		t.Fatalf("Expected failed event to be retried, Actual %v (%v)", retried, err)
+	}
+	relay.publishPending()
+	if len(publisher.messages) != 1 || getEntry(t, db, id).PublishedOn == nil {
+		t.Errorf("Expected retried event to be published, Actual %+v", publisher.messages)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{20, 5 * time.Minute},
+	}
+	for _, test := range tests {
+		if delay := backoff(test.attempts); delay != test.expected {
+			t.Errorf("Expected backoff of %v attempts to be %v, Actual %v", test.attempts, test.expected, delay)
+		}
+	}
+}
+
+func TestRelayClaim(t *testing.T) {
+	db := openOutbox(t)
+	relay := NewRelay(db, &testPublisher{}, zerolog.New(os.Stdout), RelayConfig{})
+	other := NewRelay(db, &testPublisher{}, zerolog.New(os.Stdout), RelayConfig{})
+
+	entry := getEntry(t, db, stage(t, db, true, "test.added"))
+	if !relay.claim(&entry) {
+		t.Fatal("Expected pending event to be claimed")
+	}
+	if other.claim(&entry) {
+		t.Error("Expected claimed event not to be claimed by other relay")
+	}
+
+	db.Model(&Entry{}).Where("id = ?", entry.ID).Update("claimedUntil", time.Now().Add(-time.Second))
+	if !other.claim(&entry) {
+		t.Error("Expected event to be claimed after the claim expired")
+	}
+
+	db.Model(&Entry{}).Where("id = ?", entry.ID).Updates(map[string]interface{}{"claimedUntil": nil, "publishedOn": time.Now()})
+	if relay.claim(&entry) {
+		t.Error("Expected published event not to be claimed")
+	}
+}
+
+func TestRelayPurge(t *testing.T) {
+	db := openOutbox(t)
+	relay := NewRelay(db, &testPublisher{}, zerolog.New(os.Stdout), RelayConfig{Retention: time.Hour})
+
+	expired := stage(t, db, true, "test.expired")
+	retained := stage(t, db, true, "test.retained")
+	pending := stage(t, db, true, "test.pending")
+	db.Model(&Entry{}).Where("id = ?", expired).Update("publishedOn", time.Now().Add(-2*time.Hour))
+	db.Model(&Entry{}).Where("id = ?", retained).Update("publishedOn", time.Now())
+
+	relay.purgePublished()
+
+	var ids []string
+	if err := db.Model(&Entry{}).Order("createdOn").Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != retained.String() || ids[1] != pending.String() {
+		t.Errorf("Expected only the events published before retention to be purged, Actual %v", ids)
+	}
+}
@@ -0,0 +1,181 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSpoolFull is returned when the event can neither be queued nor spooled to disk
+var ErrSpoolFull = errors.New("event spool is full")
+
+const spoolFileExtension = ".event"
+
+type spooledMessage struct {
+	MessageID     string            `json:"messageId"`
+	Token         string            `json:"token"`
+	Topic         string            `json:"topic"`
+	CorrelationID string            `json:"correlationId"`
+	SchemaVersion string            `json:"schemaVersion,omitempty"`
+	CreatedOn     time.Time         `json:"createdOn"`
+	TraceHeaders  map[string]string `json:"traceHeaders,omitempty"`
+	Body          []byte            `json:"body"`
+}
+
+// spool persists the events on local disk till they are confirmed by the broker
+type spool struct {
+	dir         string
+	maxMessages int
+	mutex       sync.Mutex
+	count       int
+	sequence    uint64
+	inFlight    map[string]bool
+}
+
+func newSpool(dir string, maxMessages int) (*spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	spool := &spool{dir: dir, maxMessages: maxMessages, inFlight: make(map[string]bool)}
+	names, err := spool.list()
+	if err != nil {
+		return nil, err
+	}
+	spool.count = len(names)
+	return spool, nil
+}
+
+// write persists the command, payload of the command should already be encoded
+func (spool *spool) write(command *queueCommand) (string, error) {
+	body, _ := command.payload.([]byte)
+	content, err := json.Marshal(&spooledMessage{MessageID: command.messageID, Token: command.token, Topic: command.topic, CorrelationID: command.corelationID, SchemaVersion: command.schemaVersion, CreatedOn: command.createdOn, TraceHeaders: command.traceHeaders, Body: body})
+	if err != nil {
+		return "", err
+	}
+
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+
+	if spool.maxMessages > 0 && spool.count >= spool.maxMessages {
+		return "", ErrSpoolFull
+	}
+
+	spool.sequence++
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), spool.sequence%1000000, spoolFileExtension)
+	tmpPath := filepath.Join(spool.dir, name+".tmp")
+	if err := writeAndSync(tmpPath, content); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(spool.dir, name)); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	// The rename is durable only once the directory is synced
+	if err := syncDir(spool.dir); err != nil {
+		os.Remove(filepath.Join(spool.dir, name))
+		return "", err
+	}
+	spool.count++
+	return name, nil
+}
+
+// writeAndSync writes the content to the file and flushes it to disk
+func writeAndSync(path string, content []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// list returns the names of spooled events in the order they were spooled
+func (spool *spool) list() ([]string, error) {
+	files, err := ioutil.ReadDir(spool.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), spoolFileExtension) {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// checkout reads the spooled event and marks it in-flight, returns nil if the event is already in-flight
+func (spool *spool) checkout(name string) (*queueCommand, error) {
+	spool.mutex.Lock()
+	if spool.inFlight[name] {
+		spool.mutex.Unlock()
+		return nil, nil
+	}
+	spool.inFlight[name] = true
+	spool.mutex.Unlock()
+
+	content, err := ioutil.ReadFile(filepath.Join(spool.dir, name))
+	if err != nil {
+		spool.release(name)
+		return nil, err
+	}
+	message := &spooledMessage{}
+	if err := json.Unmarshal(content, message); err != nil {
+		// Move aside the corrupted event so that it does not block the replay
+		spool.mutex.Lock()
+		delete(spool.inFlight, name)
+		if renameErr := os.Rename(filepath.Join(spool.dir, name), filepath.Join(spool.dir, name+".corrupt")); renameErr == nil {
+			spool.count--
+		}
+		spool.mutex.Unlock()
+		return nil, err
+	}
+	return &queueCommand{messageID: message.MessageID, token: message.Token, topic: message.Topic, corelationID: message.CorrelationID, payload: message.Body, schemaVersion: message.SchemaVersion, createdOn: message.CreatedOn, traceHeaders: message.TraceHeaders, spoolFile: name}, nil
+}
+
+// release marks the in-flight event to be picked again in next replay
+func (spool *spool) release(name string) {
+	spool.mutex.Lock()
+	delete(spool.inFlight, name)
+	spool.mutex.Unlock()
+}
+
+// remove deletes the spooled event once it is confirmed by the broker
+func (spool *spool) remove(name string) error {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+
+	delete(spool.inFlight, name)
+	if err := os.Remove(filepath.Join(spool.dir, name)); err != nil {
+		if os.IsNotExist(err) {
+			// Already removed, it is not counted anymore
+			return nil
+		}
+		return err
+	}
+	spool.count--
+	return nil
+}
+
+// size returns number of spooled events
+func (spool *spool) size() int {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	return spool.count
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package event
+
+// syncDir is a no-op on this platform, directories cannot be synced
+func syncDir(dir string) error {
+	return nil
+}
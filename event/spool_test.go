@@ -0,0 +1,64 @@
+package event
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newSpool(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := s.write(&queueCommand{messageID: "1", topic: "tenant_added", payload: []byte(`{"id":"1"}`)})
+	if err != nil {
+		t.Fatalf("Unable to spool first event: %v", err)
+	}
+	if _, err := s.write(&queueCommand{messageID: "2", topic: "tenant_added", payload: []byte(`{"id":"2"}`)}); err != nil {
+		t.Fatalf("Unable to spool second event: %v", err)
+	}
+	if _, err := s.write(&queueCommand{messageID: "3"}); err != ErrSpoolFull {
+		t.Fatalf("Expected [%v], Got [%v]", ErrSpoolFull, err)
+	}
+
+	command, err := s.checkout(first)
+	if err != nil || command == nil {
+		t.Fatalf("Unable to checkout spooled event: %v", err)
+	}
+	if command.messageID != "1" || string(command.payload.([]byte)) != `{"id":"1"}` || command.spoolFile != first {
+		t.Errorf("Unexpected spooled event: %+v", command)
+	}
+	if again, _ := s.checkout(first); again != nil {
+		t.Errorf("Expected in-flight event not to be checked out again")
+	}
+
+	if err := s.remove(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.remove(first); err != nil || s.size() != 1 {
+		t.Errorf("Expected removing a removed event to keep spool size [1], Got [%v] (%v)", s.size(), err)
+	}
+	if _, err := s.write(&queueCommand{messageID: "3"}); err != nil {
+		t.Fatalf("Unable to spool third event: %v", err)
+	}
+	if _, err := s.write(&queueCommand{messageID: "4"}); err != ErrSpoolFull {
+		t.Fatalf("Expected [%v], Got [%v]", ErrSpoolFull, err)
+	}
+
+	// Spool should survive restart
+	s, err = newSpool(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.size() != 2 {
+		t.Errorf("Expected spool size [2], Got [%v]", s.size())
+	}
+}
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/islax/microapp/config"
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/event/monitor"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// RabbitMQ transport publishes and consumes events through RabbitMQ
+	RabbitMQ = "rabbitmq"
+	// Memory transport publishes and consumes events through an in-process bus
+	Memory = "memory"
+)
+
+// Transport represents the messaging backend used to publish and consume events
+type Transport interface {
+	Name() string
+	NewDispatcher(logger *zerolog.Logger) (event.Dispatcher, error)
+	NewMonitor(logger *zerolog.Logger, queueName string, eventsToMonitor []string, eventSignal chan *monitor.EventInfo) (monitor.EventMonitor, error)
+}
+
+// New creates the transport selected by EVENT_TRANSPORT configuration
+func New(appName string, appConfig *config.Config) (Transport, error) {
+	retryPolicy := monitor.RetryPolicy{
+		ManualAck:       appConfig.GetBool(config.EvSuffixForEventManualAck),
+		MaxRedeliveries: appConfig.GetInt(config.EvSuffixForEventMaxRedeliveries),
+		InitialDelay:    time.Duration(appConfig.GetInt(config.EvSuffixForEventRetryDelay)) * time.Second,
+		MaxDelay:        time.Duration(appConfig.GetInt(config.EvSuffixForEventRetryMaxDelay)) * time.Second,
+		PrefetchCount:   appConfig.GetInt(config.EvSuffixForEventPrefetchCount),
+	}
+	var cloudEvents *event.CloudEventsConfig
+	if appConfig.GetBool(config.EvSuffixForEventCloudEventsEnabled) {
+		cloudEvents = &event.CloudEventsConfig{Source: appName, DefaultSchemaVersion: appConfig.GetString(config.EvSuffixForEventSchemaVersion)}
+	}
+
+	switch strings.ToLower(appConfig.GetString(config.EvSuffixForEventTransport)) {
+	case RabbitMQ:
+		connectionConfig := event.AMQPConnectionConfig{
+			Host:          appConfig.GetString(config.EvSuffixForQueueHost),
+			Port:          appConfig.GetString(config.EvSuffixForQueuePort),
+			User:          appConfig.GetString(config.EvSuffixForQueueUser),
+			Password:      appConfig.GetString(config.EvSuffixForQueuePassword),
+			TLS:           appConfig.GetBool(config.EvSuffixForQueueTLSEnabled),
+			CACert:        appConfig.GetString(config.EvSuffixForQueueCACert),
+			ClientCert:    appConfig.GetString(config.EvSuffixForQueueClientCert),
+			ClientCertKey: appConfig.GetString(config.EvSuffixForQueueClientCertKey),
+		}
+		return NewRabbitMQTransportWithRetryPolicy(event.RabbitMQDispatcherConfig{
+			Connection:       &connectionConfig,
+			SpoolDir:         appConfig.GetStringWithDefault(config.EvSuffixForEventSpoolDir, filepath.Join(os.TempDir(), "isla-eventspool", strings.ToLower(strings.ReplaceAll(appName, " ", "")))),
+			SpoolMaxMessages: appConfig.GetInt(config.EvSuffixForEventSpoolMaxMessages),
+			ConfirmTimeout:   time.Duration(appConfig.GetInt(config.EvSuffixForEventPublishConfirmTimeout)) * time.Second,
+			CloudEvents:      cloudEvents,
+		}, retryPolicy), nil
+	case Memory:
+		return NewMemoryTransportWithCloudEvents(event.NewMemoryBus(), retryPolicy, cloudEvents), nil
+	default:
+		return nil, fmt.Errorf("unsupported event transport: %v", appConfig.GetString(config.EvSuffixForEventTransport))
+	}
+}
+
+type rabbitMQTransport struct {
+	dispatcherConfig event.RabbitMQDispatcherConfig
+	retryPolicy      monitor.RetryPolicy
+}
+
+// NewRabbitMQTransport creates a transport which publishes and consumes events through RabbitMQ
+func NewRabbitMQTransport(dispatcherConfig event.RabbitMQDispatcherConfig) Transport {
+	return NewRabbitMQTransportWithRetryPolicy(dispatcherConfig, monitor.DefaultRetryPolicy())
+}
+
+// NewRabbitMQTransportWithRetryPolicy creates a transport which publishes and consumes events through RabbitMQ, monitors retry the failed events as per the given policy
+func NewRabbitMQTransportWithRetryPolicy(dispatcherConfig event.RabbitMQDispatcherConfig, retryPolicy monitor.RetryPolicy) Transport {
+	if dispatcherConfig.Connection == nil {
+		connectionConfig := event.AMQPConnectionConfigFromEnv()
+		dispatcherConfig.Connection = &connectionConfig
+	}
+	return &rabbitMQTransport{dispatcherConfig: dispatcherConfig, retryPolicy: retryPolicy}
+}
+
+func (transport *rabbitMQTransport) Name() string {
+	return RabbitMQ
+}
+
+func (transport *rabbitMQTransport) NewDispatcher(logger *zerolog.Logger) (event.Dispatcher, error) {
+	return event.NewRabbitMQEventDispatcherWithConfig(logger, transport.dispatcherConfig)
+}
+
+func (transport *rabbitMQTransport) NewMonitor(logger *zerolog.Logger, queueName string, eventsToMonitor []string, eventSignal chan *monitor.EventInfo) (monitor.EventMonitor, error) {
+	return monitor.NewRabbitMQEventMonitorWithRetryPolicy(logger, *transport.dispatcherConfig.Connection, queueName, eventsToMonitor, eventSignal, transport.retryPolicy)
+}
+
+type memoryTransport struct {
+	bus         *event.MemoryBus
+	retryPolicy monitor.RetryPolicy
+	cloudEvents *event.CloudEventsConfig
+}
+
+// NewMemoryTransport creates a transport which publishes and consumes events through the given in-process bus
+func NewMemoryTransport(bus *event.MemoryBus) Transport {
+	return NewMemoryTransportWithRetryPolicy(bus, monitor.DefaultRetryPolicy())
+}
+
+// NewMemoryTransportWithRetryPolicy creates a transport which publishes and consumes events through the given in-process bus, monitors retry the failed events as per the given policy
+func NewMemoryTransportWithRetryPolicy(bus *event.MemoryBus, retryPolicy monitor.RetryPolicy) Transport {
+	return NewMemoryTransportWithCloudEvents(bus, retryPolicy, nil)
+}
+
+// NewMemoryTransportWithCloudEvents creates a transport like NewMemoryTransportWithRetryPolicy, events are published in CloudEvents envelope if cloudEvents is not nil
+func NewMemoryTransportWithCloudEvents(bus *event.MemoryBus, retryPolicy monitor.RetryPolicy, cloudEvents *event.CloudEventsConfig) Transport {
+	return &memoryTransport{bus: bus, retryPolicy: retryPolicy, cloudEvents: cloudEvents}
+}
+
+func (transport *memoryTransport) Name() string {
+	return Memory
+}
+
+func (transport *memoryTransport) NewDispatcher(logger *zerolog.Logger) (event.Dispatcher, error) {
+	return event.NewMemoryEventDispatcherWithCloudEvents(transport.bus, transport.cloudEvents), nil
+}
+
+func (transport *memoryTransport) NewMonitor(logger *zerolog.Logger, queueName string, eventsToMonitor []string, eventSignal chan *monitor.EventInfo) (monitor.EventMonitor, error) {
+	return monitor.NewMemoryEventMonitorWithRetryPolicy(logger, transport.bus, queueName, eventsToMonitor, eventSignal, transport.retryPolicy)
+}
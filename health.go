@@ -0,0 +1,54 @@
+package microapp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/islax/microapp/config"
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/health"
+	"github.com/islax/microapp/lifecycle"
+)
+
+// registerHealthChecks adds the checks of the initialized components to the readiness probe
+func (app *App) registerHealthChecks() {
+	app.healthChecks = health.NewChecks(time.Duration(app.Config.GetInt(config.EvSuffixForHealthCheckTimeout))*time.Second, time.Duration(app.Config.GetInt(config.EvSuffixForHealthCheckCacheTTL))*time.Second)
+
+	app.healthChecks.AddReadinessCheck(health.CheckerFunc("lifecycle", func(ctx context.Context) error {
+		if app.Ready() {
+			return nil
+		}
+		var notRunning []string
+		for name, state := range app.lifecycle.States() {
+			if state != lifecycle.StateRunning {
+				notRunning = append(notRunning, fmt.Sprintf("%v is %v", name, state))
+			}
+		}
+		if len(notRunning) == 0 {
+			return fmt.Errorf("application is shutting down")
+		}
+		sort.Strings(notRunning)
+		return fmt.Errorf("application is not ready, %v", strings.Join(notRunning, ", "))
+	}))
+	if app.DB != nil {
+		app.healthChecks.AddReadinessCheck(health.GormChecker(app.DB))
+	}
+	if app.MemcachedClient != nil {
+		app.healthChecks.AddReadinessCheck(health.MemcachedChecker(app.MemcachedClient))
+	}
+	if connection, ok := app.eventDispatcher.(event.ConnectionStater); ok {
+		app.healthChecks.AddReadinessCheck(health.RabbitMQChecker("eventDispatcher", connection))
+	}
+	if minFreeMB := app.Config.GetInt(config.EvSuffixForHealthDiskMinFreeMB); minFreeMB > 0 {
+		app.healthChecks.AddReadinessCheck(health.DiskChecker(app.Config.GetString(config.EvSuffixForHealthDiskPath), uint64(minFreeMB)*1024*1024))
+	}
+}
+
+// HealthChecks returns the liveness and readiness checks of the app, to be served by controllers.NewHealthControllerWithChecks.
+// Custom service checks can be added to them.
+func (app *App) HealthChecks() *health.Checks {
+	return app.healthChecks
+}
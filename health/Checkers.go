@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/islax/microapp/event"
+	"gorm.io/gorm"
+)
+
+// GormChecker checks the database by pinging a connection from the pool
+func GormChecker(db *gorm.DB) Checker {
+	return CheckerFunc("db", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// MemcachedChecker checks memcached by pinging all the servers
+func MemcachedChecker(client *memcache.Client) Checker {
+	return CheckerFunc("memcached", func(ctx context.Context) error {
+		return client.Ping()
+	})
+}
+
+// RabbitMQChecker checks the connection state of the event dispatcher or monitor
+func RabbitMQChecker(name string, connection event.ConnectionStater) Checker {
+	return CheckerFunc(name, func(ctx context.Context) error {
+		if !connection.IsConnected() {
+			return errors.New("not connected to RabbitMQ")
+		}
+		return nil
+	})
+}
+
+// DiskChecker checks that the file system of the path has at least minFreeBytes available
+func DiskChecker(path string, minFreeBytes uint64) Checker {
+	return CheckerFunc("disk:"+path, func(ctx context.Context) error {
+		free, err := availableDiskSpace(path)
+		if err != nil {
+			return err
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("%v bytes available, minimum required is %v bytes", free, minFreeBytes)
+		}
+		return nil
+	})
+}
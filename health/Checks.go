@@ -0,0 +1,184 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status represents the health status of a check or the app
+type Status string
+
+const (
+	// StatusUp check passed
+	StatusUp Status = "UP"
+	// StatusDown check failed
+	StatusDown Status = "DOWN"
+)
+
+// Checker checks the health of a dependency of the app
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (checker *checkerFunc) Name() string {
+	return checker.name
+}
+
+func (checker *checkerFunc) Check(ctx context.Context) error {
+	return checker.check(ctx)
+}
+
+// CheckerFunc creates a named checker from the function, used for custom service checks
+func CheckerFunc(name string, check func(ctx context.Context) error) Checker {
+	return &checkerFunc{name: name, check: check}
+}
+
+// CheckResult represents the result of a check
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report represents the result of all the checks of a probe
+type Report struct {
+	Status    Status        `json:"status"`
+	CheckedOn time.Time     `json:"checkedOn"`
+	Cached    bool          `json:"cached"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+// Probe runs a set of checks concurrently, the report is cached for a short duration so that frequent probes do not hammer the dependencies
+type Probe struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mutex    sync.RWMutex
+	checkers []Checker
+
+	runMutex sync.Mutex
+	report   *Report
+}
+
+// NewProbe creates a new probe, each check should complete within the timeout and the report is reused for cacheTTL
+func NewProbe(timeout, cacheTTL time.Duration) *Probe {
+	return &Probe{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// AddCheck adds the checker to the probe
+func (probe *Probe) AddCheck(checker Checker) {
+	probe.mutex.Lock()
+	probe.checkers = append(probe.checkers, checker)
+	probe.mutex.Unlock()
+
+	probe.invalidate()
+}
+
+// Run runs all the checks or returns the cached report if it is not older than cacheTTL
+func (probe *Probe) Run(ctx context.Context) Report {
+	// Concurrent probes wait for the running one and share its report
+	probe.runMutex.Lock()
+	defer probe.runMutex.Unlock()
+
+	if probe.report != nil && time.Since(probe.report.CheckedOn) < probe.cacheTTL {
+		report := *probe.report
+		report.Cached = true
+		return report
+	}
+
+	probe.mutex.RLock()
+	checkers := make([]Checker, len(probe.checkers))
+	copy(checkers, probe.checkers)
+	probe.mutex.RUnlock()
+
+	report := Report{Status: StatusUp, CheckedOn: time.Now(), Checks: make([]CheckResult, len(checkers))}
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			report.Checks[i] = probe.runCheck(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	sort.SliceStable(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	probe.report = &report
+	return report
+}
+
+func (probe *Probe) runCheck(ctx context.Context, checker Checker) (result CheckResult) {
+	result = CheckResult{Name: checker.Name(), Status: StatusUp}
+	if probe.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, probe.timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check did not complete: %v", ctx.Err())
+	}
+	result.LatencyMs = float64(time.Since(startTime)) / float64(time.Millisecond)
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (probe *Probe) invalidate() {
+	probe.runMutex.Lock()
+	probe.report = nil
+	probe.runMutex.Unlock()
+}
+
+// Checks holds the liveness and readiness probes of the app
+type Checks struct {
+	Liveness  *Probe
+	Readiness *Probe
+}
+
+// NewChecks creates the liveness and readiness probes
+func NewChecks(timeout, cacheTTL time.Duration) *Checks {
+	return &Checks{Liveness: NewProbe(timeout, cacheTTL), Readiness: NewProbe(timeout, cacheTTL)}
+}
+
+// AddLivenessCheck adds the checker to the liveness probe, failing liveness checks restart the app so it should only check the app itself
+func (checks *Checks) AddLivenessCheck(checker Checker) {
+	checks.Liveness.AddCheck(checker)
+}
+
+// AddReadinessCheck adds the checker to the readiness probe, app does not receive requests while a readiness check fails
+func (checks *Checks) AddReadinessCheck(checker Checker) {
+	checks.Readiness.AddCheck(checker)
+}
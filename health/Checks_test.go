@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProbeReportsFailedCheckAndCachesReport(t *testing.T) {
+	calls := 0
+	probe := NewProbe(time.Second, time.Minute)
+	probe.AddCheck(CheckerFunc("ok", func(ctx context.Context) error { calls++; return nil }))
+	probe.AddCheck(CheckerFunc("failing", func(ctx context.Context) error { return errors.New("unreachable") }))
+
+	report := probe.Run(context.Background())
+	if report.Status != StatusDown || report.Cached {
+		t.Fatalf("Expected uncached DOWN report, got %v cached %v", report.Status, report.Cached)
+	}
+	if report.Checks[0].Name != "failing" || report.Checks[0].Error != "unreachable" || report.Checks[1].Status != StatusUp {
+		t.Errorf("Unexpected check results %+v", report.Checks)
+	}
+
+	if report = probe.Run(context.Background()); !report.Cached || calls != 1 {
+		t.Errorf("Expected cached report without running the checks again, cached %v calls %v", report.Cached, calls)
+	}
+}
+
+func TestProbeTimesOutSlowCheck(t *testing.T) {
+	probe := NewProbe(10*time.Millisecond, 0)
+	probe.AddCheck(CheckerFunc("slow", func(ctx context.Context) error { time.Sleep(time.Second); return nil }))
+
+	if report := probe.Run(context.Background()); report.Status != StatusDown {
+		t.Errorf("Expected slow check to be DOWN, got %v", report.Status)
+	}
+}
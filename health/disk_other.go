@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package health
+
+import (
+	"errors"
+	"runtime"
+)
+
+// availableDiskSpace is not supported on this platform
+func availableDiskSpace(path string) (uint64, error) {
+	return 0, errors.New("disk space check is not supported on " + runtime.GOOS)
+}
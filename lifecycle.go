@@ -0,0 +1,217 @@
+package microapp
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/islax/microapp/config"
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/lifecycle"
+)
+
+// registerComponents registers the components initialized by NewWithEnvValues with the lifecycle manager
+func (app *App) registerComponents() {
+	dbDependents := []string{"db"}
+	if app.eventDispatcher != nil {
+		app.RegisterComponent(app.eventDispatcherComponent())
+		dbDependents = append(dbDependents, "eventDispatcher")
+	}
+
+	app.RegisterComponent(lifecycle.Component{
+		Name:  "db",
+		Start: func(ctx context.Context) error { return app.initializeDB() },
+		Stop:  func(ctx context.Context) error { return app.closeDB() },
+	})
+	app.RegisterComponent(lifecycle.Component{
+		Name:      "dbReplicas",
+		DependsOn: []string{"db"},
+		Start:     func(ctx context.Context) error { return app.initializeDBReplicas() },
+		Stop:      func(ctx context.Context) error { return app.closeDBReplicas() },
+	})
+	app.RegisterComponent(lifecycle.Component{
+		Name:      "eventOutbox",
+		DependsOn: dbDependents,
+		Start:     func(ctx context.Context) error { return app.initializeEventOutbox() },
+		Stop: func(ctx context.Context) error {
+			if app.eventOutbox != nil {
+				app.eventOutbox.Stop()
+			}
+			return nil
+		},
+	})
+	app.RegisterComponent(app.auditTrailComponent())
+	app.RegisterComponent(app.softDeleteRetentionComponent())
+	app.RegisterComponent(lifecycle.Component{
+		Name:  "memcached",
+		Start: func(ctx context.Context) error { return app.initializeMemcache() },
+	})
+	app.RegisterComponent(lifecycle.Component{
+		Name:      "cache",
+		DependsOn: []string{"memcached"},
+		Start:     func(ctx context.Context) error { return app.initializeCache() },
+	})
+	app.RegisterComponent(lifecycle.Component{
+		Name:      "eventIdempotency",
+		DependsOn: []string{"db", "memcached"},
+		Start:     func(ctx context.Context) error { return app.initializeIdempotencyStore() },
+		Stop: func(ctx context.Context) error {
+			if app.idempotencyPurger != nil {
+				app.idempotencyPurger.Stop()
+			}
+			return nil
+		},
+	})
+}
+
+// registerProvidedComponents registers the components provided to New with the lifecycle manager
+func (app *App) registerProvidedComponents() {
+	if app.eventDispatcher != nil {
+		app.RegisterComponent(app.eventDispatcherComponent())
+	}
+	app.RegisterComponent(lifecycle.Component{
+		Name:  "cache",
+		Start: func(ctx context.Context) error { return app.initializeCache() },
+	})
+	if app.DB != nil && app.Config.GetBool(config.EvSuffixForDBRequired) {
+		app.RegisterComponent(lifecycle.Component{
+			Name: "db",
+			Stop: func(ctx context.Context) error { return app.closeDB() },
+		})
+		app.RegisterComponent(app.auditTrailComponent())
+		app.RegisterComponent(app.softDeleteRetentionComponent())
+	}
+}
+
+func (app *App) auditTrailComponent() lifecycle.Component {
+	return lifecycle.Component{
+		Name:      "auditTrail",
+		DependsOn: []string{"db"},
+		Start:     func(ctx context.Context) error { return app.initializeAuditTrail() },
+	}
+}
+
+func (app *App) softDeleteRetentionComponent() lifecycle.Component {
+	return lifecycle.Component{
+		Name:      "softDeleteRetention",
+		DependsOn: []string{"db"},
+		Start:     func(ctx context.Context) error { return app.initializeSoftDeleteRetention() },
+		Stop: func(ctx context.Context) error {
+			if app.retentionWorker != nil {
+				app.retentionWorker.Stop()
+			}
+			return nil
+		},
+	}
+}
+
+func (app *App) eventDispatcherComponent() lifecycle.Component {
+	dispatcher := app.eventDispatcher
+	return lifecycle.Component{
+		Name: "eventDispatcher",
+		Start: func(ctx context.Context) error {
+			if readinessWaiter, ok := dispatcher.(event.ReadinessWaiter); ok {
+				return readinessWaiter.WaitUntilReady(ctx)
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if closer, ok := dispatcher.(event.Closer); ok {
+				return closer.Close(ctx)
+			}
+			return nil
+		},
+	}
+}
+
+// closeDB closes the database connection pool
+func (app *App) closeDB() error {
+	if app.DB == nil {
+		return nil
+	}
+	sqlDB, err := app.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// registerServer registers the http server with the lifecycle manager and stops the app on SIGTERM or SIGINT
+func (app *App) registerServer() {
+	server := app.server
+	app.RegisterComponent(lifecycle.Component{
+		Name: "httpServer",
+		Stop: func(ctx context.Context) error {
+			if err := server.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		select {
+		case sig := <-signals:
+			app.log.Info().Str("signal", sig.String()).Msg("Received signal, stopping the application.")
+			app.Stop()
+		case <-app.shutdownComplete:
+		}
+		signal.Stop(signals)
+	}()
+}
+
+// RegisterComponent registers the component with the app lifecycle, it is stopped along with the app in the reverse order of start.
+// Component registered after the app is started is started immediately.
+func (app *App) RegisterComponent(component lifecycle.Component) error {
+	if err := app.lifecycle.Register(component); err != nil {
+		app.log.Error().Err(err).Str("component", component.Name).Msg("Failed to register component.")
+		return err
+	}
+	return nil
+}
+
+// Lifecycle returns the lifecycle manager of the app
+func (app *App) Lifecycle() *lifecycle.Manager {
+	return app.lifecycle
+}
+
+// Ready returns true if all the components of the app are running and the app is not shutting down
+func (app *App) Ready() bool {
+	return atomic.LoadInt32(&app.draining) == 0 && app.lifecycle.Ready()
+}
+
+// Shutdown marks the app as not ready, waits for ISLA_SHUTDOWN_DRAIN_DELAY so that the load balancers stop routing to it and then stops
+// all the components in the reverse order of start within the deadline of the context
+func (app *App) Shutdown(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&app.draining, 0, 1) {
+		select {
+		case <-app.shutdownComplete:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+
+	if drainDelay := time.Duration(app.Config.GetInt(config.EvSuffixForShutdownDrainDelay)) * time.Second; drainDelay > 0 {
+		app.log.Info().Msgf("Draining for %v before stopping the application.", drainDelay)
+		select {
+		case <-time.After(drainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	err := app.lifecycle.Stop(ctx)
+	if app.tracingShutdown != nil {
+		// Flushed after the components are stopped so that their spans are exported
+		if tracingErr := app.tracingShutdown(ctx); tracingErr != nil && err == nil {
+			err = tracingErr
+		}
+	}
+	app.shutdownOnce.Do(func() { close(app.shutdownComplete) })
+	return err
+}
@@ -0,0 +1,266 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// State represents the state of a component
+type State string
+
+const (
+	// StateRegistered component is registered but not started
+	StateRegistered State = "registered"
+	// StateStarting component is being started
+	StateStarting State = "starting"
+	// StateRunning component is started successfully
+	StateRunning State = "running"
+	// StateFailed component failed to start
+	StateFailed State = "failed"
+	// StateStopping component is being stopped
+	StateStopping State = "stopping"
+	// StateStopped component is stopped
+	StateStopped State = "stopped"
+)
+
+// Component represents a part of the app which is started and stopped along with the app
+type Component struct {
+	Name      string
+	DependsOn []string                        // Components which should be running before this component is started, this component is stopped before them
+	Start     func(ctx context.Context) error // Optional, ctx carries the startup deadline
+	Stop      func(ctx context.Context) error // Optional, ctx carries the shutdown deadline
+}
+
+type registeredComponent struct {
+	Component
+	state State
+	err   error
+}
+
+// Manager starts the registered components in the order of their dependencies and stops them in the reverse order
+type Manager struct {
+	logger     zerolog.Logger
+	mutex      sync.Mutex
+	components map[string]*registeredComponent
+	started    []*registeredComponent // In the order they are started
+	running    bool
+	stopping   bool
+}
+
+// NewManager creates a new lifecycle manager
+func NewManager(logger zerolog.Logger) *Manager {
+	return &Manager{logger: logger.With().Str("module", "Lifecycle").Logger(), components: make(map[string]*registeredComponent)}
+}
+
+// Register registers the component. Component registered after the manager is started is started immediately, its dependencies should be running.
+func (manager *Manager) Register(component Component) error {
+	manager.mutex.Lock()
+	if _, ok := manager.components[component.Name]; ok {
+		manager.mutex.Unlock()
+		return fmt.Errorf("component %v is already registered", component.Name)
+	}
+	registered := &registeredComponent{Component: component, state: StateRegistered}
+	manager.components[component.Name] = registered
+	running := manager.running
+	manager.mutex.Unlock()
+
+	if !running {
+		return nil
+	}
+	for _, dependency := range component.DependsOn {
+		if manager.State(dependency) != StateRunning {
+			manager.setState(registered, StateFailed, fmt.Errorf("dependency %v is not running", dependency))
+			return fmt.Errorf("component %v depends on %v which is not running", component.Name, dependency)
+		}
+	}
+	return manager.start(context.Background(), registered)
+}
+
+// Start starts the components in the order of their dependencies. If a component fails to start, the components already started are stopped and the error is returned.
+func (manager *Manager) Start(ctx context.Context) error {
+	order, err := manager.startOrder()
+	if err != nil {
+		return err
+	}
+
+	for _, component := range order {
+		if err := manager.start(ctx, component); err != nil {
+			manager.Stop(context.Background())
+			return err
+		}
+	}
+
+	manager.mutex.Lock()
+	manager.running = true
+	manager.mutex.Unlock()
+	manager.logger.Info().Msg("All components started.")
+	return nil
+}
+
+// Stop stops the started components in the reverse order, components which do not stop before the deadline of ctx are abandoned
+func (manager *Manager) Stop(ctx context.Context) error {
+	manager.mutex.Lock()
+	if manager.stopping {
+		manager.mutex.Unlock()
+		return nil
+	}
+	manager.stopping = true
+	manager.running = false
+	started := manager.started
+	manager.started = nil
+	manager.mutex.Unlock()
+
+	var errs []string
+	for i := len(started) - 1; i >= 0; i-- {
+		component := started[i]
+		manager.setState(component, StateStopping, nil)
+		if component.Stop != nil {
+			startTime := time.Now()
+			if err := callWithContext(ctx, component.Stop); err != nil {
+				manager.logger.Error().Err(err).Str("component", component.Name).Msg("Failed to stop component.")
+				manager.setState(component, StateStopped, err)
+				errs = append(errs, fmt.Sprintf("%v: %v", component.Name, err))
+				continue
+			}
+			manager.logger.Debug().Str("component", component.Name).Dur("took", time.Since(startTime)).Msg("Component stopped.")
+		}
+		manager.setState(component, StateStopped, nil)
+	}
+
+	manager.mutex.Lock()
+	manager.stopping = false
+	manager.mutex.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to stop components: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Ready returns true if all the registered components are running and the manager is not stopping
+func (manager *Manager) Ready() bool {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if !manager.running || manager.stopping {
+		return false
+	}
+	for _, component := range manager.components {
+		if component.state != StateRunning {
+			return false
+		}
+	}
+	return true
+}
+
+// State returns the state of the named component, empty if it is not registered
+func (manager *Manager) State(name string) State {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if component, ok := manager.components[name]; ok {
+		return component.state
+	}
+	return ""
+}
+
+// States returns the states of all the registered components
+func (manager *Manager) States() map[string]State {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	states := make(map[string]State, len(manager.components))
+	for name, component := range manager.components {
+		states[name] = component.state
+	}
+	return states
+}
+
+func (manager *Manager) start(ctx context.Context, component *registeredComponent) error {
+	manager.setState(component, StateStarting, nil)
+	if component.Start != nil {
+		startTime := time.Now()
+		if err := callWithContext(ctx, component.Start); err != nil {
+			manager.setState(component, StateFailed, err)
+			return fmt.Errorf("failed to start %v: %v", component.Name, err)
+		}
+		manager.logger.Debug().Str("component", component.Name).Dur("took", time.Since(startTime)).Msg("Component started.")
+	}
+
+	manager.mutex.Lock()
+	component.state = StateRunning
+	manager.started = append(manager.started, component)
+	manager.mutex.Unlock()
+	return nil
+}
+
+func (manager *Manager) setState(component *registeredComponent, state State, err error) {
+	manager.mutex.Lock()
+	component.state = state
+	component.err = err
+	manager.mutex.Unlock()
+}
+
+// startOrder sorts the components not yet started so that the dependencies are started first, components are otherwise started in the order of their names
+func (manager *Manager) startOrder() ([]*registeredComponent, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	names := make([]string, 0, len(manager.components))
+	for name := range manager.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	order := make([]*registeredComponent, 0, len(names))
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visited[name] {
+			return nil
+		}
+		component, ok := manager.components[name]
+		if !ok {
+			return fmt.Errorf("component %v depends on %v which is not registered", path[len(path)-1], name)
+		}
+		if visiting[name] {
+			return fmt.Errorf("circular dependency: %v", strings.Join(append(path, name), " -> "))
+		}
+		visiting[name] = true
+		for _, dependency := range component.DependsOn {
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		if component.state == StateRegistered || component.state == StateStopped || component.state == StateFailed {
+			order = append(order, component)
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// callWithContext invokes the hook and returns when it completes or the deadline of the context expires
+func callWithContext(ctx context.Context, hook func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
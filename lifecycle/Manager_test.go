@@ -0,0 +1,69 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestManager(t *testing.T) {
+	manager := NewManager(zerolog.New(os.Stdout))
+	var events []string
+	component := func(name string, dependsOn ...string) Component {
+		return Component{
+			Name:      name,
+			DependsOn: dependsOn,
+			Start:     func(ctx context.Context) error { events = append(events, "start "+name); return nil },
+			Stop:      func(ctx context.Context) error { events = append(events, "stop "+name); return nil },
+		}
+	}
+	manager.Register(component("outbox", "db", "dispatcher"))
+	manager.Register(component("dispatcher"))
+	manager.Register(component("db"))
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !manager.Ready() {
+		t.Error("Expected manager to be ready")
+	}
+	if err := manager.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if manager.Ready() {
+		t.Error("Expected manager not to be ready after stop")
+	}
+
+	expected := []string{"start db", "start dispatcher", "start outbox", "stop outbox", "stop dispatcher", "stop db"}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("Expected %v, Actual %v", expected, events)
+	}
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	manager := NewManager(zerolog.New(os.Stdout))
+	stopped := false
+	manager.Register(Component{Name: "db", Stop: func(ctx context.Context) error { stopped = true; return nil }})
+	manager.Register(Component{Name: "cache", DependsOn: []string{"db"}, Start: func(ctx context.Context) error { return errors.New("unreachable") }})
+
+	if err := manager.Start(context.Background()); err == nil {
+		t.Error("Expected start to fail")
+	}
+	if !stopped {
+		t.Error("Expected started components to be stopped")
+	}
+	if manager.State("cache") != StateFailed {
+		t.Errorf("Expected cache to be failed, Actual %v", manager.State("cache"))
+	}
+
+	cyclic := NewManager(zerolog.New(os.Stdout))
+	cyclic.Register(Component{Name: "a", DependsOn: []string{"b"}})
+	cyclic.Register(Component{Name: "b", DependsOn: []string{"a"}})
+	if err := cyclic.Start(context.Background()); err == nil {
+		t.Error("Expected circular dependency to fail")
+	}
+}
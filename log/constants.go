@@ -3,6 +3,8 @@ package log
 const (
 	// EventTypeAuthenticationErr log event type for validation error
 	EventTypeAuthenticationErr = "Key_AuthenticationError"
+	// EventTypeSecurityAudit log event type for security sensitive actions to be audited
+	EventTypeSecurityAudit = "Key_SecurityAudit"
 	// EventTypeServiceDataReplication log event type for
 	EventTypeServiceDataReplication = "Key_ServiceDataReplication"
 	// EventTypeSuccess log event type key success
@@ -24,6 +26,8 @@ const (
 	EventCodeInvalidData = "Key_InvalidPayload"
 	// EventCodeReadWriteFailure event code for read/write errors
 	EventCodeReadWriteFailure = "Key_ReadWriteFailure"
+	// EventCodeTenantIsolationBypassed log event code for statements executed without tenant isolation
+	EventCodeTenantIsolationBypassed = "Key_TenantIsolationBypassed"
 	// EventCodeUnknown log event code for unknown errors
 	EventCodeUnknown = "Key_Unknown"
 )
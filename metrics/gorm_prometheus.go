@@ -20,15 +20,33 @@ type Gormmetrics struct {
 }
 
 func RegisterGormMetrics(db *gorm.DB, appConfig *config.Config) error {
+	return RegisterGormMetricsWithContext(context.Background(), db, appConfig)
+}
+
+// RegisterGormMetricsWithContext registers the gorm metrics which are refreshed till the context is done
+func RegisterGormMetricsWithContext(ctx context.Context, db *gorm.DB, appConfig *config.Config) error {
+	return RegisterGormMetricsWithLabels(ctx, db, appConfig, nil)
+}
+
+// RegisterGormMetricsWithLabels registers the gorm metrics of the connection pool with the labels, e.g. to tell the replicas apart
+func RegisterGormMetricsWithLabels(ctx context.Context, db *gorm.DB, appConfig *config.Config, labels map[string]string) error {
 	p := Gormmetrics{
 		DB:     db,
 		Config: appConfig,
+		Labels: labels,
 	}
 	p.DBStats = newStats(p.Labels)
 	p.refreshOnce.Do(func() {
 		go func() {
-			for range time.Tick(time.Duration(p.Config.GetInt(config.EvSuffixForGormMetricsRefresh)) * time.Second) {
-				p.refresh()
+			ticker := time.NewTicker(time.Duration(p.Config.GetInt(config.EvSuffixForGormMetricsRefresh)) * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					p.refresh()
+				}
 			}
 		}()
 	})
@@ -10,7 +10,7 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
-// Base contains common columns for all tables.
+// Base contains common columns for all tables. Embed RowVersion along with it for the version column.
 type Base struct {
 	ID        uuid.UUID  `gorm:"type:varchar(36);primary_key;"`
 	CreatedAt time.Time  `gorm:"column:createdOn"`
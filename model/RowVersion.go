@@ -0,0 +1,23 @@
+package model
+
+// Versioned is implemented by the entities having version column, used for optimistic concurrency
+type Versioned interface {
+	GetVersion() int64
+	SetVersion(version int64)
+}
+
+// RowVersion contains the version column, embed it along with Base or TenantBase to check the version instead of modifiedOn on CheckVersionAndUpdate.
+// The version is incremented on every update and upsert if the repository.RowVersion plugin is registered (App registers it), else only on CheckVersionAndUpdate.
+type RowVersion struct {
+	Version int64 `gorm:"column:version;not null;default:0"`
+}
+
+// GetVersion returns the version of the record
+func (rowVersion *RowVersion) GetVersion() int64 {
+	return rowVersion.Version
+}
+
+// SetVersion sets the version of the record
+func (rowVersion *RowVersion) SetVersion(version int64) {
+	rowVersion.Version = version
+}
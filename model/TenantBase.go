@@ -15,3 +15,13 @@ type TenantBase struct {
 	UpdatedAt time.Time      `gorm:"column:modifiedOn"`
 	DeletedAt gorm.DeletedAt `sql:"index" gorm:"column:deletedOn"`
 }
+
+// TenantScoped is implemented by the entities whose records belong to a tenant, tenant isolation restricts them to the tenant of the unit of work
+type TenantScoped interface {
+	TenantColumn() string
+}
+
+// TenantColumn returns the column holding the tenant of the record
+func (TenantBase) TenantColumn() string {
+	return "tenantId"
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/islax/microapp/model"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// ChangeActionCreate entity is added
+	ChangeActionCreate = "create"
+	// ChangeActionUpdate entity is updated
+	ChangeActionUpdate = "update"
+	// ChangeActionDelete entity is deleted
+	ChangeActionDelete = "delete"
+)
+
+// Actor is the user on whose behalf the unit of work is executed
+type Actor struct {
+	UserID   uuid.UUID
+	UserName string
+	TenantID uuid.UUID
+	Token    string // Raw token, used to dispatch the change events
+}
+
+// FieldChange is the old and new value of a changed field
+type FieldChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// Change is a change made to an entity through the repository with change recorder
+type Change struct {
+	Action        string
+	Entity        string // Table of the entity
+	EntityID      string
+	TenantID      uuid.UUID // Tenant of the entity if it is tenant scoped, else of the actor
+	Fields        map[string]FieldChange
+	Actor         Actor
+	CorrelationID string
+}
+
+// ChangeRecorder records the changes made by Update, Upsert, UpdateBatch, UpsertBatch, CheckVersionAndUpdate and Delete of GormRepository, it is invoked in the transaction of the change
+type ChangeRecorder interface {
+	RecordChange(uow *UnitOfWork, change Change) error
+}
+
+type actorContextKey struct{}
+
+type correlationIDContextKey struct{}
+
+// WithActor returns the context whose units of work record the changes on behalf of the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor set on the context by WithActor
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx != nil {
+		if actor, ok := ctx.Value(actorContextKey{}).(Actor); ok {
+			return actor
+		}
+	}
+	return Actor{}
+}
+
+// WithCorrelationID returns the context whose units of work record the changes with the correlation id
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation id set on the context by WithCorrelationID
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if correlationID, ok := ctx.Value(correlationIDContextKey{}).(string); ok {
+			return correlationID
+		}
+	}
+	return ""
+}
+
+// NewAuditedRepository returns a repository which records the changes with the recorder
+func NewAuditedRepository(recorder ChangeRecorder) *GormRepository {
+	repository := &GormRepository{}
+	repository.SetChangeRecorder(recorder)
+	return repository
+}
+
+// SetChangeRecorder makes the repository record the changes with the recorder, nil stops recording
+func (repository *GormRepository) SetChangeRecorder(recorder ChangeRecorder) {
+	repository.changeRecorder = recorder
+}
+
+// snapshot holds the records as they were before a change
+type snapshot struct {
+	schema  *schema.Schema
+	records reflect.Value
+}
+
+// takeSnapshot reads the records to be changed, the ones with the primary key of the entity or matching the conditions
+func (repository *GormRepository) takeSnapshot(uow *UnitOfWork, entity interface{}, where ...interface{}) (*snapshot, error) {
+	if repository.changeRecorder == nil {
+		return nil, nil
+	}
+	statement := &gorm.Statement{DB: uow.DB}
+	if err := statement.Parse(entity); err != nil {
+		return nil, err
+	}
+	primaryField := statement.Schema.PrioritizedPrimaryField
+	if primaryField == nil {
+		return nil, fmt.Errorf("audit requires primary key on %v", statement.Schema.Name)
+	}
+
+	records := reflect.New(reflect.SliceOf(statement.Schema.ModelType))
+	db := uow.DB.Session(&gorm.Session{NewDB: true})
+	if id, zero := primaryField.ValueOf(reflect.Indirect(reflect.ValueOf(entity))); !zero {
+		db = db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: primaryField.DBName}, Value: id})
+	} else if len(where) == 0 {
+		return &snapshot{schema: statement.Schema, records: records.Elem()}, nil
+	}
+	if err := db.Find(records.Interface(), where...).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot{schema: statement.Schema, records: records.Elem()}, nil
+}
+
+// recordUpdate records the fields of the entity that differ from the snapshot, the entity is recorded as created if it did not exist.
+// Zero fields are not compared as they are not updated.
+func (repository *GormRepository) recordUpdate(uow *UnitOfWork, before *snapshot, entity interface{}) error {
+	if before == nil {
+		return nil
+	}
+	after := reflect.Indirect(reflect.ValueOf(entity))
+	action := ChangeActionUpdate
+	var old reflect.Value
+	if before.records.Len() > 0 {
+		old = before.records.Index(0)
+	} else {
+		action = ChangeActionCreate
+	}
+
+	fields := make(map[string]FieldChange)
+	for _, field := range auditedFields(before.schema) {
+		newValue, zero := field.ValueOf(after)
+		if zero {
+			continue
+		}
+		if !old.IsValid() {
+			fields[field.DBName] = FieldChange{New: newValue}
+		} else if oldValue, _ := field.ValueOf(old); !equalValues(oldValue, newValue) {
+			fields[field.DBName] = FieldChange{Old: oldValue, New: newValue}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return repository.changeRecorder.RecordChange(uow, newChange(uow, before.schema, action, after, fields))
+}
+
+// recordDelete records the deleted records of the snapshot with their values
+func (repository *GormRepository) recordDelete(uow *UnitOfWork, before *snapshot) error {
+	if before == nil {
+		return nil
+	}
+	for i := 0; i < before.records.Len(); i++ {
+		old := before.records.Index(i)
+		fields := make(map[string]FieldChange)
+		for _, field := range auditedFields(before.schema) {
+			if oldValue, zero := field.ValueOf(old); !zero {
+				fields[field.DBName] = FieldChange{Old: oldValue}
+			}
+		}
+		if err := repository.changeRecorder.RecordChange(uow, newChange(uow, before.schema, ChangeActionDelete, old, fields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// equalValues compares the values, times are compared irrespective of their location
+func equalValues(oldValue interface{}, newValue interface{}) bool {
+	switch typedNewValue := newValue.(type) {
+	case time.Time:
+		if typedOldValue, ok := oldValue.(time.Time); ok {
+			return typedOldValue.Equal(typedNewValue)
+		}
+	case *time.Time:
+		if typedOldValue, ok := oldValue.(*time.Time); ok && typedOldValue != nil && typedNewValue != nil {
+			return typedOldValue.Equal(*typedNewValue)
+		}
+	}
+	return reflect.DeepEqual(oldValue, newValue)
+}
+
+// auditedFields returns the columns of the entity except the ones maintained by gorm
+func auditedFields(entitySchema *schema.Schema) []*schema.Field {
+	fields := make([]*schema.Field, 0, len(entitySchema.Fields))
+	for _, field := range entitySchema.Fields {
+		if field.DBName != "" && field.AutoCreateTime == 0 && field.AutoUpdateTime == 0 && field.Readable {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func newChange(uow *UnitOfWork, entitySchema *schema.Schema, action string, record reflect.Value, fields map[string]FieldChange) Change {
+	ctx := uow.DB.Statement.Context
+	change := Change{Action: action, Entity: entitySchema.Table, Fields: fields, Actor: ActorFromContext(ctx), CorrelationID: CorrelationIDFromContext(ctx)}
+	if id, _ := entitySchema.PrioritizedPrimaryField.ValueOf(record); id != nil {
+		change.EntityID = fmt.Sprintf("%v", id)
+	}
+	change.TenantID = change.Actor.TenantID
+	if tenantScoped, ok := reflect.New(entitySchema.ModelType).Interface().(model.TenantScoped); ok {
+		if field := entitySchema.LookUpField(tenantScoped.TenantColumn()); field != nil {
+			if tenantID, ok := reflect.Indirect(field.ReflectValueOf(record)).Interface().(uuid.UUID); ok {
+				change.TenantID = tenantID
+			}
+		}
+	}
+	return change
+}
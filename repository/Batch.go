@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	microappError "github.com/islax/microapp/error"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize is the number of records written per batch when BatchOptions.BatchSize is not set
+const DefaultBatchSize = 100
+
+// BatchOptions configures AddBatch, UpdateBatch and UpsertBatch
+type BatchOptions struct {
+	// BatchSize is the number of records written per batch, DefaultBatchSize if not set
+	BatchSize int
+	// ConflictColumns identify the existing record on upsert, primary key if not set. MySQL ignores them and uses all the unique keys of the table.
+	ConflictColumns []string
+	// UpdateColumns are updated on upsert if the record exists, all the columns except primary key and created time if not set
+	UpdateColumns []string
+}
+
+// BatchError is the error of a failed batch, Offset and Count identify the records of the batch
+type BatchError struct {
+	Offset int
+	Count  int
+	Err    microappError.DatabaseError
+}
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("batch of %v records at %v failed: %v", e.Count, e.Offset, e.Err)
+}
+
+// BatchResult reports the number of records written and the errors of the failed batches
+type BatchResult struct {
+	Succeeded int
+	Errors    []BatchError
+}
+
+// AddBatch adds the entities (slice of entities) in batches of multi row inserts
+func (repository *GormRepository) AddBatch(uow *UnitOfWork, entities interface{}, options BatchOptions) (BatchResult, microappError.DatabaseError) {
+	return writeInBatches(uow, entities, options, func(inner *UnitOfWork, batch interface{}) error {
+		return inner.DB.Create(batch).Error
+	})
+}
+
+// UpdateBatch updates the non-zero fields of the entities (slice of entities), the updates of a batch are committed or rolled back together.
+// The changes are recorded per entity like Update.
+func (repository *GormRepository) UpdateBatch(uow *UnitOfWork, entities interface{}, options BatchOptions) (BatchResult, microappError.DatabaseError) {
+	return writeInBatches(uow, entities, options, func(inner *UnitOfWork, batch interface{}) error {
+		records := reflect.ValueOf(batch)
+		for i := 0; i < records.Len(); i++ {
+			entity := records.Index(i)
+			if entity.Kind() != reflect.Ptr {
+				entity = entity.Addr()
+			}
+			before, err := repository.takeSnapshot(inner, entity.Interface())
+			if err != nil {
+				return err
+			}
+			if err := inner.DB.Model(entity.Interface()).Updates(entity.Interface()).Error; err != nil {
+				return err
+			}
+			if err := repository.recordUpdate(inner, before, entity.Interface()); err != nil {
+				return err
+			}
+		}
+		return repository.invalidateAfterCommit(inner, batch)
+	})
+}
+
+// UpsertBatch inserts the entities (slice of entities) in batches updating the existing records on conflict (INSERT ... ON DUPLICATE KEY UPDATE / ON CONFLICT DO UPDATE).
+// The changes are recorded per entity like Upsert.
+func (repository *GormRepository) UpsertBatch(uow *UnitOfWork, entities interface{}, options BatchOptions) (BatchResult, microappError.DatabaseError) {
+	onConflict := clause.OnConflict{UpdateAll: len(options.UpdateColumns) == 0}
+	for _, column := range options.ConflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+	if len(options.UpdateColumns) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(options.UpdateColumns)
+	}
+	return writeInBatches(uow, entities, options, func(inner *UnitOfWork, batch interface{}) error {
+		records := reflect.ValueOf(batch)
+		snapshots := make([]*snapshot, records.Len())
+		for i := 0; i < records.Len(); i++ {
+			before, err := repository.takeSnapshot(inner, records.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+			snapshots[i] = before
+		}
+		if err := inner.DB.Clauses(onConflict).Create(batch).Error; err != nil {
+			return err
+		}
+		for i, before := range snapshots {
+			if err := repository.recordUpdate(inner, before, records.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return repository.invalidateAfterCommit(inner, batch)
+	})
+}
+
+// writeInBatches writes each batch in its own savepoint (UnitOfWork.Nested) so that a failed batch does not affect the others.
+// It returns the first batch error along with the result.
+func writeInBatches(uow *UnitOfWork, entities interface{}, options BatchOptions, write func(inner *UnitOfWork, batch interface{}) error) (BatchResult, microappError.DatabaseError) {
+	result := BatchResult{Errors: make([]BatchError, 0)}
+	records := reflect.Indirect(reflect.ValueOf(entities))
+	if records.Kind() != reflect.Slice {
+		return result, microappError.NewDatabaseError(errors.New("batch requires slice of entities"))
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	for offset := 0; offset < records.Len(); offset += batchSize {
+		end := offset + batchSize
+		if end > records.Len() {
+			end = records.Len()
+		}
+		// Slice shares the entities so that the generated values (e.g. timestamps) are set on them
+		batch := records.Slice(offset, end).Interface()
+		if err := uow.Nested(func(inner *UnitOfWork) error { return write(inner, batch) }); err != nil {
+			result.Errors = append(result.Errors, BatchError{Offset: offset, Count: end - offset, Err: microappError.NewDatabaseError(err)})
+			continue
+		}
+		result.Succeeded += end - offset
+	}
+
+	if len(result.Errors) > 0 {
+		return result, result.Errors[0].Err
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+
+	"github.com/islax/microapp/cache"
+	"github.com/islax/microapp/model"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// NewCachedRepository returns a repository whose reads by id are cached with the client
+func NewCachedRepository(client *cache.Client) *GormRepository {
+	repository := &GormRepository{}
+	repository.SetCache(client)
+	return repository
+}
+
+// SetCache makes Get and GetForTenant of the read-only units of work read through the cache, nil stops caching.
+// The records are keyed on their table and id in the namespace of their tenant, reads with preloaded associations are not cached.
+// Update, Upsert, CheckVersionAndUpdate, Delete and the batch updates invalidate the records once the unit of work is committed,
+// a read racing with the commit may cache the previous record till the ttl of the client.
+func (repository *GormRepository) SetCache(client *cache.Client) {
+	repository.cache = client
+}
+
+// readKey returns the key of the record with the id, false if the read is not cached.
+// The records of the tenant scoped entities are cached only if the read is restricted to the tenant of the key.
+func (repository *GormRepository) readKey(uow *UnitOfWork, out interface{}, id string, tenantID uuid.UUID, preloadAssociations []string) (string, *schema.Schema, bool) {
+	if repository.cache == nil || !uow.readOnly || len(preloadAssociations) > 0 {
+		return "", nil, false
+	}
+	statement := &gorm.Statement{DB: uow.DB}
+	if err := statement.Parse(out); err != nil {
+		return "", nil, false
+	}
+	if _, tenantScoped := reflect.New(statement.Schema.ModelType).Interface().(model.TenantScoped); !tenantScoped {
+		return repository.cache.Key(uuid.Nil, statement.Schema.Table, id), statement.Schema, true
+	}
+	ctx := uow.DB.Statement.Context
+	if ctx.Value(tenantIsolationBypassKey{}) != nil {
+		return "", nil, false
+	}
+	contextTenantID, ok := TenantFromContext(ctx)
+	if tenantID == uuid.Nil {
+		tenantID = contextTenantID
+	} else if ok && contextTenantID != tenantID {
+		return "", nil, false
+	}
+	if tenantID == uuid.Nil {
+		return "", nil, false
+	}
+	return repository.cache.Key(tenantID, statement.Schema.Table, id), statement.Schema, true
+}
+
+// readThrough reads the record of the key from the cache, on miss it is read by query and cached
+func (repository *GormRepository) readThrough(uow *UnitOfWork, out interface{}, key string, entitySchema *schema.Schema, query func() error) error {
+	loaded := false
+	value, err := repository.cache.GetOrLoad(key, func() ([]byte, error) {
+		loaded = true
+		if err := query(); err != nil {
+			return nil, err
+		}
+		if recordKey, ok := repository.recordKey(uow, entitySchema, reflect.Indirect(reflect.ValueOf(out))); !ok || recordKey != key {
+			return nil, nil
+		}
+		var buffer bytes.Buffer
+		if err := gob.NewEncoder(&buffer).Encode(out); err != nil {
+			uow.logger.Debug().Err(err).Str("entity", entitySchema.Table).Msg("Record is not cached, failed to encode.")
+			return nil, nil
+		}
+		return buffer.Bytes(), nil
+	})
+	if err != nil || loaded {
+		return err
+	}
+	if value != nil {
+		record := reflect.ValueOf(out).Elem()
+		record.Set(reflect.Zero(record.Type()))
+		if err := gob.NewDecoder(bytes.NewReader(value)).Decode(out); err == nil {
+			return nil
+		}
+	}
+	// The concurrent load did not yield a cacheable record
+	return query()
+}
+
+// recordKey returns the key of the record in the namespace of its tenant, false if the record has no id
+func (repository *GormRepository) recordKey(uow *UnitOfWork, entitySchema *schema.Schema, record reflect.Value) (string, bool) {
+	if entitySchema.PrioritizedPrimaryField == nil {
+		return "", false
+	}
+	id, zero := entitySchema.PrioritizedPrimaryField.ValueOf(record)
+	if zero {
+		return "", false
+	}
+	tenantID := uuid.Nil
+	if tenantScoped, ok := reflect.New(entitySchema.ModelType).Interface().(model.TenantScoped); ok {
+		tenantID, _ = TenantFromContext(uow.DB.Statement.Context)
+		if field := entitySchema.LookUpField(tenantScoped.TenantColumn()); field != nil {
+			if value, zero := field.ValueOf(record); !zero {
+				if recordTenantID, ok := value.(uuid.UUID); ok {
+					tenantID = recordTenantID
+				}
+			}
+		}
+	}
+	return repository.cache.Key(tenantID, entitySchema.Table, fmt.Sprintf("%v", id)), true
+}
+
+// invalidateAfterCommit removes the entities (entity or slice of entities) from the cache once the unit of work is committed.
+// If the entity has no id, the records matching the conditions are invalidated.
+func (repository *GormRepository) invalidateAfterCommit(uow *UnitOfWork, entities interface{}, where ...interface{}) error {
+	if repository.cache == nil {
+		return nil
+	}
+	statement := &gorm.Statement{DB: uow.DB}
+	if err := statement.Parse(entities); err != nil {
+		return err
+	}
+	if statement.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+
+	records := reflect.Indirect(reflect.ValueOf(entities))
+	if records.Kind() != reflect.Slice && records.Kind() != reflect.Array {
+		if _, zero := statement.Schema.PrioritizedPrimaryField.ValueOf(records); zero && len(where) > 0 {
+			matching := reflect.New(reflect.SliceOf(statement.Schema.ModelType))
+			if err := uow.DB.Session(&gorm.Session{NewDB: true}).Find(matching.Interface(), where...).Error; err != nil {
+				return err
+			}
+			records = matching.Elem()
+		} else {
+			records = reflect.Append(reflect.MakeSlice(reflect.SliceOf(records.Type()), 0, 1), records)
+		}
+	}
+
+	keys := make([]string, 0, records.Len())
+	for i := 0; i < records.Len(); i++ {
+		if key, ok := repository.recordKey(uow, statement.Schema, reflect.Indirect(records.Index(i))); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		client := repository.cache
+		uow.AfterCommit(func() { client.Invalidate(keys...) })
+	}
+	return nil
+}
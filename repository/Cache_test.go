@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/cache"
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/model"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type cachedWidget struct {
+	model.TenantBase
+	Name string
+}
+
+func TestCachedRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&cachedWidget{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(NewTenantIsolationPlugin()); err != nil {
+		t.Fatal(err)
+	}
+	backend := cache.NewLRU(10)
+	repository := NewCachedRepository(cache.NewClient(backend, "test", time.Minute))
+	tenant1, tenant2 := uuid.NewV4(), uuid.NewV4()
+	newUOW := func(tenantID uuid.UUID, readOnly bool) *UnitOfWork {
+		return NewUnitOfWorkWithContext(WithTenant(context.Background(), tenantID), db, readOnly, zerolog.New(os.Stdout), log.Config{})
+	}
+	get := func(tenantID uuid.UUID, id uuid.UUID) (cachedWidget, microappError.DatabaseError) {
+		widget := cachedWidget{Name: "stale"}
+		err := repository.Get(newUOW(tenantID, true), &widget, id, nil)
+		return widget, err
+	}
+
+	id := uuid.NewV4()
+	uow := newUOW(tenant1, false)
+	if err := repository.Add(uow, &cachedWidget{TenantBase: model.TenantBase{ID: id}, Name: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+
+	if widget, err := get(tenant1, id); err != nil || widget.Name != "first" || backend.Len() != 1 {
+		t.Fatalf("Expected widget read and cached, got %v, %v, %v entries", widget, err, backend.Len())
+	}
+	db.Exec("UPDATE cached_widgets SET name = 'changed outside'")
+	if widget, err := get(tenant1, id); err != nil || widget.Name != "first" || widget.TenantID != tenant1 {
+		t.Errorf("Expected cached widget, got %v, %v", widget, err)
+	}
+	if _, err := get(tenant2, id); err == nil || !err.IsRecordNotFoundError() {
+		t.Errorf("Expected widget not found for other tenant, got %v", err)
+	}
+
+	uow = newUOW(tenant1, false)
+	if err := repository.Update(uow, &cachedWidget{TenantBase: model.TenantBase{ID: id}, Name: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if backend.Len() != 1 {
+		t.Error("Expected widget invalidated only after commit")
+	}
+	uow.Commit()
+	if widget, err := get(tenant1, id); err != nil || widget.Name != "second" {
+		t.Errorf("Expected updated widget after commit, got %v, %v", widget, err)
+	}
+
+	uow = newUOW(tenant1, false)
+	if err := repository.Delete(uow, &cachedWidget{}, "name = ?", "second"); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+	if _, err := get(tenant1, id); err == nil || !err.IsRecordNotFoundError() {
+		t.Errorf("Expected deleted widget not found, got %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	microappError "github.com/islax/microapp/error"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultCursorPageLimit is the number of records returned by CursorPaginateForWeb when limit is not specified
+const DefaultCursorPageLimit = 100
+
+// SortColumn is a column the records are ordered by, Direction is ASC, DESC or empty for ascending
+type SortColumn struct {
+	Name      string
+	Direction string
+}
+
+func (sortColumn SortColumn) descending() bool {
+	return sortColumn.Direction == "DESC"
+}
+
+// column returns the sort column to be quoted as per the dialect, a table prefix (table.column) is kept
+func (sortColumn SortColumn) column() clause.Column {
+	column := clause.Column{Name: strings.Trim(sortColumn.Name, "`\"")}
+	if dot := strings.LastIndex(column.Name, "."); dot >= 0 {
+		column.Table = strings.Trim(column.Name[:dot], "`\"")
+		column.Name = strings.Trim(column.Name[dot+1:], "`\"")
+	}
+	return column
+}
+
+// CursorPaginator paginates the records on the sort columns using an opaque cursor instead of offset.
+// The cursor encodes the sort column values and the id of the first or last record of the page, the id breaks the ties so that the order is stable.
+// Sort columns should not be nullable. Paginate should be the last query processor and Complete should be called with the records after the query.
+type CursorPaginator struct {
+	Limit       int
+	SortColumns []SortColumn
+	CountTotal  bool
+
+	// Set by Paginate and Complete
+	Total      *int64
+	NextCursor string
+	PrevCursor string
+
+	position *cursor
+	fields   []*schema.Field
+}
+
+type cursor struct {
+	Signature string        `json:"s"`
+	Values    []cursorValue `json:"v"`
+	Backward  bool          `json:"b,omitempty"`
+}
+
+type cursorValue struct {
+	Time  *time.Time  `json:"t,omitempty"`
+	Value interface{} `json:"v,omitempty"`
+}
+
+// NewCursorPaginator returns a paginator positioned at encodedCursor, first page is returned if encodedCursor is empty
+func NewCursorPaginator(limit int, sortColumns []SortColumn, encodedCursor string, countTotal bool) (*CursorPaginator, error) {
+	if limit <= 0 {
+		return nil, microappError.NewValidationError("Key_InvalidFields", map[string]string{"limit": "Key_InvalidValue"})
+	}
+	paginator := &CursorPaginator{Limit: limit, SortColumns: sortColumns, CountTotal: countTotal}
+	if encodedCursor != "" {
+		position, err := decodeCursor(encodedCursor)
+		if err != nil || position.Signature != paginator.signature() || len(position.Values) != len(sortColumns)+1 {
+			return nil, microappError.NewValidationError("Key_InvalidFields", map[string]string{"cursor": "Key_InvalidValue"})
+		}
+		paginator.position = position
+	}
+	return paginator, nil
+}
+
+// CursorPaginateForWeb creates paginator from limit, cursor, count and orderby parameters of the URL, orderby is validated as in GetOrderBy
+func CursorPaginateForWeb(r *http.Request, validOrderByAttrs []string, orderByAttrAndDBColumn map[string][]string) (*CursorPaginator, error) {
+	queryParams := r.URL.Query()
+
+	limit := DefaultCursorPageLimit
+	if limitParam := queryParams.Get("limit"); limitParam != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit <= 0 {
+			return nil, microappError.NewValidationError("Key_InvalidFields", map[string]string{"limit": "Key_InvalidValue"})
+		}
+	}
+	countTotal := false
+	if countParam := queryParams.Get("count"); countParam != "" {
+		var err error
+		if countTotal, err = strconv.ParseBool(countParam); err != nil {
+			return nil, microappError.NewValidationError("Key_InvalidFields", map[string]string{"count": "Key_InvalidValue"})
+		}
+	}
+	sortColumns, err := GetSortColumns(queryParams["orderby"], validOrderByAttrs, orderByAttrAndDBColumn)
+	if err != nil {
+		return nil, err
+	}
+	return NewCursorPaginator(limit, sortColumns, queryParams.Get("cursor"), countTotal)
+}
+
+// Paginate restricts the query to the page after (or before) the cursor, it counts the records matching the query if CountTotal is set
+func (paginator *CursorPaginator) Paginate() QueryProcessor {
+	return func(db *gorm.DB, out interface{}) (*gorm.DB, microappError.DatabaseError) {
+		if out == nil {
+			return db, microappError.NewDatabaseError(errors.New("cursor pagination requires the output records"))
+		}
+		if paginator.CountTotal {
+			var total int64
+			if err := db.Model(out).Count(&total).Error; err != nil {
+				return db, microappError.NewDatabaseError(err)
+			}
+			paginator.Total = &total
+		}
+
+		sortColumns, err := paginator.resolveSortColumns(db, out)
+		if err != nil {
+			return db, microappError.NewDatabaseError(err)
+		}
+
+		backward := paginator.position != nil && paginator.position.Backward
+		if paginator.position != nil {
+			condition, args := keysetCondition(sortColumns, paginator.position.values(), backward)
+			db = db.Where(condition, args...)
+		}
+		for _, sortColumn := range sortColumns {
+			// Read in the reverse order when going backward, Complete restores the order
+			db = db.Order(clause.OrderByColumn{Column: sortColumn.column(), Desc: sortColumn.descending() != backward})
+		}
+		// One more record is read to find out if there is a next page
+		return db.Limit(paginator.Limit + 1), nil
+	}
+}
+
+// Complete trims the records read by Paginate to the page and sets NextCursor and PrevCursor, out should be the pointer to the slice passed to the query
+func (paginator *CursorPaginator) Complete(out interface{}) error {
+	records := reflect.ValueOf(out)
+	if records.Kind() != reflect.Ptr || records.Elem().Kind() != reflect.Slice {
+		return errors.New("cursor pagination requires pointer to slice of records")
+	}
+	records = records.Elem()
+
+	hasMore := records.Len() > paginator.Limit
+	if hasMore {
+		records.Set(records.Slice(0, paginator.Limit))
+	}
+	backward := paginator.position != nil && paginator.position.Backward
+	if backward {
+		swap := reflect.Swapper(records.Interface())
+		for i, j := 0, records.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	paginator.NextCursor, paginator.PrevCursor = "", ""
+	if records.Len() == 0 {
+		return nil
+	}
+	hasNext := hasMore || backward
+	hasPrev := backward && hasMore || !backward && paginator.position != nil
+	var err error
+	if hasNext {
+		if paginator.NextCursor, err = paginator.encodeCursor(records.Index(records.Len()-1), false); err != nil {
+			return err
+		}
+	}
+	if hasPrev {
+		if paginator.PrevCursor, err = paginator.encodeCursor(records.Index(0), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetHeaders sets X-Next-Cursor, X-Prev-Cursor and X-Total-Count (if counted) headers in response
+func (paginator *CursorPaginator) SetHeaders(w http.ResponseWriter) {
+	exposedHeaders := []string{"X-Next-Cursor", "X-Prev-Cursor"}
+	if paginator.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", paginator.NextCursor)
+	}
+	if paginator.PrevCursor != "" {
+		w.Header().Set("X-Prev-Cursor", paginator.PrevCursor)
+	}
+	if paginator.Total != nil {
+		exposedHeaders = append(exposedHeaders, "X-Total-Count")
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*paginator.Total, 10))
+	}
+	w.Header().Add("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+}
+
+// resolveSortColumns appends the primary key to the sort columns and looks up the model fields to read the cursor values from
+func (paginator *CursorPaginator) resolveSortColumns(db *gorm.DB, out interface{}) ([]SortColumn, error) {
+	statement := &gorm.Statement{DB: db}
+	if err := statement.Parse(out); err != nil {
+		return nil, err
+	}
+	primaryField := statement.Schema.PrioritizedPrimaryField
+	if primaryField == nil {
+		return nil, fmt.Errorf("cursor pagination requires primary key on %v", statement.Schema.Name)
+	}
+
+	tieBreaker := SortColumn{Name: primaryField.DBName}
+	if len(paginator.SortColumns) > 0 {
+		tieBreaker.Direction = paginator.SortColumns[len(paginator.SortColumns)-1].Direction
+	}
+	sortColumns := append(append([]SortColumn{}, paginator.SortColumns...), tieBreaker)
+
+	paginator.fields = make([]*schema.Field, len(sortColumns))
+	for i, sortColumn := range sortColumns {
+		name := sortColumn.Name
+		if dot := strings.LastIndex(name, "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+		field := statement.Schema.LookUpField(strings.Trim(name, "`\""))
+		if field == nil {
+			return nil, fmt.Errorf("cursor pagination sort column %v is not a field of %v", sortColumn.Name, statement.Schema.Name)
+		}
+		paginator.fields[i] = field
+	}
+	return sortColumns, nil
+}
+
+func (paginator *CursorPaginator) signature() string {
+	signature := make([]string, len(paginator.SortColumns))
+	for i, sortColumn := range paginator.SortColumns {
+		signature[i] = sortColumn.Name + " " + sortColumn.Direction
+	}
+	return strings.Join(signature, ",")
+}
+
+func (paginator *CursorPaginator) encodeCursor(record reflect.Value, backward bool) (string, error) {
+	position := cursor{Signature: paginator.signature(), Backward: backward, Values: make([]cursorValue, len(paginator.fields))}
+	for i, field := range paginator.fields {
+		value, _ := field.ValueOf(record)
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			if value, err = valuer.Value(); err != nil {
+				return "", err
+			}
+		}
+		switch typedValue := value.(type) {
+		case time.Time:
+			position.Values[i].Time = &typedValue
+		case *time.Time:
+			position.Values[i].Time = typedValue
+		default:
+			position.Values[i].Value = typedValue
+		}
+	}
+	encoded, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeCursor(encodedCursor string) (*cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	position := &cursor{}
+	if err := decoder.Decode(position); err != nil {
+		return nil, err
+	}
+	return position, nil
+}
+
+func (position *cursor) values() []interface{} {
+	values := make([]interface{}, len(position.Values))
+	for i, value := range position.Values {
+		switch {
+		case value.Time != nil:
+			values[i] = *value.Time
+		default:
+			if number, ok := value.Value.(json.Number); ok {
+				if intValue, err := number.Int64(); err == nil {
+					values[i] = intValue
+				} else {
+					values[i], _ = number.Float64()
+				}
+			} else {
+				values[i] = value.Value
+			}
+		}
+	}
+	return values
+}
+
+// keysetCondition returns (c1 > v1) OR (c1 = v1 AND c2 > v2) ..., the comparison is reversed for descending columns and when going backward
+func keysetCondition(sortColumns []SortColumn, values []interface{}, backward bool) (string, []interface{}) {
+	disjuncts := make([]string, len(sortColumns))
+	args := make([]interface{}, 0)
+	for i, sortColumn := range sortColumns {
+		conjuncts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, "? = ?")
+			args = append(args, sortColumns[j].column(), values[j])
+		}
+		operator := ">"
+		if sortColumn.descending() != backward {
+			operator = "<"
+		}
+		conjuncts = append(conjuncts, "? "+operator+" ?")
+		args = append(args, sortColumn.column(), values[i])
+		disjuncts[i] = "(" + strings.Join(conjuncts, " AND ") + ")"
+	}
+	return "(" + strings.Join(disjuncts, " OR ") + ")", args
+}
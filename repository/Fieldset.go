@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"net/http"
+	"strings"
+
+	microappError "github.com/islax/microapp/error"
+	"gorm.io/gorm"
+)
+
+// SelectFields restricts the selected columns to the ones needed for the requested fields, all the columns are selected if no field is requested.
+// fields - ["id", "name", "owner.name"], nested fields are of the preloaded associations.
+// validFields - {"id": ["id"], "name": ["name"], "owner": ["ownerId"]} maps the field to the db columns it needs, a nested field is valid if its parent is valid.
+// requiredColumns are always selected, e.g. primary key and foreign keys needed to preload the associations.
+func SelectFields(fields []string, validFields map[string][]string, requiredColumns ...string) (QueryProcessor, error) {
+	columns := make([]string, 0)
+	selected := make(map[string]bool)
+	addColumns := func(names ...string) {
+		for _, name := range names {
+			if !selected[name] {
+				selected[name] = true
+				columns = append(columns, name)
+			}
+		}
+	}
+
+	addColumns(requiredColumns...)
+	fieldRequested := false
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		dbColumns, ok := validFieldColumns(field, validFields)
+		if !ok {
+			return nil, microappError.NewValidationError("Key_InvalidFields", map[string]string{"fields": "Key_InvalidAttribute"})
+		}
+		addColumns(dbColumns...)
+		fieldRequested = true
+	}
+
+	return func(db *gorm.DB, out interface{}) (*gorm.DB, microappError.DatabaseError) {
+		if fieldRequested {
+			db = db.Select(columns)
+		}
+		return db, nil
+	}, nil
+}
+
+// SelectFieldsForWeb restricts the selected columns to the ones needed for the comma separated fields query parameter, see SelectFields.
+// It returns the requested fields as well so that the response can be projected on them with web.RespondJSONWithFields.
+func SelectFieldsForWeb(r *http.Request, validFields map[string][]string, requiredColumns ...string) (QueryProcessor, []string, error) {
+	fields := make([]string, 0)
+	for _, fieldsParam := range r.URL.Query()["fields"] {
+		for _, field := range strings.Split(fieldsParam, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	queryProcessor, err := SelectFields(fields, validFields, requiredColumns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return queryProcessor, fields, nil
+}
+
+// validFieldColumns returns the columns of the field or of its nearest valid parent
+func validFieldColumns(field string, validFields map[string][]string) ([]string, bool) {
+	for {
+		if dbColumns, ok := validFields[field]; ok {
+			return dbColumns, true
+		}
+		dot := strings.LastIndex(field, ".")
+		if dot < 0 {
+			return nil, false
+		}
+		field = field[:dot]
+	}
+}
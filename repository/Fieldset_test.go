@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/islax/microapp/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type fieldsetWidget struct {
+	model.Base
+	Name    string
+	OwnerID string `gorm:"column:ownerId"`
+}
+
+func TestSelectFields(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	validFields := map[string][]string{"id": {"id"}, "name": {"name"}, "owner": {"ownerId"}}
+
+	tests := []struct {
+		name     string
+		fields   []string
+		required []string
+		expected string
+	}{
+		{"NoFields", nil, []string{"id"}, "SELECT * FROM `fieldset_widgets`"},
+		{"Field", []string{"name"}, []string{"id"}, "SELECT `id`,`name` FROM `fieldset_widgets`"},
+		{"RequiredField", []string{"id"}, []string{"id"}, "SELECT `id` FROM `fieldset_widgets`"},
+		{"DuplicateRequiredColumns", []string{"id"}, []string{"id", "id"}, "SELECT `id` FROM `fieldset_widgets`"},
+		{"NestedField", []string{"owner.name", " "}, nil, "SELECT `ownerId` FROM `fieldset_widgets`"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queryProcessor, err := SelectFields(test.fields, validFields, test.required...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var widgets []fieldsetWidget
+			query, _ := queryProcessor(db.Model(&fieldsetWidget{}), &widgets)
+			if sql := query.Find(&widgets).Statement.SQL.String(); sql != test.expected {
+				t.Errorf("Expected [%v], got [%v]", test.expected, sql)
+			}
+		})
+	}
+
+	if _, err := SelectFields([]string{"secret"}, validFields); err == nil {
+		t.Error("Expected error for invalid field")
+	}
+}
+
+func TestSelectFieldsForWeb(t *testing.T) {
+	request := httptest.NewRequest("GET", "/widgets?fields=name,%20owner.name&fields=id", nil)
+	_, fields, err := SelectFieldsForWeb(request, map[string][]string{"id": {"id"}, "name": {"name"}, "owner": {"ownerId"}}, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 3 || fields[0] != "name" || fields[1] != "owner.name" || fields[2] != "id" {
+		t.Errorf("Expected the requested fields, got %v", fields)
+	}
+
+	if _, _, err := SelectFieldsForWeb(httptest.NewRequest("GET", "/widgets?fields=secret", nil), map[string][]string{"id": {"id"}}); err == nil {
+		t.Error("Expected error for invalid field")
+	}
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+const (
+	// ReplicaSelectionRoundRobin distributes the reads evenly across the healthy replicas
+	ReplicaSelectionRoundRobin = "round-robin"
+	// ReplicaSelectionLeastLatency routes the reads to the healthy replica with the least latency in the last health check
+	ReplicaSelectionLeastLatency = "least-latency"
+)
+
+// ReplicaRouterConfig configures the routing of the reads to the replicas
+type ReplicaRouterConfig struct {
+	// Selection is ReplicaSelectionRoundRobin (default) or ReplicaSelectionLeastLatency
+	Selection string
+	// Stickiness is the duration for which the reads of a correlation id are routed to the primary after its write is committed, 0 disables it
+	Stickiness time.Duration
+	// HealthCheckInterval is the interval at which the replicas are pinged, defaults to 10 seconds
+	HealthCheckInterval time.Duration
+}
+
+// Replica is a read replica of the primary database
+type Replica struct {
+	Name      string
+	DB        *gorm.DB
+	unhealthy int32
+	latency   int64
+}
+
+// NewReplica returns a replica which is considered healthy till its health check fails
+func NewReplica(name string, db *gorm.DB) *Replica {
+	return &Replica{Name: name, DB: db}
+}
+
+// IsHealthy returns whether the replica can serve the reads
+func (replica *Replica) IsHealthy() bool {
+	return atomic.LoadInt32(&replica.unhealthy) == 0
+}
+
+// Latency returns the ping latency of the replica in the last health check
+func (replica *Replica) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&replica.latency))
+}
+
+// ReplicaRouter routes the read-only units of work to the replicas and the rest to the primary.
+// Reads fall back to the primary if no replica is healthy or the correlation id has written within the stickiness window.
+// A read which fails on a replica with connection error is run again once on the primary, the replica is marked unhealthy till its health check succeeds.
+type ReplicaRouter struct {
+	primary  *gorm.DB
+	replicas []*Replica
+	config   ReplicaRouterConfig
+	logger   zerolog.Logger
+	next     uint32
+
+	mutex  sync.Mutex
+	writes map[string]time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewReplicaRouter returns a router of the reads to the replicas, Start begins the health checks of the replicas
+func NewReplicaRouter(primary *gorm.DB, replicas []*Replica, config ReplicaRouterConfig, logger zerolog.Logger) (*ReplicaRouter, error) {
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = 10 * time.Second
+	}
+	router := &ReplicaRouter{primary: primary, replicas: replicas, config: config, logger: logger, writes: make(map[string]time.Time)}
+	for _, replica := range replicas {
+		// Registered before gorm:preload so that the preloads and AfterFind hooks run on the result of the primary
+		if err := replica.DB.Callback().Query().After("gorm:query").Before("gorm:preload").Register("microapp:replica_query_fallback", router.fallBackToPrimary(replica, callbacks.Query)); err != nil {
+			return nil, err
+		}
+		if err := replica.DB.Callback().Row().After("gorm:row").Register("microapp:replica_row_fallback", router.fallBackToPrimary(replica, callbacks.RowQuery)); err != nil {
+			return nil, err
+		}
+	}
+	return router, nil
+}
+
+// Replicas returns the replicas of the router
+func (router *ReplicaRouter) Replicas() []*Replica {
+	return router.replicas
+}
+
+// ReadDB returns the database for the read-only unit of work of the context
+func (router *ReplicaRouter) ReadDB(ctx context.Context) *gorm.DB {
+	if router.isSticky(CorrelationIDFromContext(ctx)) {
+		return router.primary
+	}
+	if replica := router.selectReplica(); replica != nil {
+		return replica.DB
+	}
+	return router.primary
+}
+
+// RecordWrite routes the reads of the correlation id of the context to the primary for the stickiness window
+func (router *ReplicaRouter) RecordWrite(ctx context.Context) {
+	correlationID := CorrelationIDFromContext(ctx)
+	if router.config.Stickiness <= 0 || correlationID == "" {
+		return
+	}
+	router.mutex.Lock()
+	router.writes[correlationID] = time.Now().Add(router.config.Stickiness)
+	router.mutex.Unlock()
+}
+
+// Start begins the periodic health checks of the replicas
+func (router *ReplicaRouter) Start() {
+	router.stop = make(chan struct{})
+	router.done = make(chan struct{})
+	go func() {
+		defer close(router.done)
+		ticker := time.NewTicker(router.config.HealthCheckInterval)
+		defer ticker.Stop()
+		router.checkHealth()
+		for {
+			select {
+			case <-router.stop:
+				return
+			case <-ticker.C:
+				router.checkHealth()
+				router.purgeWrites()
+			}
+		}
+	}()
+}
+
+// Stop ends the health checks of the replicas
+func (router *ReplicaRouter) Stop() {
+	if router.stop == nil {
+		return
+	}
+	close(router.stop)
+	<-router.done
+	router.stop = nil
+}
+
+func (router *ReplicaRouter) isSticky(correlationID string) bool {
+	if router.config.Stickiness <= 0 || correlationID == "" {
+		return false
+	}
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	until, ok := router.writes[correlationID]
+	return ok && time.Now().Before(until)
+}
+
+func (router *ReplicaRouter) selectReplica() *Replica {
+	if len(router.replicas) == 0 {
+		return nil
+	}
+	if router.config.Selection == ReplicaSelectionLeastLatency {
+		var selected *Replica
+		for _, replica := range router.replicas {
+			if replica.IsHealthy() && (selected == nil || replica.Latency() < selected.Latency()) {
+				selected = replica
+			}
+		}
+		return selected
+	}
+	start := atomic.AddUint32(&router.next, 1)
+	for i := 0; i < len(router.replicas); i++ {
+		replica := router.replicas[(int(start)+i)%len(router.replicas)]
+		if replica.IsHealthy() {
+			return replica
+		}
+	}
+	return nil
+}
+
+func (router *ReplicaRouter) checkHealth() {
+	for _, replica := range router.replicas {
+		sqlDB, err := replica.DB.DB()
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), router.config.HealthCheckInterval)
+			start := time.Now()
+			err = sqlDB.PingContext(ctx)
+			cancel()
+			atomic.StoreInt64(&replica.latency, int64(time.Since(start)))
+		}
+		router.setHealth(replica, err)
+	}
+}
+
+// fallBackToPrimary returns the callback which marks the replica unhealthy if the read fails with connection error and runs the read again on the primary.
+// Reads within a transaction are not run again as the transaction cannot move to the primary.
+func (router *ReplicaRouter) fallBackToPrimary(replica *Replica, read func(db *gorm.DB)) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		err := db.Error
+		if !isConnectionError(err) {
+			return
+		}
+		router.setHealth(replica, err)
+		if _, inTransaction := db.Statement.ConnPool.(gorm.TxCommitter); inTransaction {
+			return
+		}
+		router.logger.Warn().Err(err).Str("replica", replica.Name).Msg("Read failed on the database replica, retrying on the primary.")
+		db.Error = nil
+		db.Statement.ConnPool = router.primary.Statement.ConnPool
+		read(db)
+	}
+}
+
+// isConnectionError checks whether the statement failed as the connection to the database is broken or closed
+func isConnectionError(err error) bool {
+	var netError net.Error
+	// database/sql does not export the error of the closed database
+	return err != nil && (errors.Is(err, driver.ErrBadConn) || errors.As(err, &netError) || err.Error() == "sql: database is closed")
+}
+
+// setHealth marks the replica healthy if err is nil else unhealthy, the transitions are logged
+func (router *ReplicaRouter) setHealth(replica *Replica, err error) {
+	if err != nil {
+		if atomic.SwapInt32(&replica.unhealthy, 1) == 0 {
+			router.logger.Warn().Err(err).Str("replica", replica.Name).Msg("Database replica is unhealthy, reads are routed to the other replicas or the primary.")
+		}
+		return
+	}
+	if atomic.SwapInt32(&replica.unhealthy, 0) == 1 {
+		router.logger.Info().Str("replica", replica.Name).Msg("Database replica is healthy again.")
+	}
+}
+
+func (router *ReplicaRouter) purgeWrites() {
+	now := time.Now()
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	for correlationID, until := range router.writes {
+		if now.After(until) {
+			delete(router.writes, correlationID)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/log"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReplicaRouter(t *testing.T) {
+	open := func() *gorm.DB {
+		db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+	primary := open()
+	replicas := []*Replica{NewReplica("replica-0", open()), NewReplica("replica-1", open())}
+	router, err := NewReplicaRouter(primary, replicas, ReplicaRouterConfig{Stickiness: time.Minute}, zerolog.New(os.Stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithCorrelationID(context.Background(), "correlation-1")
+	first, second := router.ReadDB(ctx), router.ReadDB(ctx)
+	if first == primary || second == primary || first == second {
+		t.Error("Expected reads to be distributed across the replicas")
+	}
+
+	router.RecordWrite(ctx)
+	if router.ReadDB(ctx) != primary {
+		t.Error("Expected reads of the correlation id to stick to the primary after write")
+	}
+	if router.ReadDB(WithCorrelationID(context.Background(), "correlation-2")) == primary {
+		t.Error("Expected reads of other correlation id to be routed to the replicas")
+	}
+
+	router.setHealth(replicas[0], errors.New("connection refused"))
+	for i := 0; i < 3; i++ {
+		if db := router.ReadDB(context.Background()); db != replicas[1].DB {
+			t.Error("Expected reads to skip the unhealthy replica")
+		}
+	}
+	router.setHealth(replicas[1], errors.New("connection refused"))
+	if router.ReadDB(context.Background()) != primary {
+		t.Error("Expected reads to fall back to the primary")
+	}
+
+	router.Start()
+	router.Stop()
+	if !replicas[0].IsHealthy() || !replicas[1].IsHealthy() {
+		t.Error("Expected replicas to be healthy after the health check")
+	}
+}
+
+type replicatedWidget struct {
+	ID   int
+	Name string
+}
+
+func TestReplicaRouterFallsBackToPrimary(t *testing.T) {
+	open := func(name string) *gorm.DB {
+		db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%v?mode=memory&cache=shared", uuid.NewV4())), &gorm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.AutoMigrate(&replicatedWidget{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&replicatedWidget{ID: 1, Name: name}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+	primary := open("primary")
+	replica := NewReplica("replica-0", open("replica"))
+	router, err := NewReplicaRouter(primary, []*Replica{replica}, ReplicaRouterConfig{}, zerolog.New(os.Stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uow := NewUnitOfWorkWithContext(context.Background(), router.ReadDB(context.Background()), true, zerolog.New(os.Stdout), log.Config{})
+	widget := replicatedWidget{}
+	if err := uow.DB.First(&widget, 1).Error; err != nil || widget.Name != "replica" {
+		t.Fatalf("Expected widget read from the replica, got %+v, %v", widget, err)
+	}
+
+	sqlDB, _ := replica.DB.DB()
+	sqlDB.Close()
+	widget = replicatedWidget{}
+	if err := uow.DB.First(&widget, 1).Error; err != nil || widget.Name != "primary" {
+		t.Errorf("Expected widget read from the primary after the replica connection is closed, got %+v, %v", widget, err)
+	}
+	var count int64
+	if err := uow.DB.Model(&replicatedWidget{}).Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("Expected count from the primary, got %v, %v", count, err)
+	}
+	if replica.IsHealthy() {
+		t.Error("Expected replica to be unhealthy after the connection error")
+	}
+	if router.ReadDB(context.Background()) != primary {
+		t.Error("Expected reads to be routed to the primary")
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/islax/microapp/cache"
 	microappError "github.com/islax/microapp/error"
 	"github.com/islax/microapp/log"
 	"github.com/islax/microapp/model"
@@ -16,6 +18,7 @@ import (
 
 	uuid "github.com/satori/go.uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository represents generic interface for interacting with DB
@@ -36,9 +39,14 @@ type Repository interface {
 	Update(uow *UnitOfWork, out interface{}) microappError.DatabaseError
 	UpdateWithOmit(uow *UnitOfWork, out interface{}, omitFields []string) microappError.DatabaseError
 	Upsert(uow *UnitOfWork, out interface{}, queryProcessors []QueryProcessor) microappError.DatabaseError
+	AddBatch(uow *UnitOfWork, entities interface{}, options BatchOptions) (BatchResult, microappError.DatabaseError)
+	UpdateBatch(uow *UnitOfWork, entities interface{}, options BatchOptions) (BatchResult, microappError.DatabaseError)
+	UpsertBatch(uow *UnitOfWork, entities interface{}, options BatchOptions) (BatchResult, microappError.DatabaseError)
 	Delete(uow *UnitOfWork, out interface{}, where ...interface{}) microappError.DatabaseError
 	DeleteForTenant(uow *UnitOfWork, out interface{}, tenantID uuid.UUID) microappError.DatabaseError
 	DeletePermanent(uow *UnitOfWork, out interface{}, where ...interface{}) microappError.DatabaseError
+	Restore(uow *UnitOfWork, out interface{}, where ...interface{}) microappError.DatabaseError
+	ListDeleted(uow *UnitOfWork, out interface{}, queryProcessors []QueryProcessor) microappError.DatabaseError
 
 	AddAssociations(uow *UnitOfWork, out interface{}, associationName string, associations ...interface{}) microappError.DatabaseError
 	RemoveAssociations(uow *UnitOfWork, out interface{}, associationName string, associations ...interface{}) microappError.DatabaseError
@@ -47,36 +55,94 @@ type Repository interface {
 
 // UnitOfWork represents a connection
 type UnitOfWork struct {
-	DB        *gorm.DB
-	committed bool
-	readOnly  bool
+	DB                 *gorm.DB
+	committed          bool
+	readOnly           bool
+	afterCommitHooks   []func()
+	afterRollbackHooks []func()
+	savepoints         []savepoint
+	nestedSequence     int
+	parent             *UnitOfWork // Set on the unit of work of Nested, hooks and savepoints are kept on the outermost one
+	logger             zerolog.Logger
 }
 
 // NewUnitOfWork creates new UnitOfWork
 func NewUnitOfWork(db *gorm.DB, readOnly bool, logger zerolog.Logger, logConfig log.Config) *UnitOfWork {
+	return NewUnitOfWorkWithContext(context.Background(), db, readOnly, logger, logConfig)
+}
+
+// NewUnitOfWorkWithContext creates new UnitOfWork whose statements are executed with the given context, so that they are part of its trace
+func NewUnitOfWorkWithContext(ctx context.Context, db *gorm.DB, readOnly bool, logger zerolog.Logger, logConfig log.Config) *UnitOfWork {
 	if readOnly {
-		return &UnitOfWork{DB: db.Session(&gorm.Session{NewDB: true, FullSaveAssociations: true, Logger: log.NewGormLogger(logger, logConfig)}), committed: false, readOnly: true}
+		return &UnitOfWork{DB: db.Session(&gorm.Session{NewDB: true, FullSaveAssociations: true, Context: ctx, Logger: log.NewGormLogger(logger, logConfig)}), committed: false, readOnly: true, logger: logger}
 	}
-	return &UnitOfWork{DB: db.Session(&gorm.Session{NewDB: true, FullSaveAssociations: true, Logger: log.NewGormLogger(logger, logConfig)}).Begin(), committed: false, readOnly: false}
+	return &UnitOfWork{DB: db.Session(&gorm.Session{NewDB: true, FullSaveAssociations: true, Context: ctx, Logger: log.NewGormLogger(logger, logConfig)}).Begin(), committed: false, readOnly: false, logger: logger}
 }
 
-// Complete marks end of unit of work
+// Complete marks end of unit of work, the transaction is rolled back if it is not committed.
+// It is a no-op on the unit of work of Nested.
 func (uow *UnitOfWork) Complete() {
+	if uow.parent != nil {
+		return
+	}
 	if !uow.committed && !uow.readOnly {
 		uow.DB.Rollback()
+		uow.afterCommitHooks = nil
+		uow.savepoints = nil
+		runHooks(uow.takeRollbackHooks(0))
 	}
 }
 
-// Commit the transaction
+// Commit the transaction. It is a no-op on the unit of work of Nested, which is committed along with the outer one.
+// A failed commit is only logged, use TryCommit when the caller must not report success without the commit, e.g. of outbox events.
 func (uow *UnitOfWork) Commit() {
+	if err := uow.TryCommit(); err != nil {
+		uow.logger.Error().Err(err).Msg("Unable to commit the transaction.")
+	}
+}
+
+// TryCommit commits the transaction like Commit and returns the error if the commit fails
+func (uow *UnitOfWork) TryCommit() error {
+	if uow.parent != nil {
+		return nil
+	}
 	if !uow.readOnly {
-		uow.DB.Commit()
+		if err := uow.DB.Commit().Error; err != nil {
+			return err
+		}
 	}
 	uow.committed = true
+	uow.afterRollbackHooks = nil
+	uow.savepoints = nil
+
+	hooks := uow.afterCommitHooks
+	uow.afterCommitHooks = nil
+	runHooks(hooks)
+	return nil
+}
+
+// IsReadOnly returns whether the unit of work is read-only, i.e. not in a transaction
+func (uow *UnitOfWork) IsReadOnly() bool {
+	return uow.readOnly
+}
+
+// AfterCommit registers a function to be invoked once the transaction is committed successfully.
+// It is discarded if the savepoint preceding its registration is rolled back.
+func (uow *UnitOfWork) AfterCommit(hook func()) {
+	root := uow.root()
+	root.afterCommitHooks = append(root.afterCommitHooks, hook)
+}
+
+// AfterRollback registers a function to be invoked once the transaction is rolled back, or the savepoint preceding its registration is rolled back
+func (uow *UnitOfWork) AfterRollback(hook func()) {
+	root := uow.root()
+	root.afterRollbackHooks = append(root.afterRollbackHooks, hook)
 }
 
 // GormRepository implements Repository
 type GormRepository struct {
+	changeRecorder ChangeRecorder
+	cache          *cache.Client
 }
 
 // NewRepository returns a new repository object
@@ -207,6 +273,14 @@ func Order(value interface{}, reorder bool) QueryProcessor {
 	}
 }
 
+// FilterColumn filters the results on the column equal to the value, nil filters on IS NULL.
+// The column is quoted as per the dialect, so camelCase columns match on PostgreSQL too.
+func FilterColumn(column string, value interface{}) QueryProcessor {
+	return func(db *gorm.DB, out interface{}) (*gorm.DB, microappError.DatabaseError) {
+		return db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value}), nil
+	}
+}
+
 // Filter will filter the results
 func Filter(condition string, args ...interface{}) QueryProcessor {
 	return func(db *gorm.DB, out interface{}) (*gorm.DB, microappError.DatabaseError) {
@@ -263,7 +337,14 @@ func (repository *GormRepository) Get(uow *UnitOfWork, out interface{}, id uuid.
 	for _, association := range preloadAssociations {
 		db = db.Preload(association)
 	}
-	if err := db.First(out, "id = ?", id).Error; err != nil {
+	query := func() error { return db.First(out, "id = ?", id).Error }
+	if key, entitySchema, ok := repository.readKey(uow, out, id.String(), uuid.Nil, preloadAssociations); ok {
+		if err := repository.readThrough(uow, out, key, entitySchema, query); err != nil {
+			return microappError.NewDatabaseError(err)
+		}
+		return nil
+	}
+	if err := query(); err != nil {
 		return microappError.NewDatabaseError(err)
 	}
 	return nil
@@ -275,7 +356,14 @@ func (repository *GormRepository) GetForTenant(uow *UnitOfWork, out interface{},
 	for _, association := range preloadAssociations {
 		db = db.Preload(association)
 	}
-	if err := db.First(out, "id = ? AND tenantid = ?", id, tenantID).Error; err != nil {
+	query := func() error { return db.First(out, "id = ? AND tenantid = ?", id, tenantID).Error }
+	if key, entitySchema, ok := repository.readKey(uow, out, id, tenantID, preloadAssociations); ok {
+		if err := repository.readThrough(uow, out, key, entitySchema, query); err != nil {
+			return microappError.NewDatabaseError(err)
+		}
+		return nil
+	}
+	if err := query(); err != nil {
 		return microappError.NewDatabaseError(err)
 	}
 	return nil
@@ -302,7 +390,7 @@ func (repository *GormRepository) GetAll(uow *UnitOfWork, out interface{}, query
 
 // GetAllForTenant returns all objects of specifeid tenantID
 func (repository *GormRepository) GetAllForTenant(uow *UnitOfWork, out interface{}, tenantID uuid.UUID, queryProcessors []QueryProcessor) microappError.DatabaseError {
-	queryProcessors = append([]QueryProcessor{Filter("tenantID = ?", tenantID)}, queryProcessors...)
+	queryProcessors = append([]QueryProcessor{FilterColumn("tenantId", tenantID)}, queryProcessors...)
 	return repository.GetAll(uow, out, queryProcessors)
 }
 
@@ -327,7 +415,7 @@ func (repository *GormRepository) GetAllUnscoped(uow *UnitOfWork, out interface{
 
 // GetAllUnscopedForTenant returns all objects (including deleted) of specifeid tenantID
 func (repository *GormRepository) GetAllUnscopedForTenant(uow *UnitOfWork, out interface{}, tenantID uuid.UUID, queryProcessors []QueryProcessor) microappError.DatabaseError {
-	queryProcessors = append([]QueryProcessor{Filter("tenantID = ?", tenantID)}, queryProcessors...)
+	queryProcessors = append([]QueryProcessor{FilterColumn("tenantId", tenantID)}, queryProcessors...)
 	return repository.GetAllUnscoped(uow, out, queryProcessors)
 }
 
@@ -353,7 +441,7 @@ func (repository *GormRepository) GetCount(uow *UnitOfWork, count *int64, entity
 // GetCountForTenant gets count of the given entity type for specified tenant
 func (repository *GormRepository) GetCountForTenant(uow *UnitOfWork, count *int64, tenantID uuid.UUID, entity interface{}, queryProcessors []QueryProcessor) microappError.DatabaseError {
 
-	db := uow.DB.Where("tenantID = ?", tenantID)
+	db := uow.DB.Where(clause.Eq{Column: clause.Column{Name: "tenantId"}, Value: tenantID})
 
 	if queryProcessors != nil {
 		var err error
@@ -388,14 +476,28 @@ func (repository *GormRepository) AddWithOmit(uow *UnitOfWork, entity interface{
 
 // Update specified Entity
 func (repository *GormRepository) Update(uow *UnitOfWork, entity interface{}) microappError.DatabaseError {
+	before, err := repository.takeSnapshot(uow, entity)
+	if err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	if err := uow.DB.Model(entity).Updates(entity).Error; err != nil {
 		return microappError.NewDatabaseError(err)
 	}
+	if err := repository.recordUpdate(uow, before, entity); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	if err := repository.invalidateAfterCommit(uow, entity); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	return nil
 }
 
 // Update or insert if not found
 func (repository *GormRepository) Upsert(uow *UnitOfWork, entity interface{}, queryProcessors []QueryProcessor) microappError.DatabaseError {
+	before, err := repository.takeSnapshot(uow, entity)
+	if err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	db := uow.DB
 	if queryProcessors != nil {
 		var err error
@@ -417,6 +519,12 @@ func (repository *GormRepository) Upsert(uow *UnitOfWork, entity interface{}, qu
 		}
 	}
 
+	if err := repository.recordUpdate(uow, before, entity); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	if err := repository.invalidateAfterCommit(uow, entity); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	return nil
 }
 
@@ -425,14 +533,33 @@ func (repository *GormRepository) UpdateWithOmit(uow *UnitOfWork, entity interfa
 	if err := uow.DB.Model(entity).Omit(omitFields...).Updates(entity).Error; err != nil {
 		return microappError.NewDatabaseError(err)
 	}
+	if err := repository.invalidateAfterCommit(uow, entity); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	return nil
 }
 
-// CheckVersionAndUpdate specified Entity after checking for version change
+// CheckVersionAndUpdate specified Entity after checking for version change.
+// The version column is checked and incremented for model.Versioned entities, else modifiedOn is checked. Returns ConflictError if the record is modified.
 func (repository *GormRepository) CheckVersionAndUpdate(uow *UnitOfWork, entity interface{}, queryProcessors []QueryProcessor) microappError.DatabaseError {
+	before, err := repository.takeSnapshot(uow, entity)
+	if err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	db := uow.DB
-	queryProcessors = append(queryProcessors, Filter("modifiedOn = ?", reflect.ValueOf(entity).Elem().FieldByName("Base").Interface().(model.Base).UpdatedAt))
-	var err error
+	versioned, isVersioned := entity.(model.Versioned)
+	if isVersioned {
+		version := versioned.GetVersion()
+		queryProcessors = append(queryProcessors, Filter("version = ?", version))
+		versioned.SetVersion(version + 1)
+		defer func() {
+			if err != nil {
+				versioned.SetVersion(version)
+			}
+		}()
+	} else {
+		queryProcessors = append(queryProcessors, FilterColumn("modifiedOn", reflect.Indirect(reflect.ValueOf(entity)).FieldByName("UpdatedAt").Interface()))
+	}
 	for _, queryProcessor := range queryProcessors {
 		db, err = queryProcessor(db, entity)
 		if err != nil {
@@ -441,25 +568,45 @@ func (repository *GormRepository) CheckVersionAndUpdate(uow *UnitOfWork, entity
 	}
 
 	updateResponse := db.Model(entity).Updates(entity)
-	if updateResponse.Error != nil {
-		return microappError.NewDatabaseError(updateResponse.Error)
+	if err = updateResponse.Error; err != nil {
+		return microappError.NewDatabaseError(err)
 	}
 	if updateResponse.RowsAffected == 0 {
-		return microappError.NewDatabaseError(errors.New("row data modified"))
+		err = errors.New("row data modified")
+		return microappError.NewConflictError(err)
+	}
+	if err = repository.recordUpdate(uow, before, entity); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	if err = repository.invalidateAfterCommit(uow, entity); err != nil {
+		return microappError.NewDatabaseError(err)
 	}
 	return nil
 }
 
 // Delete specified Entity
 func (repository *GormRepository) Delete(uow *UnitOfWork, entity interface{}, where ...interface{}) microappError.DatabaseError {
+	before, err := repository.takeSnapshot(uow, entity, where...)
+	if err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	if err := repository.invalidateAfterCommit(uow, entity, where...); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	if err := uow.DB.Delete(entity, where...).Error; err != nil {
 		return microappError.NewDatabaseError(err)
 	}
+	if err := repository.recordDelete(uow, before); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	return nil
 }
 
 // DeleteForTenant all recrod(s) of specified entity / entity type for given tenant
 func (repository *GormRepository) DeleteForTenant(uow *UnitOfWork, entity interface{}, tenantID uuid.UUID) microappError.DatabaseError {
+	if err := repository.invalidateAfterCommit(uow, entity, "tenantid = ?", tenantID); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	if err := uow.DB.Delete(entity, "tenantid = ?", tenantID).Error; err != nil {
 		return microappError.NewDatabaseError(err)
 	}
@@ -468,6 +615,9 @@ func (repository *GormRepository) DeleteForTenant(uow *UnitOfWork, entity interf
 
 // DeletePermanent deletes record permanently specified Entity
 func (repository *GormRepository) DeletePermanent(uow *UnitOfWork, entity interface{}, where ...interface{}) microappError.DatabaseError {
+	if err := repository.invalidateAfterCommit(uow, entity, where...); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
 	if err := uow.DB.Unscoped().Delete(entity, where...).Error; err != nil {
 		return microappError.NewDatabaseError(err)
 	}
@@ -563,8 +713,30 @@ func AddFiltersFromQueryParamsWithOR(r *http.Request, filterDetails ...string) (
 // orderByAttrs - ["column1:0", "column2:1"], validOrderByAttrs - ["column1", "column2", "column3"],
 // orderByAttrAndDBCloum - {"cloumn3": ["dbColunm4", "dbColumn5"]}
 func GetOrderBy(orderByAttrs []string, validOrderByAttrs []string, orderByAttrAndDBCloum map[string][]string, reorder bool) (QueryProcessor, error) {
+	sortColumns, err := GetSortColumns(orderByAttrs, validOrderByAttrs, orderByAttrAndDBCloum)
+	if err != nil {
+		return nil, err
+	}
 
 	retOrderByStr := ""
+	for i, sortColumn := range sortColumns {
+		if i > 0 {
+			retOrderByStr += ","
+		}
+		retOrderByStr += sortColumn.Name
+		if sortColumn.Direction != "" {
+			retOrderByStr = fmt.Sprintf("%v %v", retOrderByStr, sortColumn.Direction)
+		}
+	}
+	if retOrderByStr != "" {
+		return Order(retOrderByStr, reorder), nil
+	}
+	return nil, nil
+}
+
+// GetSortColumns validates orderByAttrs and maps them to db columns, see GetOrderBy for the format of parameters
+func GetSortColumns(orderByAttrs []string, validOrderByAttrs []string, orderByAttrAndDBCloum map[string][]string) ([]SortColumn, error) {
+	sortColumns := make([]SortColumn, 0)
 	validOrderByAttrsAsMap := make(map[string]bool)
 	validOrderByDirection := map[string]string{"ASC": "ASC", "0": "ASC", "A": "ASC", "DESC": "DESC", "1": "DESC", "D": "DESC"}
 
@@ -572,10 +744,7 @@ func GetOrderBy(orderByAttrs []string, validOrderByAttrs []string, orderByAttrAn
 		validOrderByAttrsAsMap[validOrderByAttr] = true
 	}
 
-	for i, orderByAttr := range orderByAttrs {
-		if i > 0 {
-			retOrderByStr += ","
-		}
+	for _, orderByAttr := range orderByAttrs {
 		if strings.TrimSpace(orderByAttr) != "" {
 			attrAndDirection := strings.Split(orderByAttr, ",")
 			if len(attrAndDirection) > 2 {
@@ -585,20 +754,17 @@ func GetOrderBy(orderByAttrs []string, validOrderByAttrs []string, orderByAttrAn
 				orderByDirection := ""
 				if len(attrAndDirection) == 2 { // 2 - order by contains direction too
 					if direction, ok := validOrderByDirection[strings.ToUpper(attrAndDirection[1])]; ok {
-						orderByDirection = fmt.Sprintf(" %v", direction)
+						orderByDirection = direction
 					} else {
 						return nil, microappError.NewValidationError("Key_InvalidFields", map[string]string{"orderby": "Key_InvalidDirection"})
 					}
 				}
 				if dbColumns, ok := orderByAttrAndDBCloum[attrAndDirection[0]]; ok { //Chk if it has any db column mapping
-					for j, dbColumn := range dbColumns {
-						if j > 0 {
-							retOrderByStr += ","
-						}
-						retOrderByStr = fmt.Sprintf("%v%v%v", retOrderByStr, dbColumn, orderByDirection)
+					for _, dbColumn := range dbColumns {
+						sortColumns = append(sortColumns, SortColumn{Name: dbColumn, Direction: orderByDirection})
 					}
 				} else {
-					retOrderByStr = fmt.Sprintf("%v%v%v", retOrderByStr, attrAndDirection[0], orderByDirection)
+					sortColumns = append(sortColumns, SortColumn{Name: attrAndDirection[0], Direction: orderByDirection})
 				}
 
 			} else {
@@ -606,10 +772,7 @@ func GetOrderBy(orderByAttrs []string, validOrderByAttrs []string, orderByAttrAn
 			}
 		}
 	}
-	if retOrderByStr != "" {
-		return Order(retOrderByStr, reorder), nil
-	}
-	return nil, nil
+	return sortColumns, nil
 }
 
 // Contains checks if value present in array
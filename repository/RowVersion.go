@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"reflect"
+
+	"github.com/islax/microapp/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// RowVersion is a gorm plugin which increments the version column of the versioned entities (model.Versioned) on every update and upsert,
+// so that CheckVersionAndUpdate and the ETags detect the changes made by Update, UpdateWithOmit, Upsert, UpdateBatch and UpsertBatch.
+// The version is incremented in the database, only CheckVersionAndUpdate sets the new version on the entity. Raw SQL is not versioned.
+type RowVersion struct{}
+
+// NewRowVersionPlugin returns the row version plugin to be registered with gorm.DB.Use
+func NewRowVersionPlugin() *RowVersion {
+	return &RowVersion{}
+}
+
+// Name implements gorm.Plugin
+func (plugin *RowVersion) Name() string {
+	return "microapp:rowVersion"
+}
+
+// Initialize implements gorm.Plugin
+func (plugin *RowVersion) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("microapp:row_version_upsert", incrementVersionOnConflict); err != nil {
+		return err
+	}
+	// gorm:update is wrapped instead of registering before it so that the statement is built after the other callbacks (e.g. tenant isolation) have added their clauses
+	return callback.Update().Replace("gorm:update", func(db *gorm.DB) {
+		incrementVersion(db)
+		callbacks.Update(db)
+	})
+}
+
+// versionField returns the version field of the statement if the model is versioned
+func versionField(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	if _, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(model.Versioned); !ok {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField("version")
+}
+
+// versionIncrement assigns version + 1 of the stored record, whatever the version of the entity is
+func versionIncrement(field *schema.Field) clause.Assignment {
+	return clause.Assignment{
+		Column: clause.Column{Name: field.DBName},
+		Value:  clause.Expr{SQL: "? + 1", Vars: []interface{}{clause.Column{Table: clause.CurrentTable, Name: field.DBName}}},
+	}
+}
+
+// withoutVersion removes the assignments of the version column
+func withoutVersion(set clause.Set, field *schema.Field) clause.Set {
+	assignments := make(clause.Set, 0, len(set)+1)
+	for _, assignment := range set {
+		if assignment.Column.Name != field.DBName {
+			assignments = append(assignments, assignment)
+		}
+	}
+	return assignments
+}
+
+// incrementVersion builds the UPDATE statement the way gorm:update does, with the version assignment replaced by the increment.
+// gorm:update then executes the built statement.
+func incrementVersion(db *gorm.DB) {
+	field := versionField(db)
+	if field == nil || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	if !db.Statement.Unscoped {
+		for _, c := range db.Statement.Schema.UpdateClauses {
+			db.Statement.AddClause(c)
+		}
+	}
+	db.Statement.AddClauseIfNotExists(clause.Update{})
+	set := callbacks.ConvertToAssignments(db.Statement)
+	if db.Error != nil || len(set) == 0 {
+		return
+	}
+	db.Statement.AddClause(append(withoutVersion(set, field), versionIncrement(field)))
+	db.Statement.Build("UPDATE", "SET", "WHERE")
+}
+
+// incrementVersionOnConflict increments the version of the existing records updated on conflict (upsert)
+func incrementVersionOnConflict(db *gorm.DB) {
+	field := versionField(db)
+	if field == nil {
+		return
+	}
+	c, ok := db.Statement.Clauses["ON CONFLICT"]
+	if !ok {
+		return
+	}
+	if onConflict, _ := c.Expression.(clause.OnConflict); onConflict.UpdateAll {
+		// Let gorm resolve the columns to update, they are kept on the clause
+		callbacks.ConvertToCreateValues(db.Statement)
+		if db.Error != nil {
+			return
+		}
+	}
+	onConflict, ok := db.Statement.Clauses["ON CONFLICT"].Expression.(clause.OnConflict)
+	if !ok || onConflict.DoNothing {
+		return
+	}
+	onConflict.UpdateAll = false
+	onConflict.DoUpdates = append(withoutVersion(onConflict.DoUpdates, field), versionIncrement(field))
+	if len(onConflict.Columns) == 0 {
+		for _, primaryField := range db.Statement.Schema.PrimaryFields {
+			onConflict.Columns = append(onConflict.Columns, clause.Column{Name: primaryField.DBName})
+		}
+	}
+	db.Statement.AddClause(onConflict)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrSavepointNotFound is returned when rolling back to a savepoint which is not created in the unit of work
+var ErrSavepointNotFound = errors.New("savepoint not found")
+
+var savepointNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// savepoint marks the hooks registered before it, so that the ones registered after it are discarded on rollback to it
+type savepoint struct {
+	name               string
+	afterCommitHooks   int
+	afterRollbackHooks int
+}
+
+// Savepoint creates a savepoint with the name in the transaction, name should be an identifier
+func (uow *UnitOfWork) Savepoint(name string) error {
+	if uow.readOnly {
+		return errors.New("savepoint requires a transaction, unit of work is read only")
+	}
+	if !savepointNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid savepoint name [%v]", name)
+	}
+	if err := uow.DB.SavePoint(name).Error; err != nil {
+		return err
+	}
+	root := uow.root()
+	root.savepoints = append(root.savepoints, savepoint{name: name, afterCommitHooks: len(root.afterCommitHooks), afterRollbackHooks: len(root.afterRollbackHooks)})
+	return nil
+}
+
+// RollbackTo rolls back the changes made after the savepoint, the savepoint remains to be rolled back to again.
+// The AfterCommit hooks registered after it are discarded and the AfterRollback hooks are invoked.
+func (uow *UnitOfWork) RollbackTo(name string) error {
+	root := uow.root()
+	index := -1
+	for i := len(root.savepoints) - 1; i >= 0; i-- {
+		if root.savepoints[i].name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%w: %v", ErrSavepointNotFound, name)
+	}
+	if err := uow.DB.RollbackTo(name).Error; err != nil {
+		return err
+	}
+
+	mark := root.savepoints[index]
+	root.savepoints = root.savepoints[:index+1]
+	root.afterCommitHooks = root.afterCommitHooks[:mark.afterCommitHooks]
+	runHooks(root.takeRollbackHooks(mark.afterRollbackHooks))
+	return nil
+}
+
+// Nested runs fn in a savepoint of the transaction, the changes of fn are rolled back if it returns error or panics, leaving the rest of the transaction intact.
+// The unit of work passed to fn shares the transaction, its Commit and Complete are no-ops. On read only unit of work fn is run as is.
+func (uow *UnitOfWork) Nested(fn func(inner *UnitOfWork) error) (err error) {
+	inner := &UnitOfWork{DB: uow.DB, readOnly: uow.readOnly, parent: uow.root(), logger: uow.logger}
+	if uow.readOnly {
+		return fn(inner)
+	}
+
+	root := uow.root()
+	root.nestedSequence++
+	name := fmt.Sprintf("microapp_nested_%d", root.nestedSequence)
+	if err := uow.Savepoint(name); err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			uow.RollbackTo(name)
+			panic(recovered)
+		}
+	}()
+
+	if err = fn(inner); err != nil {
+		if rollbackErr := uow.RollbackTo(name); rollbackErr != nil {
+			uow.logger.Error().Err(rollbackErr).Str("savepoint", name).Msg("Unable to roll back to savepoint.")
+		}
+	}
+	return err
+}
+
+func (uow *UnitOfWork) root() *UnitOfWork {
+	if uow.parent != nil {
+		return uow.parent
+	}
+	return uow
+}
+
+// takeRollbackHooks removes the AfterRollback hooks from the index and returns them in the reverse order of registration
+func (uow *UnitOfWork) takeRollbackHooks(from int) []func() {
+	hooks := make([]func(), 0, len(uow.afterRollbackHooks)-from)
+	for i := len(uow.afterRollbackHooks) - 1; i >= from; i-- {
+		hooks = append(hooks, uow.afterRollbackHooks[i])
+	}
+	uow.afterRollbackHooks = uow.afterRollbackHooks[:from]
+	return hooks
+}
+
+func runHooks(hooks []func()) {
+	for _, hook := range hooks {
+		hook()
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/model"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type savepointWidget struct {
+	model.Base
+	Name string
+}
+
+func TestNested(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&savepointWidget{}); err != nil {
+		t.Fatal(err)
+	}
+	repository := &GormRepository{}
+	events := make([]string, 0)
+	uow := NewUnitOfWork(db, false, zerolog.New(os.Stdout), log.Config{})
+	defer uow.Complete()
+
+	if err := repository.Add(uow, &savepointWidget{Base: model.Base{ID: uuid.NewV4()}, Name: "outer"}); err != nil {
+		t.Fatal(err)
+	}
+	uow.AfterCommit(func() { events = append(events, "outer committed") })
+
+	err = uow.Nested(func(inner *UnitOfWork) error {
+		if err := repository.Add(inner, &savepointWidget{Base: model.Base{ID: uuid.NewV4()}, Name: "failed"}); err != nil {
+			return err
+		}
+		inner.AfterCommit(func() { events = append(events, "failed committed") })
+		inner.AfterRollback(func() { events = append(events, "failed rolled back") })
+		inner.Commit()
+		return errors.New("failed")
+	})
+	if err == nil || len(events) != 1 || events[0] != "failed rolled back" {
+		t.Fatalf("Expected nested error and its rollback hook, got %v, %v", err, events)
+	}
+
+	err = uow.Nested(func(inner *UnitOfWork) error {
+		inner.AfterCommit(func() { events = append(events, "nested committed") })
+		return repository.Add(inner, &savepointWidget{Base: model.Base{ID: uuid.NewV4()}, Name: "nested"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uow.Savepoint("before_last"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Add(uow, &savepointWidget{Base: model.Base{ID: uuid.NewV4()}, Name: "last"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := uow.RollbackTo("before_last"); err != nil {
+		t.Fatal(err)
+	}
+	if err := uow.RollbackTo("unknown"); !errors.Is(err, ErrSavepointNotFound) {
+		t.Errorf("Expected savepoint not found, got %v", err)
+	}
+	if err := uow.Savepoint("invalid; DROP TABLE"); err == nil {
+		t.Error("Expected invalid savepoint name to fail")
+	}
+	uow.Commit()
+
+	widgets := []savepointWidget{}
+	if err := db.Order("name").Find(&widgets).Error; err != nil || len(widgets) != 2 || widgets[0].Name != "nested" || widgets[1].Name != "outer" {
+		t.Errorf("Expected outer and nested widgets, got %v, %v", widgets, err)
+	}
+	if len(events) != 3 || events[1] != "outer committed" || events[2] != "nested committed" {
+		t.Errorf("Expected commit hooks of outer and nested, got %v", events)
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	microappError "github.com/islax/microapp/error"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SoftDeleteColumn returns the column of the DeletedAt field of the entity, error if the entity is not soft deletable
+func SoftDeleteColumn(db *gorm.DB, entity interface{}) (string, error) {
+	statement := &gorm.Statement{DB: db}
+	if err := statement.Parse(entity); err != nil {
+		return "", err
+	}
+	if field := statement.Schema.LookUpField("DeletedAt"); field != nil && field.DBName != "" {
+		return field.DBName, nil
+	}
+	return "", fmt.Errorf("%v is not soft deletable, DeletedAt field is missing", statement.Schema.Name)
+}
+
+// Restore undeletes the soft deleted record(s) of the entity with its primary key or matching the conditions, returns record not found error if nothing is restored
+func (repository *GormRepository) Restore(uow *UnitOfWork, entity interface{}, where ...interface{}) microappError.DatabaseError {
+	column, err := SoftDeleteColumn(uow.DB, entity)
+	if err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	statement := &gorm.Statement{DB: uow.DB}
+	if err := statement.Parse(entity); err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	if len(where) == 0 {
+		primaryField := statement.Schema.PrioritizedPrimaryField
+		if primaryField == nil {
+			return microappError.NewDatabaseError(errors.New("restore requires primary key or conditions"))
+		}
+		if _, zero := primaryField.ValueOf(reflect.Indirect(reflect.ValueOf(entity))); zero {
+			return microappError.NewDatabaseError(errors.New("restore requires primary key or conditions"))
+		}
+	}
+
+	db := uow.DB.Unscoped().Model(entity).Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: nil})
+	if len(where) > 0 {
+		db = db.Where(where[0], where[1:]...)
+	}
+	result := db.Update(column, nil)
+	if result.Error != nil {
+		return microappError.NewDatabaseError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return microappError.NewDatabaseError(gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// ListDeleted returns the soft deleted records, out should be pointer to slice of entities
+func (repository *GormRepository) ListDeleted(uow *UnitOfWork, out interface{}, queryProcessors []QueryProcessor) microappError.DatabaseError {
+	column, err := SoftDeleteColumn(uow.DB, out)
+	if err != nil {
+		return microappError.NewDatabaseError(err)
+	}
+	deleted := func(db *gorm.DB, out interface{}) (*gorm.DB, microappError.DatabaseError) {
+		return db.Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: nil}), nil
+	}
+	return repository.GetAllUnscoped(uow, out, append([]QueryProcessor{deleted}, queryProcessors...))
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/model"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTenantRequired is returned when a statement on a tenant scoped entity is executed without a tenant in the unit of work
+var ErrTenantRequired = errors.New("tenant isolation: tenant is required for tenant scoped entity")
+
+// ErrTenantMismatch is returned when a tenant scoped entity of another tenant is added, or an update moves the entity to another tenant
+var ErrTenantMismatch = errors.New("tenant isolation: entity belongs to another tenant")
+
+type tenantContextKey struct{}
+
+type tenantIsolationBypassKey struct{}
+
+// WithTenant returns the context whose units of work are restricted to the records of the tenant when tenant isolation is enabled
+func WithTenant(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set on the context by WithTenant
+func TenantFromContext(ctx context.Context) (uuid.UUID, bool) {
+	if ctx == nil {
+		return uuid.Nil, false
+	}
+	tenantID, ok := ctx.Value(tenantContextKey{}).(uuid.UUID)
+	return tenantID, ok && tenantID != uuid.Nil
+}
+
+// TenantIsolation is a gorm plugin which restricts the statements on the tenant scoped entities (model.TenantScoped) to the tenant of the context.
+// Queries, updates and deletes are filtered on the tenant column and the tenant is set on the added entities, statements without a tenant fail with ErrTenantRequired.
+// Raw SQL is not restricted. Use UnitOfWork.WithoutTenantIsolation for the system tasks spanning the tenants.
+type TenantIsolation struct{}
+
+// NewTenantIsolationPlugin returns the tenant isolation plugin to be registered with gorm.DB.Use
+func NewTenantIsolationPlugin() *TenantIsolation {
+	return &TenantIsolation{}
+}
+
+// Name implements gorm.Plugin
+func (plugin *TenantIsolation) Name() string {
+	return "microapp:tenantIsolation"
+}
+
+// Initialize implements gorm.Plugin
+func (plugin *TenantIsolation) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	registrations := []error{
+		callback.Create().Before("gorm:create").Register("microapp:tenant_create", setTenant),
+		callback.Query().Before("gorm:query").Register("microapp:tenant_query", filterOnTenant),
+		callback.Row().Before("gorm:row").Register("microapp:tenant_row", filterOnTenant),
+		callback.Update().Before("gorm:update").Register("microapp:tenant_update", filterOnTenant),
+		callback.Update().Before("gorm:update").Register("microapp:tenant_update_check", rejectTenantChange),
+		callback.Delete().Before("gorm:delete").Register("microapp:tenant_delete", filterOnTenant),
+	}
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithoutTenantIsolation runs fn with a unit of work whose statements are not restricted to the tenant, reason is logged for audit.
+// The bypassed unit of work shares the transaction and the hooks of uow, like the one of Nested, and uow itself remains restricted.
+func (uow *UnitOfWork) WithoutTenantIsolation(reason string, fn func(bypassed *UnitOfWork) error) error {
+	event := uow.logger.Warn().Str("eventType", log.EventTypeSecurityAudit).Str("eventCode", log.EventCodeTenantIsolationBypassed).Str("reason", reason)
+	if _, file, line, ok := runtime.Caller(1); ok {
+		event = event.Str("caller", fmt.Sprintf("%v:%v", file, line))
+	}
+	if tenantID, ok := TenantFromContext(uow.DB.Statement.Context); ok {
+		event = event.Str("tenantId", tenantID.String())
+	}
+	event.Msg("Tenant isolation bypassed.")
+
+	bypassedDB := uow.DB.WithContext(context.WithValue(uow.DB.Statement.Context, tenantIsolationBypassKey{}, reason))
+	return fn(&UnitOfWork{DB: bypassedDB, readOnly: uow.readOnly, parent: uow.root(), logger: uow.logger})
+}
+
+// tenantColumn returns the tenant column of the entity of the statement, empty if the entity is not tenant scoped or isolation is bypassed
+func tenantColumn(db *gorm.DB) string {
+	if db.Statement.Schema == nil || db.Statement.Context == nil || db.Statement.Context.Value(tenantIsolationBypassKey{}) != nil {
+		return ""
+	}
+	if tenantScoped, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(model.TenantScoped); ok {
+		return tenantScoped.TenantColumn()
+	}
+	return ""
+}
+
+func filterOnTenant(db *gorm.DB) {
+	column := tenantColumn(db)
+	if column == "" || db.Error != nil {
+		return
+	}
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrTenantRequired)
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: tenantID},
+	}})
+}
+
+func setTenant(db *gorm.DB) {
+	column := tenantColumn(db)
+	if column == "" || db.Error != nil {
+		return
+	}
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrTenantRequired)
+		return
+	}
+	field := db.Statement.Schema.LookUpField(column)
+	if field == nil {
+		db.AddError(fmt.Errorf("tenant isolation: tenant column %v is not a field of %v", column, db.Statement.Schema.Name))
+		return
+	}
+
+	setOn := func(entity reflect.Value) {
+		value, zero := field.ValueOf(entity)
+		if zero {
+			db.AddError(field.Set(entity, tenantID))
+		} else if value != tenantID {
+			db.AddError(ErrTenantMismatch)
+		}
+	}
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			setOn(reflect.Indirect(db.Statement.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		setOn(db.Statement.ReflectValue)
+	}
+}
+
+// rejectTenantChange fails the update which sets the tenant column to another tenant, by the updated struct or the map of updates
+func rejectTenantChange(db *gorm.DB) {
+	column := tenantColumn(db)
+	if column == "" || db.Error != nil {
+		return
+	}
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	field := db.Statement.Schema.LookUpField(column)
+	if !ok || field == nil {
+		return // filterOnTenant reports the missing tenant
+	}
+
+	switch updates := db.Statement.Dest.(type) {
+	case map[string]interface{}:
+		for name, value := range updates {
+			if db.Statement.Schema.LookUpField(name) == field && !isTenant(value, tenantID) {
+				db.AddError(ErrTenantMismatch)
+				return
+			}
+		}
+	default:
+		entity := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+		if entity.Kind() != reflect.Struct || entity.Type() != db.Statement.Schema.ModelType {
+			return
+		}
+		if value, zero := field.ValueOf(entity); !zero && !isTenant(value, tenantID) {
+			db.AddError(ErrTenantMismatch)
+		}
+	}
+}
+
+// isTenant returns whether the value of the tenant column is the tenant
+func isTenant(value interface{}, tenantID uuid.UUID) bool {
+	switch tenant := value.(type) {
+	case uuid.UUID:
+		return tenant == tenantID
+	case *uuid.UUID:
+		return tenant != nil && *tenant == tenantID
+	case string:
+		return tenant == tenantID.String()
+	}
+	return false
+}
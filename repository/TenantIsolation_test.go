@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/model"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type tenantWidget struct {
+	model.TenantBase
+	Name string
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&tenantWidget{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(NewTenantIsolationPlugin()); err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(os.Stdout)
+	repository := &GormRepository{}
+	tenant1, tenant2 := uuid.NewV4(), uuid.NewV4()
+	newUOW := func(tenantID uuid.UUID, readOnly bool) *UnitOfWork {
+		return NewUnitOfWorkWithContext(WithTenant(context.Background(), tenantID), db, readOnly, logger, log.Config{})
+	}
+
+	for _, tenantID := range []uuid.UUID{tenant1, tenant2} {
+		uow := newUOW(tenantID, false)
+		widget := &tenantWidget{TenantBase: model.TenantBase{ID: uuid.NewV4()}, Name: tenantID.String()}
+		if err := repository.Add(uow, widget); err != nil || widget.TenantID != tenantID {
+			t.Fatalf("Expected widget added to tenant %v, got %v, %v", tenantID, widget.TenantID, err)
+		}
+		uow.Commit()
+	}
+
+	uow := newUOW(tenant1, false)
+	defer uow.Complete()
+	if err := repository.Add(uow, &tenantWidget{TenantBase: model.TenantBase{ID: uuid.NewV4(), TenantID: tenant2}}); err == nil {
+		t.Error("Expected adding widget of other tenant to fail")
+	}
+
+	widgets := []tenantWidget{}
+	if err := repository.GetAll(uow, &widgets, nil); err != nil || len(widgets) != 1 || widgets[0].TenantID != tenant1 {
+		t.Errorf("Expected only widget of tenant1, got %v, %v", widgets, err)
+	}
+	moved := widgets[0]
+	moved.TenantID = tenant2
+	if err := repository.Update(uow, &moved); err == nil || !errors.Is(err.GetCause(), ErrTenantMismatch) {
+		t.Errorf("Expected moving widget to other tenant to fail, got %v", err)
+	}
+	if err := uow.DB.Model(&widgets[0]).Update("tenantId", tenant2).Error; !errors.Is(err, ErrTenantMismatch) {
+		t.Errorf("Expected updating tenant column to other tenant to fail, got %v", err)
+	}
+	widgets[0].Name = "renamed"
+	if err := repository.Update(uow, &widgets[0]); err != nil {
+		t.Errorf("Expected widget to be updated within its tenant, got %v", err)
+	}
+	if err := repository.DeletePermanent(uow, &tenantWidget{}, "1 = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	err = uow.WithoutTenantIsolation("count widgets of all tenants", func(bypassed *UnitOfWork) error {
+		return repository.GetCount(bypassed, &count, &tenantWidget{}, nil)
+	})
+	if err != nil || count != 1 {
+		t.Errorf("Expected widget of tenant2 to remain, got %v, %v", count, err)
+	}
+	if err := repository.GetCount(uow, &count, &tenantWidget{}, nil); err != nil || count != 0 {
+		t.Errorf("Expected unit of work to remain restricted to tenant1 after bypass, got %v, %v", count, err)
+	}
+
+	systemUOW := NewUnitOfWork(db, true, logger, log.Config{})
+	if err := repository.GetAll(systemUOW, &widgets, nil); err == nil || !errors.Is(err.GetCause(), ErrTenantRequired) {
+		t.Errorf("Expected tenant required error, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package filter
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/repository"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// FieldType is the type of a filterable field, the literals are converted to it
+type FieldType int
+
+const (
+	// String field accepts string literals
+	String FieldType = iota
+	// Number field accepts number literals
+	Number
+	// Bool field accepts true or false
+	Bool
+	// DateTime field accepts RFC3339 string literals
+	DateTime
+	// UUID field accepts UUID string literals
+	UUID
+)
+
+var operatorsByType = map[FieldType][]Operator{
+	String:   {Eq, Ne, Gt, Ge, Lt, Le, Like, In, NotIn, Between, IsNull, IsNotNull},
+	Number:   {Eq, Ne, Gt, Ge, Lt, Le, In, NotIn, Between, IsNull, IsNotNull},
+	Bool:     {Eq, Ne, IsNull, IsNotNull},
+	DateTime: {Eq, Ne, Gt, Ge, Lt, Le, Between, IsNull, IsNotNull},
+	UUID:     {Eq, Ne, In, NotIn, IsNull, IsNotNull},
+}
+
+var sqlOperators = map[Operator]string{Eq: "=", Ne: "<>", Gt: ">", Ge: ">=", Lt: "<", Le: "<=", Like: "LIKE", In: "IN", NotIn: "NOT IN"}
+
+// Field is a field allowed in the filter expressions, Operators defaults to all the operators applicable to the Type
+type Field struct {
+	Column    string
+	Type      FieldType
+	Operators []Operator
+}
+
+// Fields is the whitelist of the fields allowed in the filter expressions of an entity, keyed on the name used in the expression
+type Fields map[string]Field
+
+// Compile validates the expression against the fields and returns the parameterized condition for Where
+func (fields Fields) Compile(node Node) (string, []interface{}, error) {
+	switch typedNode := node.(type) {
+	case *Logical:
+		conditions := make([]string, len(typedNode.Operands))
+		args := make([]interface{}, 0)
+		for i, operand := range typedNode.Operands {
+			condition, operandArgs, err := fields.Compile(operand)
+			if err != nil {
+				return "", nil, err
+			}
+			conditions[i] = condition
+			args = append(args, operandArgs...)
+		}
+		return "(" + strings.Join(conditions, fmt.Sprintf(" %v ", typedNode.Operator)) + ")", args, nil
+	case *Comparison:
+		return fields.compileComparison(typedNode)
+	}
+	return "", nil, fmt.Errorf("unknown filter node %T", node)
+}
+
+func (fields Fields) compileComparison(comparison *Comparison) (string, []interface{}, error) {
+	field, ok := fields[comparison.Field]
+	if !ok {
+		return "", nil, microappError.NewInvalidFieldsError(map[string]string{comparison.Field: "Key_InvalidAttribute"})
+	}
+	if !field.allows(comparison.Operator) {
+		return "", nil, microappError.NewInvalidFieldsError(map[string]string{comparison.Field: "Key_InvalidOperator"})
+	}
+
+	args := make([]interface{}, len(comparison.Values))
+	for i, literal := range comparison.Values {
+		value, err := field.convert(literal)
+		if err != nil {
+			return "", nil, microappError.NewInvalidFieldsError(map[string]string{comparison.Field: "Key_InvalidValue"})
+		}
+		args[i] = value
+	}
+
+	switch comparison.Operator {
+	case IsNull:
+		return field.Column + " IS NULL", nil, nil
+	case IsNotNull:
+		return field.Column + " IS NOT NULL", nil, nil
+	case Between:
+		return field.Column + " BETWEEN ? AND ?", args, nil
+	case In, NotIn:
+		return fmt.Sprintf("%v %v ?", field.Column, sqlOperators[comparison.Operator]), []interface{}{args}, nil
+	}
+	return fmt.Sprintf("%v %v ?", field.Column, sqlOperators[comparison.Operator]), args, nil
+}
+
+func (field Field) allows(operator Operator) bool {
+	operators := field.Operators
+	if len(operators) == 0 {
+		operators = operatorsByType[field.Type]
+	}
+	for _, allowed := range operators {
+		if allowed == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func (field Field) convert(literal Literal) (interface{}, error) {
+	switch field.Type {
+	case String:
+		if literal.Kind == StringLiteral {
+			return literal.Text, nil
+		}
+	case Number:
+		if literal.Kind == NumberLiteral {
+			if value, err := strconv.ParseInt(literal.Text, 10, 64); err == nil {
+				return value, nil
+			}
+			return strconv.ParseFloat(literal.Text, 64)
+		}
+	case Bool:
+		if literal.Kind == BoolLiteral {
+			return literal.Text == "true", nil
+		}
+	case DateTime:
+		if literal.Kind == StringLiteral {
+			value, err := time.Parse(time.RFC3339, literal.Text)
+			return value.UTC(), err
+		}
+	case UUID:
+		if literal.Kind == StringLiteral {
+			return uuid.FromString(literal.Text)
+		}
+	}
+	return nil, fmt.Errorf("literal %v is not of field type %v", literal.Text, field.Type)
+}
+
+// Where parses the expression, validates it against the fields and returns the query processor filtering on it
+func Where(expression string, fields Fields) (repository.QueryProcessor, error) {
+	node, err := Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	condition, args, err := fields.Compile(node)
+	if err != nil {
+		return nil, err
+	}
+	return func(db *gorm.DB, out interface{}) (*gorm.DB, microappError.DatabaseError) {
+		return db.Where(condition, args...), nil
+	}, nil
+}
+
+// FromQueryParams returns the query processors for the filter parameters of the URL, multiple filter parameters are combined with AND
+func FromQueryParams(r *http.Request, fields Fields) ([]repository.QueryProcessor, error) {
+	filters := make([]repository.QueryProcessor, 0)
+	for _, expression := range r.URL.Query()["filter"] {
+		if strings.TrimSpace(expression) == "" {
+			continue
+		}
+		filter, err := Where(expression, fields)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
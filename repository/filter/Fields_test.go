@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	microappError "github.com/islax/microapp/error"
+)
+
+var testFields = Fields{
+	"name":      {Column: "name", Type: String},
+	"createdOn": {Column: "createdOn", Type: DateTime},
+	"status":    {Column: "status", Type: String, Operators: []Operator{Eq, In}},
+	"size":      {Column: "size", Type: Number},
+	"deletedOn": {Column: "deletedOn", Type: DateTime},
+	"active":    {Column: "isActive", Type: Bool},
+}
+
+func TestCompile(t *testing.T) {
+	createdOn := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expression string
+		condition  string
+		args       []interface{}
+	}{
+		{"name eq 'it''s'", "name = ?", []interface{}{"it's"}},
+		{"name eq 'x' AND createdOn gt '2021-01-01T00:00:00Z' or status in ('a', 'b')",
+			"((name = ? AND createdOn > ?) OR status IN ?)", []interface{}{"x", createdOn, []interface{}{"a", "b"}}},
+		{"(name like 'x%' or size between 1 and 2.5) and deletedOn is null and active eq true",
+			"((name LIKE ? OR size BETWEEN ? AND ?) AND deletedOn IS NULL AND isActive = ?)", []interface{}{"x%", int64(1), 2.5, true}},
+		{"size not in (-1) and deletedOn is not null", "(size NOT IN ? AND deletedOn IS NOT NULL)", []interface{}{[]interface{}{int64(-1)}}},
+	}
+	for _, test := range tests {
+		node, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("%v: %v", test.expression, err)
+			continue
+		}
+		condition, args, err := testFields.Compile(node)
+		if err != nil || condition != test.condition || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%v: expected %v %v, got %v %v %v", test.expression, test.condition, test.args, condition, args, err)
+		}
+	}
+}
+
+func TestInvalidExpressions(t *testing.T) {
+	tests := map[string]map[string]string{
+		"name eq":                        {"filter": "Key_InvalidSyntax", "filter.position": "7"},
+		"name eq 'x' and":                {"filter": "Key_InvalidSyntax", "filter.position": "15"},
+		"(name eq 'x'":                   {"filter": "Key_InvalidSyntax", "filter.position": "12"},
+		"name eq 'x":                     {"filter": "Key_InvalidSyntax", "filter.position": "8"},
+		"name = 'x'":                     {"filter": "Key_InvalidSyntax", "filter.position": "5"},
+		"password eq 'x'":                {"password": "Key_InvalidAttribute"},
+		"status like 'x'":                {"status": "Key_InvalidOperator"},
+		"size eq 'x'":                    {"size": "Key_InvalidValue"},
+		"createdOn gt '2021-01-01'":      {"createdOn": "Key_InvalidValue"},
+		"name eq 'x'; drop table users":  {"filter": "Key_InvalidSyntax", "filter.position": "11"},
+		"name eq 'x' or (size gt 1) )":   {"filter": "Key_InvalidSyntax", "filter.position": "27"},
+		"name eq 'x' and active eq 'no'": {"active": "Key_InvalidValue"},
+	}
+	for expression, expected := range tests {
+		_, err := Where(expression, testFields)
+		validationError, ok := err.(microappError.ValidationError)
+		if !ok || !reflect.DeepEqual(validationError.Errors, expected) {
+			t.Errorf("%v: expected %v, got %v", expression, expected, err)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	microappError "github.com/islax/microapp/error"
+)
+
+// MaxDepth is the maximum nesting of parentheses allowed in an expression
+const MaxDepth = 16
+
+// Node is a node of the expression AST, it is either *Logical or *Comparison
+type Node interface {
+	node()
+}
+
+// LogicalOperator combines the operands of a Logical node
+type LogicalOperator string
+
+const (
+	// And is true if all the operands are true
+	And LogicalOperator = "AND"
+	// Or is true if any of the operands is true
+	Or LogicalOperator = "OR"
+)
+
+// Logical is an AND / OR of the operands
+type Logical struct {
+	Operator LogicalOperator
+	Operands []Node
+}
+
+// Operator compares the field with the values
+type Operator string
+
+const (
+	// Eq field equals the value
+	Eq Operator = "eq"
+	// Ne field does not equal the value
+	Ne Operator = "ne"
+	// Gt field is greater than the value
+	Gt Operator = "gt"
+	// Ge field is greater than or equal to the value
+	Ge Operator = "ge"
+	// Lt field is less than the value
+	Lt Operator = "lt"
+	// Le field is less than or equal to the value
+	Le Operator = "le"
+	// Like field matches the pattern, % and _ are wildcards
+	Like Operator = "like"
+	// In field is one of the values
+	In Operator = "in"
+	// NotIn field is not one of the values
+	NotIn Operator = "not in"
+	// Between field is between the two values inclusive
+	Between Operator = "between"
+	// IsNull field is null
+	IsNull Operator = "is null"
+	// IsNotNull field is not null
+	IsNotNull Operator = "is not null"
+)
+
+// Comparison compares a field with the values, Position is the offset of the field in the expression
+type Comparison struct {
+	Field    string
+	Operator Operator
+	Values   []Literal
+	Position int
+}
+
+// LiteralKind is the kind of a literal value
+type LiteralKind int
+
+const (
+	// StringLiteral is a single quoted string, quote is escaped by another quote
+	StringLiteral LiteralKind = iota
+	// NumberLiteral is an integer or decimal number
+	NumberLiteral
+	// BoolLiteral is true or false
+	BoolLiteral
+)
+
+// Literal is a value in the expression, Text is the unquoted value
+type Literal struct {
+	Kind LiteralKind
+	Text string
+}
+
+func (*Logical) node()    {}
+func (*Comparison) node() {}
+
+// Parse parses the expression to AST, e.g. "name eq 'x' and (createdOn gt '2021-01-01T00:00:00Z' or status in ('a', 'b'))".
+// Keywords and operators are case insensitive, AND has higher precedence than OR.
+func Parse(expression string) (Node, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.at(tokenEOF) {
+		return nil, p.syntaxError()
+	}
+	return node, nil
+}
+
+// NewSyntaxError returns validation error for the invalid expression at the position
+func NewSyntaxError(position int) error {
+	return microappError.NewValidationError(microappError.ErrorCodeInvalidFields, map[string]string{"filter": "Key_InvalidSyntax", "filter.position": strconv.Itoa(position)})
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOpenParen
+	tokenCloseParen
+	tokenComma
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+func tokenize(expression string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenOpenParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenCloseParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case r == '\'':
+			start := i
+			var text strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, NewSyntaxError(start)
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+				text.WriteRune(runes[i])
+			}
+			tokens = append(tokens, token{tokenString, text.String(), start})
+			i++
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			for i++; i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.'); i++ {
+			}
+			text := string(runes[start:i])
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, NewSyntaxError(start)
+			}
+			tokens = append(tokens, token{tokenNumber, text, start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i++; i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.'); i++ {
+			}
+			tokens = append(tokens, token{tokenIdentifier, string(runes[start:i]), start})
+		default:
+			return nil, NewSyntaxError(i)
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+type parser struct {
+	tokens  []token
+	current int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.current]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.current]
+	if t.kind != tokenEOF {
+		p.current++
+	}
+	return t
+}
+
+func (p *parser) at(kind tokenKind) bool {
+	return p.peek().kind == kind
+}
+
+func (p *parser) atKeyword(keyword string) bool {
+	return p.at(tokenIdentifier) && strings.EqualFold(p.peek().text, keyword)
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.atKeyword(keyword) {
+		return p.syntaxError()
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expect(kind tokenKind) error {
+	if !p.at(kind) {
+		return p.syntaxError()
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) syntaxError() error {
+	return NewSyntaxError(p.peek().position)
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	return p.parseLogical(depth, Or, "or", p.parseAnd)
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	return p.parseLogical(depth, And, "and", p.parseTerm)
+}
+
+func (p *parser) parseLogical(depth int, operator LogicalOperator, keyword string, parseOperand func(int) (Node, error)) (Node, error) {
+	operand, err := parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+	operands := []Node{operand}
+	for p.atKeyword(keyword) {
+		p.next()
+		if operand, err = parseOperand(depth); err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &Logical{Operator: operator, Operands: operands}, nil
+}
+
+func (p *parser) parseTerm(depth int) (Node, error) {
+	if p.at(tokenOpenParen) {
+		if depth >= MaxDepth {
+			return nil, p.syntaxError()
+		}
+		p.next()
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseParen); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	if !p.at(tokenIdentifier) || isKeyword(p.peek().text) {
+		return nil, p.syntaxError()
+	}
+	field := p.next()
+	comparison := &Comparison{Field: field.text, Position: field.position}
+
+	if !p.at(tokenIdentifier) {
+		return nil, p.syntaxError()
+	}
+	operator := strings.ToLower(p.next().text)
+	switch Operator(operator) {
+	case Eq, Ne, Gt, Ge, Lt, Le, Like:
+		comparison.Operator = Operator(operator)
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = []Literal{value}
+	case In:
+		comparison.Operator = In
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = values
+	case Between:
+		comparison.Operator = Between
+		from, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("and"); err != nil {
+			return nil, err
+		}
+		to, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = []Literal{from, to}
+	case "not":
+		if err := p.expectKeyword("in"); err != nil {
+			return nil, err
+		}
+		comparison.Operator = NotIn
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = values
+	case "is":
+		comparison.Operator = IsNull
+		if p.atKeyword("not") {
+			p.next()
+			comparison.Operator = IsNotNull
+		}
+		if err := p.expectKeyword("null"); err != nil {
+			return nil, err
+		}
+	default:
+		p.current--
+		return nil, p.syntaxError()
+	}
+	return comparison, nil
+}
+
+func (p *parser) parseList() ([]Literal, error) {
+	if err := p.expect(tokenOpenParen); err != nil {
+		return nil, err
+	}
+	values := make([]Literal, 0)
+	for {
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if !p.at(tokenComma) {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(tokenCloseParen); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (p *parser) parseLiteral() (Literal, error) {
+	switch t := p.peek(); {
+	case t.kind == tokenString:
+		p.next()
+		return Literal{Kind: StringLiteral, Text: t.text}, nil
+	case t.kind == tokenNumber:
+		p.next()
+		return Literal{Kind: NumberLiteral, Text: t.text}, nil
+	case p.atKeyword("true") || p.atKeyword("false"):
+		p.next()
+		return Literal{Kind: BoolLiteral, Text: strings.ToLower(t.text)}, nil
+	}
+	return Literal{}, p.syntaxError()
+}
+
+func isKeyword(text string) bool {
+	switch strings.ToLower(text) {
+	case "and", "or", "not", "in", "is", "null", "between", "true", "false":
+		return true
+	}
+	return false
+}
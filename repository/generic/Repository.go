@@ -0,0 +1,209 @@
+package generic
+
+import (
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/repository"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Page represents a page of records along with the number of records matching the query
+type Page[T any] struct {
+	Items  []T   `json:"items"`
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// CursorPage represents a page of records read with repository.CursorPaginator, Total is set only if the paginator counts the records
+type CursorPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// Repository is a type-safe layer over repository.GormRepository for the model T, T should be the struct type not a pointer to it.
+// Results are returned typed and errors are repository errors (microappError.DatabaseError).
+type Repository[T any] struct {
+	gormRepository *repository.GormRepository
+}
+
+// NewRepository returns a new repository for the model T
+func NewRepository[T any]() *Repository[T] {
+	return NewRepositoryWith[T](&repository.GormRepository{})
+}
+
+// NewRepositoryWith returns a repository for the model T over the configured repository, e.g. the cached or audited repository of the App
+func NewRepositoryWith[T any](gormRepository *repository.GormRepository) *Repository[T] {
+	return &Repository[T]{gormRepository: gormRepository}
+}
+
+// Get returns the record with the given id
+func (typedRepository *Repository[T]) Get(uow *repository.UnitOfWork, id uuid.UUID, preloadAssociations ...string) (*T, error) {
+	out := new(T)
+	if err := typedRepository.gormRepository.Get(uow, out, id, preloadAssociations); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetForTenant returns the record with the given id of the specified tenant
+func (typedRepository *Repository[T]) GetForTenant(uow *repository.UnitOfWork, id uuid.UUID, tenantID uuid.UUID, preloadAssociations ...string) (*T, error) {
+	out := new(T)
+	if err := typedRepository.gormRepository.GetForTenant(uow, out, id.String(), tenantID, preloadAssociations); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetFirst returns the first record matching the query
+func (typedRepository *Repository[T]) GetFirst(uow *repository.UnitOfWork, queryProcessors ...repository.QueryProcessor) (*T, error) {
+	out := new(T)
+	if err := typedRepository.gormRepository.GetFirst(uow, out, queryProcessors); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// List returns all the records matching the query
+func (typedRepository *Repository[T]) List(uow *repository.UnitOfWork, queryProcessors ...repository.QueryProcessor) ([]T, error) {
+	out := []T{}
+	if err := typedRepository.gormRepository.GetAll(uow, &out, queryProcessors); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListForTenant returns all the records of the specified tenant matching the query
+func (typedRepository *Repository[T]) ListForTenant(uow *repository.UnitOfWork, tenantID uuid.UUID, queryProcessors ...repository.QueryProcessor) ([]T, error) {
+	out := []T{}
+	if err := typedRepository.gormRepository.GetAllForTenant(uow, &out, tenantID, queryProcessors); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Count returns the number of records matching the query
+func (typedRepository *Repository[T]) Count(uow *repository.UnitOfWork, queryProcessors ...repository.QueryProcessor) (int64, error) {
+	var count int64
+	if err := typedRepository.gormRepository.GetCount(uow, &count, new(T), queryProcessors); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountForTenant returns the number of records of the specified tenant matching the query
+func (typedRepository *Repository[T]) CountForTenant(uow *repository.UnitOfWork, tenantID uuid.UUID, queryProcessors ...repository.QueryProcessor) (int64, error) {
+	var count int64
+	if err := typedRepository.gormRepository.GetCountForTenant(uow, &count, tenantID, new(T), queryProcessors); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Page returns the records matching the query within limit and offset along with the number of records matching the query, limit -1 returns all the records
+func (typedRepository *Repository[T]) Page(uow *repository.UnitOfWork, limit int, offset int, queryProcessors ...repository.QueryProcessor) (Page[T], error) {
+	page := Page[T]{Limit: limit, Offset: offset}
+	// Total is counted before the records are limited, so pagination should be the last query processor
+	queryProcessors = append(queryProcessors, repository.Paginate(limit, offset, &page.Total))
+	items, err := typedRepository.List(uow, queryProcessors...)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	page.Items = items
+	return page, nil
+}
+
+// PageForTenant returns a page of the records of the specified tenant, see Page
+func (typedRepository *Repository[T]) PageForTenant(uow *repository.UnitOfWork, tenantID uuid.UUID, limit int, offset int, queryProcessors ...repository.QueryProcessor) (Page[T], error) {
+	queryProcessors = append([]repository.QueryProcessor{repository.FilterColumn("tenantId", tenantID)}, queryProcessors...)
+	return typedRepository.Page(uow, limit, offset, queryProcessors...)
+}
+
+// PageByCursor returns the page of records matching the query at the cursor of the paginator
+func (typedRepository *Repository[T]) PageByCursor(uow *repository.UnitOfWork, paginator *repository.CursorPaginator, queryProcessors ...repository.QueryProcessor) (CursorPage[T], error) {
+	queryProcessors = append(queryProcessors, paginator.Paginate())
+	items, err := typedRepository.List(uow, queryProcessors...)
+	if err != nil {
+		return CursorPage[T]{}, err
+	}
+	if err := paginator.Complete(&items); err != nil {
+		return CursorPage[T]{}, microappError.NewDatabaseError(err)
+	}
+	return CursorPage[T]{Items: items, NextCursor: paginator.NextCursor, PrevCursor: paginator.PrevCursor, Total: paginator.Total}, nil
+}
+
+// PageByCursorForTenant returns the page of records of the specified tenant, see PageByCursor
+func (typedRepository *Repository[T]) PageByCursorForTenant(uow *repository.UnitOfWork, tenantID uuid.UUID, paginator *repository.CursorPaginator, queryProcessors ...repository.QueryProcessor) (CursorPage[T], error) {
+	queryProcessors = append([]repository.QueryProcessor{repository.FilterColumn("tenantId", tenantID)}, queryProcessors...)
+	return typedRepository.PageByCursor(uow, paginator, queryProcessors...)
+}
+
+// Add adds the record
+func (typedRepository *Repository[T]) Add(uow *repository.UnitOfWork, entity *T) error {
+	return typedRepository.gormRepository.Add(uow, entity)
+}
+
+// Update updates the non-zero fields of the record
+func (typedRepository *Repository[T]) Update(uow *repository.UnitOfWork, entity *T) error {
+	return typedRepository.gormRepository.Update(uow, entity)
+}
+
+// Upsert updates the record matching the query, it is added if there is no such record
+func (typedRepository *Repository[T]) Upsert(uow *repository.UnitOfWork, entity *T, queryProcessors ...repository.QueryProcessor) error {
+	return typedRepository.gormRepository.Upsert(uow, entity, queryProcessors)
+}
+
+// AddBatch adds the records in batches, see repository.GormRepository.AddBatch
+func (typedRepository *Repository[T]) AddBatch(uow *repository.UnitOfWork, entities []T, options repository.BatchOptions) (repository.BatchResult, error) {
+	return typedRepository.gormRepository.AddBatch(uow, entities, options)
+}
+
+// UpdateBatch updates the records in batches, see repository.GormRepository.UpdateBatch
+func (typedRepository *Repository[T]) UpdateBatch(uow *repository.UnitOfWork, entities []T, options repository.BatchOptions) (repository.BatchResult, error) {
+	return typedRepository.gormRepository.UpdateBatch(uow, entities, options)
+}
+
+// UpsertBatch adds the records in batches updating the existing ones on conflict, see repository.GormRepository.UpsertBatch
+func (typedRepository *Repository[T]) UpsertBatch(uow *repository.UnitOfWork, entities []T, options repository.BatchOptions) (repository.BatchResult, error) {
+	return typedRepository.gormRepository.UpsertBatch(uow, entities, options)
+}
+
+// CheckVersionAndUpdate updates the record if it is not modified since it is read, T should embed model.Base or model.TenantBase and optionally model.RowVersion
+func (typedRepository *Repository[T]) CheckVersionAndUpdate(uow *repository.UnitOfWork, entity *T, queryProcessors ...repository.QueryProcessor) error {
+	return typedRepository.gormRepository.CheckVersionAndUpdate(uow, entity, queryProcessors)
+}
+
+// Delete deletes the record
+func (typedRepository *Repository[T]) Delete(uow *repository.UnitOfWork, entity *T) error {
+	return typedRepository.gormRepository.Delete(uow, entity)
+}
+
+// DeleteByID deletes the record with the given id
+func (typedRepository *Repository[T]) DeleteByID(uow *repository.UnitOfWork, id uuid.UUID) error {
+	return typedRepository.gormRepository.Delete(uow, new(T), "id = ?", id)
+}
+
+// DeleteForTenant deletes all the records of the specified tenant
+func (typedRepository *Repository[T]) DeleteForTenant(uow *repository.UnitOfWork, tenantID uuid.UUID) error {
+	return typedRepository.gormRepository.DeleteForTenant(uow, new(T), tenantID)
+}
+
+// DeletePermanent deletes the records matching the conditions permanently
+func (typedRepository *Repository[T]) DeletePermanent(uow *repository.UnitOfWork, where ...interface{}) error {
+	return typedRepository.gormRepository.DeletePermanent(uow, new(T), where...)
+}
+
+// RestoreByID undeletes the soft deleted record with the given id
+func (typedRepository *Repository[T]) RestoreByID(uow *repository.UnitOfWork, id uuid.UUID) error {
+	return typedRepository.gormRepository.Restore(uow, new(T), "id = ?", id)
+}
+
+// ListDeleted returns the soft deleted records matching the query
+func (typedRepository *Repository[T]) ListDeleted(uow *repository.UnitOfWork, queryProcessors ...repository.QueryProcessor) ([]T, error) {
+	out := []T{}
+	if err := typedRepository.gormRepository.ListDeleted(uow, &out, queryProcessors); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,326 @@
+package generic
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/cache"
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/log"
+	"github.com/islax/microapp/model"
+	"github.com/islax/microapp/repository"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	model.Base
+	TenantID uuid.UUID `gorm:"column:tenantId;type:varchar(36)"`
+	Name     string
+}
+
+func TestRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(os.Stdout)
+	widgets := NewRepository[widget]()
+
+	tenantID := uuid.NewV4()
+	uow := repository.NewUnitOfWork(db, false, logger, log.Config{})
+	for _, name := range []string{"a", "b", "c"} {
+		if err := widgets.Add(uow, &widget{Base: model.Base{ID: uuid.NewV4()}, TenantID: tenantID, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := widgets.Add(uow, &widget{Base: model.Base{ID: uuid.NewV4()}, TenantID: uuid.NewV4(), Name: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+
+	uow = repository.NewUnitOfWork(db, true, logger, log.Config{})
+	page, err := widgets.PageForTenant(uow, tenantID, 2, 1, repository.Order("name", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || len(page.Items) != 2 || page.Items[0].Name != "b" {
+		t.Errorf("Unexpected page %+v", page)
+	}
+
+	found, err := widgets.Get(uow, page.Items[0].ID)
+	if err != nil || found.Name != "b" {
+		t.Errorf("Expected widget b, got %v, %v", found, err)
+	}
+
+	if _, err := widgets.Get(uow, uuid.NewV4()); err == nil || !err.(interface{ IsRecordNotFoundError() bool }).IsRecordNotFoundError() {
+		t.Errorf("Expected record not found error, got %v", err)
+	}
+}
+
+func TestPageByCursor(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(os.Stdout)
+	widgets := NewRepository[widget]()
+
+	uow := repository.NewUnitOfWork(db, false, logger, log.Config{})
+	for _, name := range []string{"a", "c", "b", "a", "b"} {
+		if err := widgets.Add(uow, &widget{Base: model.Base{ID: uuid.NewV4()}, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uow.Commit()
+
+	sortColumns, err := repository.GetSortColumns([]string{"name,desc"}, []string{"name"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readPage := func(cursor string) CursorPage[widget] {
+		paginator, err := repository.NewCursorPaginator(2, sortColumns, cursor, cursor == "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err := widgets.PageByCursor(repository.NewUnitOfWork(db, true, logger, log.Config{}), paginator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	forward := []widget{}
+	pages := []CursorPage[widget]{readPage("")}
+	for pages[len(pages)-1].NextCursor != "" {
+		pages = append(pages, readPage(pages[len(pages)-1].NextCursor))
+	}
+	for _, page := range pages {
+		forward = append(forward, page.Items...)
+	}
+	if len(pages) != 3 || *pages[0].Total != 5 || pages[0].PrevCursor != "" || len(forward) != 5 {
+		t.Fatalf("Unexpected pages %+v", pages)
+	}
+	for i, name := range []string{"c", "b", "b", "a", "a"} {
+		if forward[i].Name != name {
+			t.Errorf("Expected %v at %v, got %v", name, i, forward[i].Name)
+		}
+	}
+
+	previous := readPage(pages[2].PrevCursor)
+	if previous.Items[0].ID != pages[1].Items[0].ID || previous.Items[1].ID != pages[1].Items[1].ID || previous.NextCursor == "" || previous.PrevCursor == "" {
+		t.Errorf("Expected previous page to be %+v, got %+v", pages[1], previous)
+	}
+	if first := readPage(previous.PrevCursor); first.PrevCursor != "" || first.Items[0].ID != pages[0].Items[0].ID {
+		t.Errorf("Expected first page to be %+v, got %+v", pages[0], first)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(os.Stdout)
+	widgets := NewRepository[widget]()
+
+	duplicateID := uuid.NewV4()
+	entities := []widget{{Base: model.Base{ID: uuid.NewV4()}}, {Base: model.Base{ID: duplicateID}}, {Base: model.Base{ID: duplicateID}}, {Base: model.Base{ID: uuid.NewV4()}}, {Base: model.Base{ID: uuid.NewV4()}}}
+	uow := repository.NewUnitOfWork(db, false, logger, log.Config{})
+	result, err := widgets.AddBatch(uow, entities, repository.BatchOptions{BatchSize: 2})
+	uow.Commit()
+	if err == nil || result.Succeeded != 3 || len(result.Errors) != 1 || result.Errors[0].Offset != 2 || result.Errors[0].Count != 2 {
+		t.Errorf("Expected second batch to fail, got %+v, %v", result, err)
+	}
+
+	for i := range entities {
+		entities[i].Name = "upserted"
+	}
+	uow = repository.NewUnitOfWork(db, false, logger, log.Config{})
+	if result, err := widgets.UpsertBatch(uow, entities[:3], repository.BatchOptions{BatchSize: 2}); err != nil || result.Succeeded != 3 {
+		t.Errorf("Expected upsert to succeed, got %+v, %v", result, err)
+	}
+	uow.Commit()
+
+	uow = repository.NewUnitOfWork(db, true, logger, log.Config{})
+	if count, err := widgets.Count(uow, repository.Filter("name = ?", "upserted")); err != nil || count != 2 {
+		t.Errorf("Expected 2 upserted widgets, got %v, %v", count, err)
+	}
+	if count, err := widgets.Count(uow); err != nil || count != 3 {
+		t.Errorf("Expected 3 widgets, got %v, %v", count, err)
+	}
+}
+
+type versionedWidget struct {
+	model.Base
+	model.RowVersion
+	Name string
+}
+
+func TestCheckVersionAndUpdate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&versionedWidget{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(os.Stdout)
+	widgets := NewRepository[versionedWidget]()
+
+	uow := repository.NewUnitOfWork(db, false, logger, log.Config{})
+	defer uow.Complete()
+	id := uuid.NewV4()
+	if err := widgets.Add(uow, &versionedWidget{Base: model.Base{ID: id}, Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := widgets.Get(uow, id)
+	second, _ := widgets.Get(uow, id)
+	first.Name = "b"
+	if err := widgets.CheckVersionAndUpdate(uow, first); err != nil || first.Version != 1 {
+		t.Fatalf("Expected update to version 1, got %v, %v", first.Version, err)
+	}
+	second.Name = "c"
+	if err := widgets.CheckVersionAndUpdate(uow, second); !microappError.IsConflictError(err) || second.Version != 0 {
+		t.Errorf("Expected conflict error with version unchanged, got %v, %v", second.Version, err)
+	}
+	if found, _ := widgets.Get(uow, id); found.Name != "b" || found.Version != 1 {
+		t.Errorf("Expected widget b of version 1, got %+v", found)
+	}
+}
+
+func TestRowVersion(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&versionedWidget{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(repository.NewRowVersionPlugin()); err != nil {
+		t.Fatal(err)
+	}
+	widgets := NewRepository[versionedWidget]()
+	uow := repository.NewUnitOfWork(db, false, zerolog.New(os.Stdout), log.Config{})
+	defer uow.Complete()
+
+	id := uuid.NewV4()
+	if err := widgets.Add(uow, &versionedWidget{Base: model.Base{ID: id}, Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	stale, _ := widgets.Get(uow, id)
+
+	if err := widgets.Update(uow, &versionedWidget{Base: model.Base{ID: id}, Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := widgets.Get(uow, id); found.Name != "b" || found.Version != 1 {
+		t.Errorf("Expected widget b of version 1 after update, got %+v", found)
+	}
+	if _, err := widgets.UpdateBatch(uow, []versionedWidget{{Base: model.Base{ID: id}, Name: "c"}}, repository.BatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := widgets.UpsertBatch(uow, []versionedWidget{{Base: model.Base{ID: id}, Name: "d"}}, repository.BatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := widgets.UpsertBatch(uow, []versionedWidget{{Base: model.Base{ID: id}, Name: "e"}}, repository.BatchOptions{UpdateColumns: []string{"name", "version"}}); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := widgets.Get(uow, id); found.Name != "e" || found.Version != 4 {
+		t.Errorf("Expected widget e of version 4 after batch update and upserts, got %+v", found)
+	}
+
+	stale.Name = "f"
+	if err := widgets.CheckVersionAndUpdate(uow, stale); !microappError.IsConflictError(err) {
+		t.Errorf("Expected conflict error on updating stale widget, got %v", err)
+	}
+	current, _ := widgets.Get(uow, id)
+	current.Name = "f"
+	if err := widgets.CheckVersionAndUpdate(uow, current); err != nil || current.Version != 5 {
+		t.Errorf("Expected update to version 5, got %v, %v", current.Version, err)
+	}
+	if found, _ := widgets.Get(uow, id); found.Name != "f" || found.Version != 5 {
+		t.Errorf("Expected widget f of version 5, got %+v", found)
+	}
+}
+
+type softDeletableWidget struct {
+	model.TenantBase
+	Name string
+}
+
+func TestRestore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&softDeletableWidget{}); err != nil {
+		t.Fatal(err)
+	}
+	widgets := NewRepository[softDeletableWidget]()
+	uow := repository.NewUnitOfWork(db, false, zerolog.New(os.Stdout), log.Config{})
+	defer uow.Complete()
+
+	id := uuid.NewV4()
+	if err := widgets.Add(uow, &softDeletableWidget{TenantBase: model.TenantBase{ID: id}, Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := widgets.DeleteByID(uow, id); err != nil {
+		t.Fatal(err)
+	}
+	if deleted, err := widgets.ListDeleted(uow); err != nil || len(deleted) != 1 || deleted[0].ID != id {
+		t.Fatalf("Expected deleted widget, got %v, %v", deleted, err)
+	}
+
+	if err := widgets.RestoreByID(uow, id); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := widgets.Get(uow, id); err != nil || found.Name != "a" {
+		t.Errorf("Expected restored widget, got %v, %v", found, err)
+	}
+	if deleted, _ := widgets.ListDeleted(uow); len(deleted) != 0 {
+		t.Errorf("Expected no deleted widget, got %v", deleted)
+	}
+	if err := widgets.RestoreByID(uow, id); err == nil || !err.(interface{ IsRecordNotFoundError() bool }).IsRecordNotFoundError() {
+		t.Errorf("Expected record not found on restoring live widget, got %v", err)
+	}
+}
+
+func TestCachedRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(os.Stdout)
+	widgets := NewRepositoryWith[widget](repository.NewCachedRepository(cache.NewClient(cache.NewLRU(10), "test", time.Minute)))
+
+	id := uuid.NewV4()
+	uow := repository.NewUnitOfWork(db, false, logger, log.Config{})
+	if err := widgets.Add(uow, &widget{Base: model.Base{ID: id}, Name: "cached"}); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+
+	if found, err := widgets.Get(repository.NewUnitOfWork(db, true, logger, log.Config{}), id); err != nil || found.Name != "cached" {
+		t.Fatalf("Expected widget, got %v, %v", found, err)
+	}
+	db.Exec("UPDATE widgets SET name = 'changed outside'")
+	if found, err := widgets.Get(repository.NewUnitOfWork(db, true, logger, log.Config{}), id); err != nil || found.Name != "cached" {
+		t.Errorf("Expected cached widget, got %v, %v", found, err)
+	}
+}
@@ -0,0 +1,188 @@
+package retention
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize is the number of records purged in one statement if not configured
+const DefaultBatchSize = 500
+
+// Policy is the retention of the soft deleted records of a table
+type Policy struct {
+	Table            string        // Table of the soft deletable entity
+	Age              time.Duration // Records soft deleted before the age are purged
+	DeletedAtColumn  string        // Defaults to deletedOn
+	PrimaryKeyColumn string        // Defaults to id
+}
+
+// Config represents the configuration for retention worker
+type Config struct {
+	Interval  time.Duration // Interval at which the records are purged, defaults to an hour
+	BatchSize int           // Maximum number of records deleted in one statement
+	DryRun    bool          // Only reports the records eligible to purge without deleting them
+}
+
+// Report is the outcome of a retention run for a policy
+type Report struct {
+	Table    string
+	Eligible int64 // Number of records eligible to purge, reported on dry run
+	Purged   int64
+	DryRun   bool
+	Err      error
+}
+
+var (
+	purgedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "microapp_retention_purged_total",
+		Help: "The total number of soft deleted records purged.",
+	}, []string{"table"})
+	eligibleGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "microapp_retention_eligible",
+		Help: "The number of soft deleted records eligible to purge in the last dry run.",
+	}, []string{"table"})
+	failureCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "microapp_retention_failures_total",
+		Help: "The total number of failed retention runs.",
+	}, []string{"table"})
+	registerMetricsOnce sync.Once
+)
+
+// Worker periodically hard deletes the records soft deleted before the age of their policy.
+// The records are deleted in batches across the tenants, tenant isolation does not apply as the tables are accessed without model.
+type Worker struct {
+	db       *gorm.DB
+	policies []Policy
+	config   Config
+	logger   zerolog.Logger
+	stop     chan struct{}
+	stopOnce sync.Once
+	started  bool
+	done     chan struct{}
+}
+
+// NewWorker creates a retention worker for the policies
+func NewWorker(db *gorm.DB, policies []Policy, config Config, logger zerolog.Logger) *Worker {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	for i := range policies {
+		if policies[i].DeletedAtColumn == "" {
+			policies[i].DeletedAtColumn = "deletedOn"
+		}
+		if policies[i].PrimaryKeyColumn == "" {
+			policies[i].PrimaryKeyColumn = "id"
+		}
+	}
+
+	registerMetricsOnce.Do(func() {
+		_ = prometheus.Register(purgedCounter)
+		_ = prometheus.Register(eligibleGauge)
+		_ = prometheus.Register(failureCounter)
+	})
+
+	return &Worker{
+		db:       db.Session(&gorm.Session{NewDB: true}),
+		policies: policies,
+		config:   config,
+		logger:   logger.With().Str("module", "RetentionWorker").Logger(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start starts purging in background
+func (worker *Worker) Start() {
+	worker.started = true
+	go func() {
+		defer close(worker.done)
+		ticker := time.NewTicker(worker.config.Interval)
+		defer ticker.Stop()
+		for {
+			worker.Run(context.Background())
+			select {
+			case <-worker.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops purging, waits for the batch in progress
+func (worker *Worker) Stop() {
+	worker.stopOnce.Do(func() {
+		close(worker.stop)
+		if worker.started {
+			<-worker.done
+		}
+	})
+}
+
+// Run applies all the policies once and returns their reports
+func (worker *Worker) Run(ctx context.Context) []Report {
+	reports := make([]Report, 0, len(worker.policies))
+	for _, policy := range worker.policies {
+		report := worker.apply(ctx, policy)
+		logEvent := worker.logger.Info()
+		if report.Err != nil {
+			failureCounter.WithLabelValues(policy.Table).Inc()
+			logEvent = worker.logger.Error().Err(report.Err)
+		}
+		if report.DryRun {
+			eligibleGauge.WithLabelValues(policy.Table).Set(float64(report.Eligible))
+			logEvent.Str("table", policy.Table).Int64("eligible", report.Eligible).Msg("Retention dry run, soft deleted records are not purged.")
+		} else if report.Purged > 0 || report.Err != nil {
+			logEvent.Str("table", policy.Table).Int64("purged", report.Purged).Msg("Purged soft deleted records.")
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func (worker *Worker) apply(ctx context.Context, policy Policy) Report {
+	report := Report{Table: policy.Table, DryRun: worker.config.DryRun}
+	cutoff := time.Now().Add(-policy.Age)
+	expired := func() *gorm.DB {
+		return worker.db.WithContext(ctx).Table(policy.Table).Where(clause.Lt{Column: clause.Column{Name: policy.DeletedAtColumn}, Value: cutoff})
+	}
+
+	if worker.config.DryRun {
+		report.Err = expired().Count(&report.Eligible).Error
+		return report
+	}
+
+	for {
+		ids := make([]string, 0, worker.config.BatchSize)
+		if report.Err = expired().Limit(worker.config.BatchSize).Pluck(policy.PrimaryKeyColumn, &ids).Error; report.Err != nil || len(ids) == 0 {
+			return report
+		}
+		result := worker.db.WithContext(ctx).Exec("DELETE FROM ? WHERE ? IN ?", clause.Table{Name: policy.Table}, clause.Column{Name: policy.PrimaryKeyColumn}, ids)
+		if report.Err = result.Error; report.Err != nil {
+			return report
+		}
+		report.Purged += result.RowsAffected
+		purgedCounter.WithLabelValues(policy.Table).Add(float64(result.RowsAffected))
+
+		if len(ids) < worker.config.BatchSize {
+			return report
+		}
+		select {
+		case <-worker.stop:
+			return report
+		case <-ctx.Done():
+			report.Err = ctx.Err()
+			return report
+		default:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package retention
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/model"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	model.TenantBase
+	Name string
+}
+
+func TestWorker(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, deletedOn := range []time.Time{{}, time.Now(), time.Now().Add(-48 * time.Hour), time.Now().Add(-72 * time.Hour), time.Now().Add(-96 * time.Hour)} {
+		entity := &widget{TenantBase: model.TenantBase{ID: uuid.NewV4(), TenantID: uuid.NewV4()}}
+		if !deletedOn.IsZero() {
+			entity.DeletedAt = gorm.DeletedAt{Time: deletedOn, Valid: true}
+		}
+		if err := db.Create(entity).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	policies := []Policy{{Table: "widgets", Age: 24 * time.Hour}}
+	logger := zerolog.New(os.Stdout)
+
+	reports := NewWorker(db, policies, Config{DryRun: true}, logger).Run(context.Background())
+	if len(reports) != 1 || reports[0].Eligible != 3 || reports[0].Purged != 0 || reports[0].Err != nil {
+		t.Fatalf("Expected 3 eligible records on dry run, got %+v", reports)
+	}
+
+	reports = NewWorker(db, policies, Config{BatchSize: 2}, logger).Run(context.Background())
+	if reports[0].Purged != 3 || reports[0].Err != nil {
+		t.Fatalf("Expected 3 records purged, got %+v", reports)
+	}
+	var remaining int64
+	db.Unscoped().Model(&widget{}).Count(&remaining)
+	if remaining != 2 {
+		t.Errorf("Expected live and recently deleted records to remain, got %v", remaining)
+	}
+}
@@ -1,6 +1,10 @@
 package retry
 
-import "time"
+import (
+	"context"
+	"math/rand"
+	"time"
+)
 
 // Do Performs repeated calls with a time delay for specific number of attempts
 // or till the function returns no error
@@ -30,3 +34,35 @@ type Stop struct {
 func (stop Stop) Error() string {
 	return stop.OriginalError.Error()
 }
+
+// DoWithJitter performs repeated calls like Do, the delay before each retry is randomized between half and full of the exponentially growing delay
+// so that the callers failed together (e.g. deadlocked transactions) do not retry in lockstep
+func DoWithJitter(attempts int, sleep time.Duration, fn func() error) error {
+	return DoWithJitterContext(context.Background(), attempts, sleep, fn)
+}
+
+// DoWithJitterContext performs repeated calls like DoWithJitter, the wait before retry ends with ctx.Err() if the context is done
+func DoWithJitterContext(ctx context.Context, attempts int, sleep time.Duration, fn func() error) error {
+	if err := fn(); err != nil {
+		if s, ok := err.(Stop); ok {
+			return s.OriginalError
+		}
+
+		if attempts--; attempts > 0 {
+			jittered := sleep / 2
+			if sleep > 1 {
+				jittered += time.Duration(rand.Int63n(int64(sleep - sleep/2)))
+			}
+			timer := time.NewTimer(jittered)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			return DoWithJitterContext(ctx, attempts, 2*sleep, fn)
+		}
+		return err
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/islax/microapp"
 	"github.com/islax/microapp/config"
+	microappError "github.com/islax/microapp/error"
 	microappLog "github.com/islax/microapp/log"
 	microappRepo "github.com/islax/microapp/repository"
 	microappSecurity "github.com/islax/microapp/security"
@@ -46,7 +47,7 @@ func (controller *SettingsMetadataMigrationController) RegisterRoutes(muxRouter
 }
 
 func (controller *SettingsMetadataMigrationController) migratetenants(w http.ResponseWriter, r *http.Request, token *microappSecurity.JwtToken) {
-	context := controller.app.NewExecutionContext(token, microapp.GetCorrelationIDFromRequest(r), "tenantsettings.migrate", true, false)
+	context := controller.app.NewExecutionContextFromRequest(r, token, "tenantsettings.migrate", true, false)
 	uow := context.GetUOW()
 	defer uow.Complete()
 
@@ -65,6 +66,8 @@ func (controller *SettingsMetadataMigrationController) migratetenants(w http.Res
 
 	successTenants := make([]string, 0)
 	failureTenants := make([]string, 0)
+	tenants := make([]*tenantModel.TenantSettings, 0)
+	tenantIDs := make([]string, 0)
 	for _, tenantMap := range tenantSettings {
 		tenantIDStr := tenantMap["id"].(string)
 		tenantID, err := uuid.FromString(tenantIDStr)
@@ -83,21 +86,52 @@ func (controller *SettingsMetadataMigrationController) migratetenants(w http.Res
 				continue
 			}
 			if tenant.Settings != "{}" {
-				if err := controller.repository.Add(uow, tenant); err != nil {
-					context.LogError(err, "Unable to add tenant settings.")
-					failureTenants = append(failureTenants, tenantIDStr)
-					continue
-				}
+				tenants = append(tenants, tenant)
+				tenantIDs = append(tenantIDs, tenantIDStr)
+				continue
 			}
 		}
 		successTenants = append(successTenants, tenantIDStr)
 	}
-	uow.Commit()
+
+	result, err := controller.repository.AddBatch(uow, tenants, microappRepo.BatchOptions{})
+	if err != nil {
+		context.LogError(err, "Unable to add tenant settings in batch, retrying the tenants of the failed batches one by one.")
+	}
+	failedTenants := make(map[int]bool)
+	for _, batchError := range result.Errors {
+		// A batch fails as a whole, so its tenants are added one by one to fail only the tenants which cannot be added
+		retryResult, err := controller.repository.AddBatch(uow, tenants[batchError.Offset:batchError.Offset+batchError.Count], microappRepo.BatchOptions{BatchSize: 1})
+		if err != nil && len(retryResult.Errors) == 0 {
+			// Retry failed as a whole, so none of the tenants of the batch are added
+			context.LogError(err, "Unable to add tenant settings.")
+			for i := batchError.Offset; i < batchError.Offset+batchError.Count; i++ {
+				failedTenants[i] = true
+			}
+			continue
+		}
+		for _, retryError := range retryResult.Errors {
+			context.LogError(retryError.Err, "Unable to add tenant settings.")
+			failedTenants[batchError.Offset+retryError.Offset] = true
+		}
+	}
+	for i, tenantIDStr := range tenantIDs {
+		if failedTenants[i] {
+			failureTenants = append(failureTenants, tenantIDStr)
+		} else {
+			successTenants = append(successTenants, tenantIDStr)
+		}
+	}
+	if err := uow.TryCommit(); err != nil {
+		context.LogError(err, "Unable to commit tenant settings.")
+		microappWeb.RespondError(w, microappError.NewDatabaseError(err))
+		return
+	}
 	microappWeb.RespondJSON(w, http.StatusOK, map[string]interface{}{"successTenants": successTenants, "failureTenants": failureTenants})
 }
 
 func (controller *SettingsMetadataMigrationController) migratetenant(w http.ResponseWriter, r *http.Request, token *microappSecurity.JwtToken) {
-	context := controller.app.NewExecutionContext(token, microapp.GetCorrelationIDFromRequest(r), "tenantsettings.migrate", true, false)
+	context := controller.app.NewExecutionContextFromRequest(r, token, "tenantsettings.migrate", true, false)
 	uow := context.GetUOW()
 	defer uow.Complete()
 
@@ -137,7 +171,11 @@ func (controller *SettingsMetadataMigrationController) migratetenant(w http.Resp
 			return
 		}
 	}
-	uow.Commit()
+	if err := uow.TryCommit(); err != nil {
+		context.LogError(err, "Unable to commit tenant settings.")
+		microappWeb.RespondError(w, microappError.NewDatabaseError(err))
+		return
+	}
 	context.LoggerEventActionCompletion().Str("TenantId", stringTenantID).Msg("Tenant settings migrated")
 	microappWeb.RespondJSON(w, http.StatusOK, "")
 }
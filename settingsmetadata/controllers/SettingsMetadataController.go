@@ -12,6 +12,7 @@ import (
 	"github.com/islax/microapp"
 	"github.com/islax/microapp/config"
 	microappCtx "github.com/islax/microapp/context"
+	microappError "github.com/islax/microapp/error"
 	microappLog "github.com/islax/microapp/log"
 	"github.com/islax/microapp/repository"
 	microappRepo "github.com/islax/microapp/repository"
@@ -55,7 +56,7 @@ func (controller *SettingsMetadataController) RegisterRoutes(muxRouter *mux.Rout
 }
 
 func (controller *SettingsMetadataController) getSettingsMetadata(w http.ResponseWriter, r *http.Request, token *microappSecurity.JwtToken) {
-	context := controller.app.NewExecutionContext(token, microapp.GetCorrelationIDFromRequest(r), "settingsmetadata.get", true, true)
+	context := controller.app.NewExecutionContextFromRequest(r, token, "settingsmetadata.get", true, true)
 	uow := context.GetUOW()
 	defer uow.Complete()
 	if err := controller.checkAndInitializeSettingsMetadata(); err != nil {
@@ -82,7 +83,7 @@ func (controller *SettingsMetadataController) getSettingsMetadata(w http.Respons
 }
 
 func (controller *SettingsMetadataController) get(w http.ResponseWriter, r *http.Request, token *microappSecurity.JwtToken) {
-	context := controller.app.NewExecutionContext(token, microapp.GetCorrelationIDFromRequest(r), "tenantsettings.get", true, true)
+	context := controller.app.NewExecutionContextFromRequest(r, token, "tenantsettings.get", true, true)
 	uow := context.GetUOW()
 	defer uow.Complete()
 	params := mux.Vars(r)
@@ -130,7 +131,7 @@ func (controller *SettingsMetadataController) get(w http.ResponseWriter, r *http
 }
 
 func (controller *SettingsMetadataController) update(w http.ResponseWriter, r *http.Request, token *microappSecurity.JwtToken) {
-	context := controller.app.NewExecutionContext(token, microapp.GetCorrelationIDFromRequest(r), "tenantsettings.update", true, false)
+	context := controller.app.NewExecutionContextFromRequest(r, token, "tenantsettings.update", true, false)
 	uow := context.GetUOW()
 	defer uow.Complete()
 	params := mux.Vars(r)
@@ -167,8 +168,8 @@ func (controller *SettingsMetadataController) update(w http.ResponseWriter, r *h
 	}
 
 	if tenant.Settings != "{}" {
-		queryProcessor := []repository.QueryProcessor{repository.Filter("id = ?", tenantID)}
-		err = controller.repository.Upsert(uow, &tenant, queryProcessor)
+		// Inserts or updates in one statement (ON CONFLICT) so that concurrent updates of a tenant without settings do not race
+		_, err = controller.repository.UpsertBatch(uow, []*tenantModel.TenantSettings{tenant}, repository.BatchOptions{})
 		if err != nil {
 			context.LogError(err, microappLog.MessageUpdateEntityError)
 			microappWeb.RespondError(w, err)
@@ -183,7 +184,6 @@ func (controller *SettingsMetadataController) update(w http.ResponseWriter, r *h
 		}
 	}
 
-	uow.Commit()
 	responseDTO := toDTO(tenant)
 
 	err = tenant.GetTenantSettings(controller.settingsMetadatas, map[string]interface{}{})
@@ -193,13 +193,23 @@ func (controller *SettingsMetadataController) update(w http.ResponseWriter, r *h
 		return
 	}
 
+	if err = controller.app.DispatchEventWithUOW(uow, token.Raw, context.GetCorrelationID(), strings.ToLower(strings.ReplaceAll(controller.app.Name, " ", ""))+".settingsupdated", toDTO(tenant)); err != nil {
+		context.LogError(err, fmt.Sprintf(microappLog.MessageGenericErrorTemplate, "dispatching settings updated event"))
+		microappWeb.RespondError(w, err)
+		return
+	}
+
+	if err = uow.TryCommit(); err != nil {
+		context.LogError(err, fmt.Sprintf(microappLog.MessageGenericErrorTemplate, "committing tenant settings"))
+		microappWeb.RespondError(w, microappError.NewDatabaseError(err))
+		return
+	}
 	context.LoggerEventActionCompletion().Str("TenantId", responseDTO.ID.String()).Msg("Tenant settings updated")
-	controller.app.DispatchEvent(token.Raw, context.GetCorrelationID(), strings.ToLower(strings.ReplaceAll(controller.app.Name, " ", ""))+".settingsupdated", toDTO(tenant))
 	microappWeb.RespondJSON(w, http.StatusOK, nil)
 }
 
 func (controller *SettingsMetadataController) getByName(w http.ResponseWriter, r *http.Request, token *microappSecurity.JwtToken) {
-	context := controller.app.NewExecutionContext(token, microapp.GetCorrelationIDFromRequest(r), "tenantsettings.get", true, true)
+	context := controller.app.NewExecutionContextFromRequest(r, token, "tenantsettings.get", true, true)
 	uow := context.GetUOW()
 	defer uow.Complete()
 
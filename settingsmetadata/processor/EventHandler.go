@@ -9,89 +9,92 @@ import (
 	"github.com/islax/microapp"
 	"github.com/islax/microapp/config"
 	microappCtx "github.com/islax/microapp/context"
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/event/handler"
 	"github.com/islax/microapp/event/monitor"
 	microappLog "github.com/islax/microapp/log"
 	microappRepo "github.com/islax/microapp/repository"
-	microappSecurity "github.com/islax/microapp/security"
 	tenantModel "github.com/islax/microapp/settingsmetadata/model"
 	uuid "github.com/satori/go.uuid"
 )
 
+// TenantEvent represents the payload of tenant.added and tenant.deleted events
+type TenantEvent struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"displayName"`
+}
+
+// Validate validates the tenant event
+func (tenantEvent *TenantEvent) Validate() error {
+	if tenantEvent.ID == uuid.Nil {
+		return microappError.NewValidationError(microappLog.EventCodeInvalidData, map[string]string{"id": "Key_Required"})
+	}
+	return nil
+}
+
 //EventHandler handles events
 type EventHandler struct {
 	app               *microapp.App
 	repository        microappRepo.Repository
 	eventChannel      chan *monitor.EventInfo
+	router            *handler.Router
 	settingsMetadatas []tenantModel.SettingsMetaData
 }
 
 // NewEventHandler creates new instance of TenantActionEventHandler
 func NewEventHandler(app *microapp.App, repository microappRepo.Repository, eventChannel chan *monitor.EventInfo) *EventHandler {
-	return &EventHandler{app: app, repository: repository, eventChannel: eventChannel}
+	eventHandler := &EventHandler{app: app, repository: repository, eventChannel: eventChannel, router: app.NewEventRouter()}
+	if err := eventHandler.router.Handle("tenant.added", "tenantsettings.add", eventHandler.processTenantAdd); err != nil {
+		app.Logger("TenantSettingsEventHandler").Error().Err(err).Msg("Unable to register the tenant added handler.")
+	}
+	if err := eventHandler.router.Handle("tenant.deleted", "tenantsettings.delete", eventHandler.processTenantDelete); err != nil {
+		app.Logger("TenantSettingsEventHandler").Error().Err(err).Msg("Unable to register the tenant deleted handler.")
+	}
+	return eventHandler
 }
 
 // Start will start listening to channel for events
 func (handler *EventHandler) Start() {
-	for eventPayload := range handler.eventChannel {
-		switch eventPayload.Name {
-		case "tenant.added":
-			handler.processTenantAdd(eventPayload)
-		case "tenant.deleted":
-			handler.processTenantDelete(eventPayload)
-		}
-	}
+	handler.router.Start(handler.eventChannel)
 }
 
-func (handler *EventHandler) processTenantAdd(eventPayload *monitor.EventInfo) {
-	token, _ := microappSecurity.GetTokenFromRawAuthHeader(handler.app.Config, eventPayload.RawToken)
-	context := handler.app.NewExecutionContext(token, eventPayload.CorelationID, "tenantsettings.add", true, false)
-	uow := context.GetUOW()
-	defer uow.Complete()
-	handler.createTenantSettingsMetadata(uow, context, eventPayload)
-	uow.Commit()
-	context.Logger(microappLog.EventTypeSuccess, microappLog.EventCodeActionComplete).Info().Msg("Finished adding new tenant settings")
-}
-
-func (handler *EventHandler) createTenantSettingsMetadata(uow *microappRepo.UnitOfWork, context microappCtx.ExecutionContext, eventPayload *monitor.EventInfo) {
-	eventData := make(map[string]interface{})
-	var tenantID uuid.UUID
-
+func (handler *EventHandler) processTenantAdd(context microappCtx.ExecutionContext, tenantEvent *TenantEvent) monitor.Result {
 	if err := handler.checkAndInitializeSettingsMetadata(); err != nil {
 		context.LogError(err, fmt.Sprintf(microappLog.MessageGenericErrorTemplate, "initializing settings-metadata"))
-		return
+		return monitor.Requeue(err, 0)
 	}
-	json.Unmarshal([]byte(eventPayload.Payload), &eventData)
-	tenantID, _ = uuid.FromString(eventData["id"].(string))
-	tenantDisplayName := eventData["displayName"].(string)
 
-	tenant, err := tenantModel.NewTenant(context, tenantID, map[string]interface{}{"displayName": tenantDisplayName}, handler.settingsMetadatas)
+	tenant, err := tenantModel.NewTenant(context, tenantEvent.ID, map[string]interface{}{"displayName": tenantEvent.DisplayName}, handler.settingsMetadatas)
 	if err != nil {
 		context.LogError(err, "Unable to add new tenant.")
-		return
+		return monitor.Nack(err)
 	}
+	uow := context.GetUOW()
 	if err := handler.repository.Add(uow, tenant); err != nil {
 		context.LogError(err, "Unable to add tenant settings.")
-		return
+		return monitor.Requeue(err, 0)
 	}
+	if err := uow.TryCommit(); err != nil {
+		context.LogError(err, "Unable to commit tenant settings.")
+		return monitor.Requeue(err, 0)
+	}
+	context.Logger(microappLog.EventTypeSuccess, microappLog.EventCodeActionComplete).Info().Msg("Finished adding new tenant settings")
+	return monitor.Ack()
 }
 
-func (handler *EventHandler) processTenantDelete(eventPayload *monitor.EventInfo) {
-	token, _ := microappSecurity.GetTokenFromRawAuthHeader(handler.app.Config, eventPayload.RawToken)
-	context := handler.app.NewExecutionContext(token, eventPayload.CorelationID, "tenantsettings.delete", true, false)
+func (handler *EventHandler) processTenantDelete(context microappCtx.ExecutionContext, tenantEvent *TenantEvent) monitor.Result {
 	uow := context.GetUOW()
-	defer uow.Complete()
-
-	eventData := make(map[string]string)
-	json.Unmarshal([]byte(eventPayload.Payload), &eventData)
-
-	tenantID, _ := uuid.FromString(eventData["id"])
-	if err := handler.repository.Delete(uow, tenantModel.TenantSettings{}, tenantID); err != nil {
-		context.Logger(microappLog.EventTypeServiceDataReplication, "Key_TenantDataReplication").Error().Err(err).Str("forTenant", tenantID.String()).Msg("Unable to delete tenant.")
-		return
+	if err := handler.repository.Delete(uow, tenantModel.TenantSettings{}, tenantEvent.ID); err != nil {
+		context.Logger(microappLog.EventTypeServiceDataReplication, "Key_TenantDataReplication").Error().Err(err).Str("forTenant", tenantEvent.ID.String()).Msg("Unable to delete tenant.")
+		return monitor.Requeue(err, 0)
 	}
 
-	uow.Commit()
+	if err := uow.TryCommit(); err != nil {
+		context.Logger(microappLog.EventTypeServiceDataReplication, "Key_TenantDataReplication").Error().Err(err).Str("forTenant", tenantEvent.ID.String()).Msg("Unable to commit tenant deletion.")
+		return monitor.Requeue(err, 0)
+	}
 	context.LoggerEventActionCompletion().Msg("Tenant deleted.")
+	return monitor.Ack()
 }
 
 func (handler *EventHandler) checkAndInitializeSettingsMetadata() error {
@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 
+	"github.com/islax/microapp"
+	"github.com/islax/microapp/cache"
 	"github.com/islax/microapp/config"
+	microappError "github.com/islax/microapp/error"
 	"github.com/islax/microapp/repository"
 	"github.com/islax/microapp/settingsmetadata/model"
 	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
 )
 
 //TenantSettingsRepository
@@ -23,13 +28,167 @@ func NewTenantSettingsRepository(config *config.Config) TenantSettingsRepository
 	return &gormTenantSettingsRepository{Config: config}
 }
 
+// NewCachedTenantSettingsRepository returns the repository whose GetTenantSettings in the read-only units of work are cached,
+// the settings of a tenant are invalidated once the unit of work updating or deleting them is committed.
+// The invalidation of an in-memory cache is local to the instance, use memcached or a short time to live when the app runs multiple instances.
+func NewCachedTenantSettingsRepository(config *config.Config, client *cache.Client) TenantSettingsRepository {
+	return &gormTenantSettingsRepository{Config: config, cache: client}
+}
+
+// NewAuditedTenantSettingsRepository returns the repository which records the changes of the tenant settings in the audit trail of the app,
+// changes are not recorded if ISLA_AUDIT_TRAIL is not set
+func NewAuditedTenantSettingsRepository(app *microapp.App) TenantSettingsRepository {
+	return newAppTenantSettingsRepository(app, nil)
+}
+
+// NewCachedAuditedTenantSettingsRepository returns the repository whose GetTenantSettings are cached like NewCachedTenantSettingsRepository in the cache of the app,
+// and whose changes are recorded like NewAuditedTenantSettingsRepository.
+// Without memcached the other instances may read the stale settings for up to ISLA_CACHE_LRU_TTL.
+func NewCachedAuditedTenantSettingsRepository(app *microapp.App) TenantSettingsRepository {
+	return newAppTenantSettingsRepository(app, app.Cache())
+}
+
+func newAppTenantSettingsRepository(app *microapp.App, client *cache.Client) TenantSettingsRepository {
+	tenantRepository := &gormTenantSettingsRepository{Config: app.Config, cache: client}
+	if recorder := app.AuditRecorder(); recorder != nil {
+		tenantRepository.SetChangeRecorder(recorder)
+	}
+	return tenantRepository
+}
+
 type gormTenantSettingsRepository struct {
 	repository.GormRepository
 	settingsMetadatas []model.SettingsMetaData
 	*config.Config
+	cache *cache.Client
 }
 
 func (tenantRepository *gormTenantSettingsRepository) GetTenantSettings(uow *repository.UnitOfWork, tenantID uuid.UUID) (map[string]string, error) {
+	if tenantRepository.cache == nil || !uow.IsReadOnly() {
+		return tenantRepository.readTenantSettings(uow, tenantID)
+	}
+	value, err := tenantRepository.cache.GetOrLoad(tenantRepository.cacheKey(tenantID), func() ([]byte, error) {
+		settings, err := tenantRepository.readTenantSettings(uow, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	if err := json.Unmarshal(value, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Add the tenant settings, the cached defaults of the tenant are invalidated once the unit of work is committed
+func (tenantRepository *gormTenantSettingsRepository) Add(uow *repository.UnitOfWork, entity interface{}) microappError.DatabaseError {
+	if err := tenantRepository.GormRepository.Add(uow, entity); err != nil {
+		return err
+	}
+	return tenantRepository.invalidateAfterCommit(uow, entity)
+}
+
+// AddBatch adds the tenant settings (slice of entities), the cached defaults of the tenants are invalidated once the unit of work is committed
+func (tenantRepository *gormTenantSettingsRepository) AddBatch(uow *repository.UnitOfWork, entities interface{}, options repository.BatchOptions) (repository.BatchResult, microappError.DatabaseError) {
+	result, err := tenantRepository.GormRepository.AddBatch(uow, entities, options)
+	if invalidateErr := tenantRepository.invalidateAfterCommit(uow, entities); err == nil {
+		err = invalidateErr
+	}
+	return result, err
+}
+
+// UpdateBatch updates the tenant settings (slice of entities), they are invalidated in cache once the unit of work is committed
+func (tenantRepository *gormTenantSettingsRepository) UpdateBatch(uow *repository.UnitOfWork, entities interface{}, options repository.BatchOptions) (repository.BatchResult, microappError.DatabaseError) {
+	result, err := tenantRepository.GormRepository.UpdateBatch(uow, entities, options)
+	if invalidateErr := tenantRepository.invalidateAfterCommit(uow, entities); err == nil {
+		err = invalidateErr
+	}
+	return result, err
+}
+
+// UpsertBatch upserts the tenant settings (slice of entities), they are invalidated in cache once the unit of work is committed
+func (tenantRepository *gormTenantSettingsRepository) UpsertBatch(uow *repository.UnitOfWork, entities interface{}, options repository.BatchOptions) (repository.BatchResult, microappError.DatabaseError) {
+	result, err := tenantRepository.GormRepository.UpsertBatch(uow, entities, options)
+	if invalidateErr := tenantRepository.invalidateAfterCommit(uow, entities); err == nil {
+		err = invalidateErr
+	}
+	return result, err
+}
+
+// Update the tenant settings, they are invalidated in cache once the unit of work is committed
+func (tenantRepository *gormTenantSettingsRepository) Update(uow *repository.UnitOfWork, entity interface{}) microappError.DatabaseError {
+	if err := tenantRepository.GormRepository.Update(uow, entity); err != nil {
+		return err
+	}
+	return tenantRepository.invalidateAfterCommit(uow, entity)
+}
+
+// Upsert the tenant settings, they are invalidated in cache once the unit of work is committed
+func (tenantRepository *gormTenantSettingsRepository) Upsert(uow *repository.UnitOfWork, entity interface{}, queryProcessors []repository.QueryProcessor) microappError.DatabaseError {
+	if err := tenantRepository.GormRepository.Upsert(uow, entity, queryProcessors); err != nil {
+		return err
+	}
+	return tenantRepository.invalidateAfterCommit(uow, entity)
+}
+
+// Delete the tenant settings, they are invalidated in cache once the unit of work is committed
+func (tenantRepository *gormTenantSettingsRepository) Delete(uow *repository.UnitOfWork, entity interface{}, where ...interface{}) microappError.DatabaseError {
+	if err := tenantRepository.invalidateAfterCommit(uow, entity, where...); err != nil {
+		return err
+	}
+	return tenantRepository.GormRepository.Delete(uow, entity, where...)
+}
+
+func (tenantRepository *gormTenantSettingsRepository) cacheKey(tenantID uuid.UUID) string {
+	return tenantRepository.cache.Key(tenantID, "tenantsettings")
+}
+
+// invalidateAfterCommit removes the settings of the tenants from cache once the unit of work is committed.
+// The entities may be a tenant settings or a slice of them, by value or pointer. The tenants are looked up by the conditions,
+// i.e. the id or the where clause, if the entity has no id.
+func (tenantRepository *gormTenantSettingsRepository) invalidateAfterCommit(uow *repository.UnitOfWork, entities interface{}, where ...interface{}) microappError.DatabaseError {
+	if tenantRepository.cache == nil {
+		return nil
+	}
+	tenantIDs := make([]uuid.UUID, 0)
+	records := reflect.Indirect(reflect.ValueOf(entities))
+	if records.Kind() == reflect.Slice {
+		for i := 0; i < records.Len(); i++ {
+			if tenant, ok := reflect.Indirect(records.Index(i)).Interface().(model.TenantSettings); ok && tenant.ID != uuid.Nil {
+				tenantIDs = append(tenantIDs, tenant.ID)
+			}
+		}
+	} else if tenant, ok := records.Interface().(model.TenantSettings); !ok {
+		return nil
+	} else if tenant.ID != uuid.Nil {
+		tenantIDs = append(tenantIDs, tenant.ID)
+	} else if len(where) > 0 {
+		matching := make([]model.TenantSettings, 0)
+		if err := uow.DB.Session(&gorm.Session{NewDB: true}).Select("id").Find(&matching, where...).Error; err != nil {
+			return microappError.NewDatabaseError(err)
+		}
+		for _, tenant := range matching {
+			tenantIDs = append(tenantIDs, tenant.ID)
+		}
+	}
+	if len(tenantIDs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		keys = append(keys, tenantRepository.cacheKey(tenantID))
+	}
+	client := tenantRepository.cache
+	uow.AfterCommit(func() { client.Invalidate(keys...) })
+	return nil
+}
+
+func (tenantRepository *gormTenantSettingsRepository) readTenantSettings(uow *repository.UnitOfWork, tenantID uuid.UUID) (map[string]string, error) {
 	tenant := model.TenantSettings{}
 	tenant.ID = tenantID
 	queryProcessor := []repository.QueryProcessor{repository.Filter("id = ?", tenantID)}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/islax/microapp/audit"
+	"github.com/islax/microapp/cache"
+	"github.com/islax/microapp/config"
+	"github.com/islax/microapp/log"
+	microappModel "github.com/islax/microapp/model"
+	"github.com/islax/microapp/repository"
+	"github.com/islax/microapp/settingsmetadata/model"
+	"github.com/rs/zerolog"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCachedTenantSettings(t *testing.T) {
+	metadataPath := filepath.Join(t.TempDir(), "settingsmetadata.json")
+	if err := os.WriteFile(metadataPath, []byte(`[{"code": "theme", "type": "string", "default": "light", "required": true, "settingsLevel": "tenant", "accessLevel": "E"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.TenantSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	tenantRepository := NewCachedTenantSettingsRepository(config.NewConfig(map[string]interface{}{config.EvSuffixForSettingsMetadataPath: metadataPath}), cache.NewClient(cache.NewLRU(10), "test", time.Minute))
+	newUOW := func(readOnly bool) *repository.UnitOfWork {
+		return repository.NewUnitOfWork(db, readOnly, zerolog.New(os.Stdout), log.Config{})
+	}
+	theme := func(tenantID uuid.UUID) string {
+		settings, err := tenantRepository.GetTenantSettings(newUOW(true), tenantID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return settings["theme"]
+	}
+
+	tenantID := uuid.NewV4()
+	if value := theme(tenantID); value != "light" {
+		t.Fatalf("Expected default theme cached, got %v", value)
+	}
+	uow := newUOW(false)
+	if err := tenantRepository.Add(uow, &model.TenantSettings{Base: microappModel.Base{ID: tenantID}, Settings: `{"theme": "dark"}`}); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+	if value := theme(tenantID); value != "dark" {
+		t.Errorf("Expected added settings after commit, got %v", value)
+	}
+
+	uow = newUOW(false)
+	if err := tenantRepository.Delete(uow, model.TenantSettings{}, tenantID); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+	if value := theme(tenantID); value != "light" {
+		t.Errorf("Expected default theme after delete by id, got %v", value)
+	}
+
+	otherTenantID := uuid.NewV4()
+	theme(otherTenantID)
+	uow = newUOW(false)
+	if _, err := tenantRepository.AddBatch(uow, []*model.TenantSettings{{Base: microappModel.Base{ID: otherTenantID}, Settings: `{"theme": "dark"}`}}, repository.BatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+	if value := theme(otherTenantID); value != "dark" {
+		t.Errorf("Expected batch added settings after commit, got %v", value)
+	}
+
+	uow = newUOW(false)
+	if _, err := tenantRepository.UpsertBatch(uow, []*model.TenantSettings{{Base: microappModel.Base{ID: otherTenantID}, Settings: `{"theme": "blue"}`}}, repository.BatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	uow.Commit()
+	if value := theme(otherTenantID); value != "blue" {
+		t.Errorf("Expected upserted settings after commit, got %v", value)
+	}
+}
+
+func TestAuditedTenantSettings(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+uuid.NewV4().String()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.TenantSettings{}, &audit.Entry{}); err != nil {
+		t.Fatal(err)
+	}
+	recorder := audit.NewRecorder(audit.Config{})
+	tenantRepository := NewTenantSettingsRepository(config.NewConfig(map[string]interface{}{}))
+	tenantRepository.(*gormTenantSettingsRepository).SetChangeRecorder(recorder)
+
+	tenantID := uuid.NewV4()
+	for _, settings := range []string{`{"theme": "dark"}`, `{"theme": "blue"}`} {
+		uow := repository.NewUnitOfWork(db, false, zerolog.New(os.Stdout), log.Config{})
+		if _, err := tenantRepository.UpsertBatch(uow, []*model.TenantSettings{{Base: microappModel.Base{ID: tenantID}, Settings: settings}}, repository.BatchOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		uow.Commit()
+	}
+
+	entries, err := recorder.EntityTrail(repository.NewUnitOfWork(db, true, zerolog.New(os.Stdout), log.Config{}), "tenant_settings", tenantID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make(map[string]string)
+	for _, entry := range entries {
+		actions[entry.Action] = entry.Changes
+	}
+	if len(entries) != 2 || actions[repository.ChangeActionCreate] == "" || actions[repository.ChangeActionUpdate] != `{"settings":{"old":"{\"theme\": \"dark\"}","new":"{\"theme\": \"blue\"}"}}` {
+		t.Errorf("Expected settings update to be recorded in the audit trail, got %+v", entries)
+	}
+}
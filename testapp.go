@@ -18,13 +18,15 @@ import (
 
 	"gorm.io/gorm/schema"
 
+	"github.com/islax/microapp/dialect"
 	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/event"
+	"github.com/islax/microapp/event/transport"
 
 	uuid "github.com/satori/go.uuid"
 
 	jwt "github.com/golang-jwt/jwt"
 	"github.com/rs/zerolog"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -50,7 +52,12 @@ func NewTestApp(appName string, controllerRouteProvider func(*App) []RouteSpecif
 
 	dbConf.NamingStrategy = schema.NamingStrategy{SingularTable: isSingularTable}
 
-	db, err := gorm.Open(sqlite.Open(dbFile), dbConf)
+	// Opened through the same dialect abstraction as the app, with ISLA_DB_DIALECT set to sqlite
+	dbDialect, err := dialect.New(dialect.SQLite)
+	if err != nil {
+		panic(err)
+	}
+	db, err := dialect.Open(dbDialect, dbDialect.DSN(dialect.Connection{Name: dbFile}), dbConf, dialect.PoolConfig{MaxOpenConnections: 15, MaxIdleConnections: 0, ConnectionLifetime: time.Minute * 5})
 	if err != nil {
 		panic(err)
 	}
@@ -60,13 +67,14 @@ func NewTestApp(appName string, controllerRouteProvider func(*App) []RouteSpecif
 	}
 
 	sqlDB.Exec("PRAGMA journal_mode=WAL;")
-	sqlDB.SetMaxOpenConns(15)
-	sqlDB.SetMaxIdleConns(0)
-	sqlDB.SetConnMaxLifetime(time.Minute * 5)
 
 	rand.Seed(time.Now().UnixNano())
 	randomAPIPort := fmt.Sprintf("10%v%v%v", rand.Intn(9), rand.Intn(9), rand.Intn(9)) // Generating random API port so that if multiple tests can run parallel
-	application := New(appName, map[string]interface{}{"API_PORT": randomAPIPort, "JWT_PRIVATE_KEY_PATH": "certs/star.dev.local.key", "JWT_PUBLIC_KEY_PATH": "certs/star.dev.local.crt"}, zerolog.New(os.Stdout), db, nil, nil)
+	application := New(appName, map[string]interface{}{"API_PORT": randomAPIPort, "DB_DIALECT": dialect.SQLite, "JWT_PRIVATE_KEY_PATH": "certs/star.dev.local.key", "JWT_PUBLIC_KEY_PATH": "certs/star.dev.local.crt", "EVENT_TRANSPORT": transport.Memory}, zerolog.New(os.Stdout), db, nil, nil)
+	// Events are published through an in-process bus so that event flows can be tested without a broker
+	if err := application.UseEventTransport(transport.NewMemoryTransport(event.NewMemoryBus())); err != nil {
+		panic(err)
+	}
 
 	return &TestApp{application: application, controllerRouteProvider: controllerRouteProvider, dbInitializer: dbInitializer}
 }
@@ -82,7 +90,7 @@ func (testApp *TestApp) Initialize() {
 func (testApp *TestApp) Stop() {
 	testApp.application.Stop()
 	sqlDB, err := testApp.application.DB.DB()
-	if err != nil {
+	if err == nil {
 		sqlDB.Close()
 	}
 	os.Remove("./test_islax.db")
@@ -336,6 +344,11 @@ func (testApp *TestApp) GetToken(tenantID string, userID string, scope []string)
 	return testApp.generateToken(tenantID, userID, userID, userID, uuid.Nil.String(), "", scope, false)
 }
 
+// PublishEvent publishes the event on the in-process bus of the test app
+func (testApp *TestApp) PublishEvent(token string, correlationID string, topic string, payload interface{}) {
+	testApp.application.DispatchEvent(token, correlationID, topic, payload)
+}
+
 // SaveToDB saves the entity to database
 func (testApp *TestApp) SaveToDB(entity interface{}) error {
 	return testApp.application.DB.Create(entity).Error
@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headersCarrier adapts the AMQP headers to propagation.TextMapCarrier
+type headersCarrier map[string]interface{}
+
+func (carrier headersCarrier) Get(key string) string {
+	value, _ := carrier[key].(string)
+	return value
+}
+
+func (carrier headersCarrier) Set(key string, value string) {
+	carrier[key] = value
+}
+
+func (carrier headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func messagingAttributes(routingKey string, messageID string) trace.SpanStartOption {
+	return trace.WithAttributes(
+		semconv.MessagingSystemKey.String("rabbitmq"),
+		semconv.MessagingDestinationKindTopic,
+		semconv.MessagingDestinationKey.String(routingKey),
+		semconv.MessagingMessageIDKey.String(messageID),
+	)
+}
+
+// InjectProducerSpan records a producer span for the event being published and returns the headers carrying its trace context.
+// Nothing is returned if the context is not part of a trace.
+func InjectProducerSpan(ctx context.Context, routingKey string, messageID string) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	ctx, span := Tracer().Start(ctx, routingKey+" send", trace.WithSpanKind(trace.SpanKindProducer), messagingAttributes(routingKey, messageID))
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// ExtractHeaders returns the context carrying the trace context propagated in the AMQP headers
+func ExtractHeaders(ctx context.Context, headers map[string]interface{}) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headersCarrier(headers))
+}
+
+// StartConsumerSpan starts the span for processing the received event, ctx should carry the trace context extracted from its headers
+func StartConsumerSpan(ctx context.Context, routingKey string, messageID string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, routingKey+" process", trace.WithSpanKind(trace.SpanKindConsumer), messagingAttributes(routingKey, messageID), trace.WithAttributes(semconv.MessagingOperationProcess))
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceIsPropagatedThroughHeaders(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if headers := InjectProducerSpan(context.Background(), "tenant.added", "1"); headers != nil {
+		t.Errorf("Expected no headers outside a trace, got %v", headers)
+	}
+
+	ctx, parent := Tracer().Start(context.Background(), "handler")
+	traceHeaders := InjectProducerSpan(ctx, "tenant.added", "1")
+	parent.End()
+
+	headers := map[string]interface{}{"X-Correlation-ID": "correlation"}
+	for name, value := range traceHeaders {
+		headers[name] = value
+	}
+	consumerCtx, consumer := StartConsumerSpan(ExtractHeaders(context.Background(), headers), "tenant.added", "1")
+	consumer.End()
+
+	if TraceID(consumerCtx) != TraceID(ctx) {
+		t.Errorf("Expected consumer span in trace %v, got %v", TraceID(ctx), TraceID(consumerCtx))
+	}
+	spans := recorder.Ended()
+	if len(spans) != 3 || spans[2].Parent().SpanID() != spans[0].SpanContext().SpanID() {
+		t.Errorf("Expected consumer span to be child of producer span, got %v spans", len(spans))
+	}
+}
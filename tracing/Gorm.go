@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var rowsAffectedKey = attribute.Key("db.rows_affected")
+
+const (
+	gormSpanKey          = "microapp:tracing:span"
+	gormParentContextKey = "microapp:tracing:parentContext"
+)
+
+type gormPlugin struct {
+}
+
+// NewGormPlugin returns the gorm plugin which records a span for each statement executed within a trace.
+// Trace is taken from the context of the statement, see repository.NewUnitOfWorkWithContext.
+func NewGormPlugin() gorm.Plugin {
+	return &gormPlugin{}
+}
+
+func (plugin *gormPlugin) Name() string {
+	return "microapp:tracing"
+}
+
+func (plugin *gormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().Before("gorm:create").Register("tracing:before_create", plugin.before("create")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", plugin.after),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", plugin.before("query")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", plugin.after),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", plugin.before("update")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", plugin.after),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", plugin.before("delete")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", plugin.after),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", plugin.before("row")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", plugin.after),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", plugin.before("raw")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", plugin.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (plugin *gormPlugin) before(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		parentContext := db.Statement.Context
+		if parentContext == nil || !trace.SpanContextFromContext(parentContext).IsValid() {
+			return // Statements executed outside a trace are not recorded
+		}
+		ctx, span := Tracer().Start(parentContext, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemKey.String(db.Dialector.Name())))
+		db.InstanceSet(gormSpanKey, span)
+		db.InstanceSet(gormParentContextKey, parentContext)
+		db.Statement.Context = ctx
+	}
+}
+
+func (plugin *gormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	if parentContext, ok := db.InstanceGet(gormParentContextKey); ok {
+		db.Statement.Context = parentContext.(context.Context)
+	}
+
+	span.SetAttributes(semconv.DBStatementKey.String(db.Statement.SQL.String()), semconv.DBSQLTableKey.String(db.Statement.Table))
+	span.SetAttributes(rowsAffectedKey.Int64(db.Statement.RowsAffected))
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(code int) {
+	recorder.status = code
+	recorder.ResponseWriter.WriteHeader(code)
+}
+
+// Middleware starts a server span for each request, continuing the trace propagated in the request headers.
+// It should be used on mux router so that the span is named after the matched route.
+func Middleware(serverName string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.URL.Path
+			if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+				if pathTemplate, err := currentRoute.GetPathTemplate(); err == nil {
+					route = pathTemplate
+				}
+			}
+
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := Tracer().Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(serverName, route, r)...))
+			defer span.End()
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(recorder.status)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(recorder.status, trace.SpanKindServer))
+		})
+	}
+}
+
+// StartClientSpan starts a client span for the outgoing request and injects its trace context in the request headers
+func StartClientSpan(ctx context.Context, request *http.Request) (*http.Request, trace.Span) {
+	ctx, span := Tracer().Start(ctx, request.Method+" "+request.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(request)...))
+	request = request.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+	return request, span
+}
+
+// EndClientSpan records the response or error of the outgoing request and ends the span
+func EndClientSpan(span trace.Span, response *http.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if response != nil {
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(response.StatusCode)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(response.StatusCode, trace.SpanKindClient))
+	}
+	span.End()
+}
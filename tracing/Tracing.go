@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/islax/microapp/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone spans are not exported, trace context is still propagated
+	ExporterNone = "none"
+	// ExporterStdout spans are written to stdout as JSON
+	ExporterStdout = "stdout"
+	// ExporterFile spans are appended to a file as JSON
+	ExporterFile = "file"
+	// ExporterOTLP spans are exported to the OpenTelemetry collector over OTLP/HTTP
+	ExporterOTLP = "otlp"
+
+	instrumentationName = "github.com/islax/microapp"
+)
+
+// Config represents the tracing configuration
+type Config struct {
+	ServiceName  string
+	Exporter     string  // One of none, stdout, file and otlp
+	FilePath     string  // File to which the spans are appended by the file exporter
+	OTLPEndpoint string  // host:port of the collector, OTEL_EXPORTER_OTLP_ENDPOINT is used if empty
+	OTLPInsecure bool    // Export over http instead of https
+	SampleRatio  float64 // Ratio of the traces started by this app that are sampled, parent decision is honored for the propagated traces
+}
+
+// ConfigFromEnv reads the tracing configuration from the app config
+func ConfigFromEnv(serviceName string, appConfig *config.Config) Config {
+	return Config{
+		ServiceName:  serviceName,
+		Exporter:     strings.ToLower(appConfig.GetString(config.EvSuffixForTracingExporter)),
+		FilePath:     appConfig.GetString(config.EvSuffixForTracingFilePath),
+		OTLPEndpoint: appConfig.GetString(config.EvSuffixForTracingOTLPEndpoint),
+		OTLPInsecure: appConfig.GetBool(config.EvSuffixForTracingOTLPInsecure),
+		SampleRatio:  appConfig.GetFloat64(config.EvSuffixForTracingSampleRatio),
+	}
+}
+
+// Init sets up the global tracer provider with the configured exporter and the W3C trace context propagator.
+// Returned function flushes the pending spans and releases the exporter.
+func Init(cfg Config) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var closer io.Closer
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var file *os.File
+		if file, err = os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return nil, err
+		}
+		closer = file
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{}
+		if cfg.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter: %v", cfg.Exporter)
+	}
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the tracer used by the microapp instrumentation
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// TraceID returns the trace id of the span in the context, empty if there is no span
+func TraceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return ""
+}
+
+// SpanID returns the id of the span in the context, empty if there is no span
+func SpanID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasSpanID() {
+		return spanContext.SpanID().String()
+	}
+	return ""
+}
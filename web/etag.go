@@ -0,0 +1,87 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/model"
+)
+
+// ETag returns the strong entity tag of the version
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseETag returns the version of the entity tag, weak tags are accepted
+func ParseETag(etag string) (int64, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	return version, err == nil
+}
+
+// RespondJSONWithETag makes the response with payload as json format and adds ETag header of the version of the entity.
+// Responds 304 without payload if the If-None-Match header of the GET / HEAD request matches the version.
+func RespondJSONWithETag(w http.ResponseWriter, r *http.Request, status int, entity model.Versioned, payload interface{}) {
+	etag := ETag(entity.GetVersion())
+	w.Header().Set("ETag", etag)
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && matchesETag(r.Header.Get("If-None-Match"), entity.GetVersion()) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	RespondJSON(w, status, payload)
+}
+
+// ApplyIfMatch checks the If-Match header against the version of the entity read for the update, it returns HTTPError 412
+// if the header is not a valid entity tag or the entity is modified since, nothing is checked if the header is missing or "*".
+// The entity keeps the version read, so CheckVersionAndUpdate fails with ConflictError if the entity is modified before the update.
+func ApplyIfMatch(r *http.Request, entity model.Versioned) error {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+	for _, etag := range strings.Split(ifMatch, ",") {
+		if _, ok := ParseETag(etag); !ok {
+			return microappError.NewHTTPError(microappError.ErrorCodePreconditionFailed, http.StatusPreconditionFailed)
+		}
+	}
+	return CheckIfMatch(r, entity)
+}
+
+// CheckIfMatch returns HTTPError 412 if the If-Match header is set and does not match the current version of the entity
+func CheckIfMatch(r *http.Request, entity model.Versioned) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || matchesETag(ifMatch, entity.GetVersion()) {
+		return nil
+	}
+	return microappError.NewHTTPError(microappError.ErrorCodePreconditionFailed, http.StatusPreconditionFailed)
+}
+
+// CheckIfNoneMatch returns HTTPError 412 if the If-None-Match header of the write matches the current entity, nil if the entity does not exist.
+// "If-None-Match: *" makes the write fail if the entity exists.
+func CheckIfNoneMatch(r *http.Request, entity model.Versioned) error {
+	if entity == nil {
+		return nil
+	}
+	if matchesETag(r.Header.Get("If-None-Match"), entity.GetVersion()) {
+		return microappError.NewHTTPError(microappError.ErrorCodePreconditionFailed, http.StatusPreconditionFailed)
+	}
+	return nil
+}
+
+// matchesETag returns whether the comma separated list of entity tags has the version or is "*"
+func matchesETag(header string, version int64) bool {
+	for _, etag := range strings.Split(header, ",") {
+		if strings.TrimSpace(etag) == "*" {
+			return true
+		}
+		if tagVersion, ok := ParseETag(etag); ok && tagVersion == version {
+			return true
+		}
+	}
+	return false
+}
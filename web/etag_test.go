@@ -0,0 +1,59 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	microappError "github.com/islax/microapp/error"
+	"github.com/islax/microapp/model"
+)
+
+func TestETag(t *testing.T) {
+	entity := &model.RowVersion{Version: 3}
+
+	r := httptest.NewRequest(http.MethodGet, "/widgets/1", nil)
+	w := httptest.NewRecorder()
+	RespondJSONWithETag(w, r, http.StatusOK, entity, map[string]string{"name": "a"})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Errorf("Expected 200 with ETag \"3\", got %v, %v", w.Code, w.Header().Get("ETag"))
+	}
+
+	r.Header.Set("If-None-Match", `"2", W/"3"`)
+	w = httptest.NewRecorder()
+	RespondJSONWithETag(w, r, http.StatusOK, entity, map[string]string{"name": "a"})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected 304 without body, got %v, %v", w.Code, w.Body.String())
+	}
+
+	r = httptest.NewRequest(http.MethodPut, "/widgets/1", nil)
+	r.Header.Set("If-Match", `"2"`)
+	if err := CheckIfMatch(r, entity); err == nil || err.(microappError.HTTPError).HTTPStatus != http.StatusPreconditionFailed {
+		t.Errorf("Expected precondition failed, got %v", err)
+	}
+	if err := ApplyIfMatch(r, entity); err == nil || err.(microappError.HTTPError).HTTPStatus != http.StatusPreconditionFailed || entity.Version != 3 {
+		t.Errorf("Expected precondition failed for stale If-Match, got %v, %v", entity.Version, err)
+	}
+	r.Header.Set("If-Match", `"2", "3"`)
+	if err := ApplyIfMatch(r, entity); err != nil || entity.Version != 3 {
+		t.Errorf("Expected current If-Match to pass, got %v, %v", entity.Version, err)
+	}
+	r.Header.Set("If-Match", "invalid")
+	if err := ApplyIfMatch(r, entity); err == nil {
+		t.Error("Expected invalid If-Match to fail")
+	}
+
+	r.Header.Set("If-None-Match", "*")
+	if err := CheckIfNoneMatch(r, entity); err == nil {
+		t.Error("Expected If-None-Match * to fail for existing entity")
+	}
+	if err := CheckIfNoneMatch(r, nil); err != nil {
+		t.Errorf("Expected If-None-Match * to pass for missing entity, got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	RespondError(w, microappError.NewConflictError(nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for conflict error, got %v", w.Code)
+	}
+}
@@ -0,0 +1,80 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// projection is the tree of the requested fields, nil projection keeps the whole value
+type projection map[string]projection
+
+// Project returns the JSON of payload with only the requested fields, fields - ["id", "name", "owner.name"].
+// Nested fields are of the nested objects (e.g. preloaded associations), arrays are projected element wise. Whole payload is returned if no field is requested.
+func Project(payload interface{}, fields []string) (json.RawMessage, error) {
+	response, err := json.Marshal(payload)
+	if err != nil || len(fields) == 0 {
+		return response, err
+	}
+
+	tree := projection{}
+	for _, field := range fields {
+		node := tree
+		names := strings.Split(field, ".")
+		for i, name := range names {
+			child, ok := node[name]
+			if ok && child == nil {
+				// Parent is requested as a whole
+				break
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			if !ok {
+				child = projection{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(response))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree.apply(value))
+}
+
+// RespondJSONWithFields makes the response with payload projected on the requested fields as json format, see Project
+func RespondJSONWithFields(w http.ResponseWriter, status int, fields []string, payload interface{}) {
+	response, err := Project(payload, fields)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	RespondJSON(w, status, response)
+}
+
+func (tree projection) apply(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case []interface{}:
+		for i, element := range typedValue {
+			typedValue[i] = tree.apply(element)
+		}
+	case map[string]interface{}:
+		for name, fieldValue := range typedValue {
+			child, ok := tree[name]
+			if !ok {
+				delete(typedValue, name)
+			} else if child != nil {
+				typedValue[name] = child.apply(fieldValue)
+			}
+		}
+	}
+	return value
+}
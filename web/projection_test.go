@@ -0,0 +1,35 @@
+package web
+
+import (
+	"testing"
+)
+
+type owner struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Mail string `json:"mail"`
+}
+
+type item struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Size   int     `json:"size"`
+	Owner  owner   `json:"owner"`
+	Owners []owner `json:"owners"`
+}
+
+func TestProject(t *testing.T) {
+	items := []item{{ID: "1", Name: "a", Size: 10, Owner: owner{"o1", "x", "x@y"}, Owners: []owner{{"o2", "y", "y@z"}}}}
+	tests := map[string][]string{
+		`[{"id":"1","name":"a","size":10,"owner":{"id":"o1","name":"x","mail":"x@y"},"owners":[{"id":"o2","name":"y","mail":"y@z"}]}]`: nil,
+		`[{"id":"1","size":10}]`: {"id", "size", "unknown"},
+		`[{"name":"a","owner":{"name":"x"},"owners":[{"mail":"y@z"}]}]`: {"name", "owner.name", "owners.mail"},
+		`[{"owner":{"id":"o1","mail":"x@y","name":"x"}}]`:               {"owner.name", "owner"},
+	}
+	for expected, fields := range tests {
+		projected, err := Project(items, fields)
+		if err != nil || string(projected) != expected {
+			t.Errorf("%v: expected %v, got %v %v", fields, expected, string(projected), err)
+		}
+	}
+}
@@ -42,14 +42,21 @@ func RespondErrorMessage(w http.ResponseWriter, code int, message string) {
 
 // RespondError returns a validation error else
 func RespondError(w http.ResponseWriter, err error) {
-	switch err.(type) {
+	switch e := err.(type) {
+	case microappError.ConflictError:
+		RespondErrorMessage(w, http.StatusConflict, microappError.ErrorCodeConflict)
+	case microappError.ConstraintViolationError:
+		status := http.StatusBadRequest
+		if e.Kind == microappError.ViolationDuplicateKey || e.Kind == microappError.ViolationReferenced {
+			status = http.StatusConflict
+		}
+		RespondJSON(w, status, e.ValidationError())
 	case microappError.ValidationError:
-		RespondJSON(w, http.StatusBadRequest, err)
+		RespondJSON(w, http.StatusBadRequest, e)
 	case microappError.HTTPResourceNotFound:
-		RespondJSON(w, http.StatusNotFound, err)
+		RespondJSON(w, http.StatusNotFound, e)
 	case microappError.HTTPError:
-		httpError := err.(microappError.HTTPError)
-		RespondErrorMessage(w, httpError.HTTPStatus, httpError.ErrorKey)
+		RespondErrorMessage(w, e.HTTPStatus, e.ErrorKey)
 	default:
 		RespondErrorMessage(w, http.StatusInternalServerError, microappError.ErrorCodeInternalError)
 	}
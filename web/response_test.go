@@ -0,0 +1,43 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	microappError "github.com/islax/microapp/error"
+)
+
+func TestRespondErrorConstraintViolation(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		errors map[string]string
+	}{
+		{microappError.NewConstraintViolationError(microappError.ViolationDuplicateKey, "idx_widgets_name", "", errors.New("duplicate")), http.StatusConflict, map[string]string{"payload": microappError.ErrorCodeDuplicateValue}},
+		{microappError.NewConstraintViolationError(microappError.ViolationDuplicateKey, "idx_widgets_code", "", errors.New("duplicate")), http.StatusConflict, map[string]string{"code": microappError.ErrorCodeDuplicateValue}},
+		{microappError.NewConstraintViolationError(microappError.ViolationReferenced, "fk_orders_customer", "customerId", errors.New("referenced")), http.StatusConflict, map[string]string{"payload": microappError.ErrorCodeInvalidReference}},
+		{microappError.NewConstraintViolationError(microappError.ViolationForeignKey, "fk_orders_customer", "customerId", errors.New("fk")), http.StatusBadRequest, map[string]string{"customerId": microappError.ErrorCodeInvalidReference}},
+		{microappError.NewConstraintViolationError(microappError.ViolationDataTooLong, "", "name", errors.New("too long")), http.StatusBadRequest, map[string]string{"name": microappError.ErrorCodeTooLong}},
+		{microappError.NewConstraintViolationError(microappError.ViolationNotNull, "", "name", errors.New("null")), http.StatusBadRequest, map[string]string{"name": microappError.ErrorCodeRequired}},
+	}
+	microappError.RegisterConstraintField("idx_widgets_code", "code")
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		RespondError(recorder, test.err)
+		var body microappError.ValidationError
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if recorder.Code != test.status || body.ErrorKey != microappError.ErrorCodeInvalidFields || len(body.Errors) != 1 {
+			t.Errorf("Expected %v with field errors %v, got %v %v", test.status, test.errors, recorder.Code, body)
+		}
+		for field, code := range test.errors {
+			if body.Errors[field] != code {
+				t.Errorf("Expected %v on %v, got %v", code, field, body.Errors)
+			}
+		}
+	}
+}